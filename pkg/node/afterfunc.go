@@ -0,0 +1,9 @@
+//go:build go1.21
+
+package node
+
+import "context"
+
+func afterFunc(ctx context.Context, f func()) func() bool {
+	return context.AfterFunc(ctx, f)
+}
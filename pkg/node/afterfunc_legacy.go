@@ -0,0 +1,36 @@
+//go:build !go1.21
+
+package node
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+const (
+	afterFuncPending = iota
+	afterFuncRunning
+	afterFuncStopped
+)
+
+// afterFunc mimics the context.AfterFunc function of Go 1.21
+func afterFunc(ctx context.Context, f func()) func() bool {
+	state := new(int32)
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			if atomic.CompareAndSwapInt32(state, afterFuncPending, afterFuncRunning) {
+				f()
+			}
+		case <-stopped:
+		}
+	}()
+	return func() bool {
+		if atomic.CompareAndSwapInt32(state, afterFuncPending, afterFuncStopped) {
+			close(stopped)
+			return true
+		}
+		return false
+	}
+}
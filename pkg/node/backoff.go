@@ -0,0 +1,84 @@
+package node
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// BackoffPolicy provides the delays between the successive attempts of an operation that is
+// retried (e.g. the reconnections of a NetworkSink). It can be shared by multiple nodes, so its
+// implementations must be safe for concurrent use.
+type BackoffPolicy interface {
+	// Delay returns the time to wait before the given retry attempt, starting at 1 for the first
+	// retry after the initial attempt failed
+	Delay(attempt int) time.Duration
+}
+
+// NewConstantBackoff returns a BackoffPolicy that always waits the same delay between attempts
+func NewConstantBackoff(delay time.Duration) BackoffPolicy {
+	if delay < 0 {
+		panic("backoff delay can't be negative")
+	}
+	return constantBackoff(delay)
+}
+
+type constantBackoff time.Duration
+
+func (c constantBackoff) Delay(int) time.Duration {
+	return time.Duration(c)
+}
+
+// NewExponentialBackoff returns a BackoffPolicy whose delay starts at initial and is multiplied
+// by factor after each attempt, up to max.
+// If jitter is greater than zero, each delay is randomly shifted up to the given fraction of
+// it (e.g. a jitter of 0.2 returns delays within ±20% of the exponential delay, but never
+// greater than max), so multiple clients that fail at the same time do not retry in lockstep.
+// The node.RandSeed or node.WithRandSource options allow making the jitter reproducible.
+// It panics if initial is not positive, max is lower than initial, factor is lower than 1 or
+// jitter is not between 0 and 1.
+func NewExponentialBackoff(initial, max time.Duration, factor float64, jitter float64, opts ...Option) BackoffPolicy {
+	if initial <= 0 {
+		panic("initial backoff delay must be greater than zero")
+	}
+	if max < initial {
+		panic("maximum backoff delay can't be lower than the initial delay")
+	}
+	if factor < 1 {
+		panic("backoff factor can't be lower than 1")
+	}
+	if jitter < 0 || jitter > 1 {
+		panic("backoff jitter must be between 0 and 1")
+	}
+	b := &exponentialBackoff{initial: initial, max: max, factor: factor, jitter: jitter}
+	if jitter > 0 {
+		options := getOptions(opts...)
+		b.rnd = options.random()
+	}
+	return b
+}
+
+type exponentialBackoff struct {
+	initial time.Duration
+	max     time.Duration
+	factor  float64
+	jitter  float64
+	// rand.Rand is not safe for concurrent use
+	rndMt sync.Mutex
+	rnd   *rand.Rand
+}
+
+func (e *exponentialBackoff) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := math.Min(float64(e.initial)*math.Pow(e.factor, float64(attempt-1)), float64(e.max))
+	if e.rnd != nil {
+		e.rndMt.Lock()
+		shift := e.jitter * (2*e.rnd.Float64() - 1)
+		e.rndMt.Unlock()
+		delay = math.Min(delay*(1+shift), float64(e.max))
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,68 @@
+package node
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// backoffFunc is a BackoffPolicy implemented by a function
+type backoffFunc func(attempt int) time.Duration
+
+func (f backoffFunc) Delay(attempt int) time.Duration {
+	return f(attempt)
+}
+
+func TestConstantBackoff(t *testing.T) {
+	b := NewConstantBackoff(time.Second)
+	for attempt := 1; attempt <= 3; attempt++ {
+		assert.Equal(t, time.Second, b.Delay(attempt))
+	}
+	assert.Panics(t, func() { NewConstantBackoff(-time.Second) })
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := NewExponentialBackoff(100*time.Millisecond, time.Second, 3, 0)
+	var delays []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		delays = append(delays, b.Delay(attempt))
+	}
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second, time.Second,
+	}, delays)
+}
+
+func TestExponentialBackoff_Jitter(t *testing.T) {
+	b := NewExponentialBackoff(100*time.Millisecond, time.Second, 2, 0.5)
+	distinct := map[time.Duration]struct{}{}
+	for i := 0; i < 100; i++ {
+		d := b.Delay(2)
+		assert.GreaterOrEqual(t, d, 100*time.Millisecond)
+		assert.LessOrEqual(t, d, 300*time.Millisecond)
+		distinct[d] = struct{}{}
+		// the jitter never exceeds the maximum delay
+		assert.LessOrEqual(t, b.Delay(10), time.Second)
+	}
+	assert.Greater(t, len(distinct), 1)
+}
+
+func TestExponentialBackoff_RandSource(t *testing.T) {
+	delays := func() []time.Duration {
+		b := NewExponentialBackoff(100*time.Millisecond, time.Second, 2, 0.5, WithRandSource(rand.NewSource(7)))
+		var delays []time.Duration
+		for attempt := 1; attempt <= 5; attempt++ {
+			delays = append(delays, b.Delay(attempt))
+		}
+		return delays
+	}
+	assert.Equal(t, delays(), delays())
+}
+
+func TestExponentialBackoff_InvalidArgs(t *testing.T) {
+	assert.Panics(t, func() { NewExponentialBackoff(0, time.Second, 2, 0) })
+	assert.Panics(t, func() { NewExponentialBackoff(time.Second, time.Millisecond, 2, 0) })
+	assert.Panics(t, func() { NewExponentialBackoff(time.Millisecond, time.Second, 0.5, 0) })
+	assert.Panics(t, func() { NewExponentialBackoff(time.Millisecond, time.Second, 2, 1.5) })
+}
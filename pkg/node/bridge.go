@@ -0,0 +1,48 @@
+package node
+
+import "context"
+
+// Bridge returns a sink and a source node that are linked by an internal channel, so two graphs
+// that are built independently (e.g. by different packages) can be composed at a typed boundary:
+// the sink is the Terminal node of the first graph, and the source is the Start node of the
+// second graph. All the items that the sink receives are sent by the source, and the source
+// finishes when the input of the sink is closed, so the completion of the first graph is
+// propagated to the second one.
+// The sink blocks until the source is started and its items can be forwarded. If the context of
+// the source is cancelled, the sink discards the rest of its input.
+// The options are applied to the sink node (e.g. node.ChannelBufferLen to buffer the items
+// between the graphs).
+func Bridge[T any](opts ...Option) (sink *Terminal[T], source *Start[T]) {
+	ch := make(chan T)
+	stopped := make(chan struct{})
+	sink = AsTerminal(func(in <-chan T) {
+		defer close(ch)
+		for item := range in {
+			select {
+			case ch <- item:
+			case <-stopped:
+				drain(in)
+				return
+			}
+		}
+	}, opts...)
+	source = AsStartCtx(func(ctx context.Context, out chan<- T) {
+		defer close(stopped)
+		for {
+			select {
+			case item, ok := <-ch:
+				if !ok {
+					return
+				}
+				select {
+				case out <- item:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return sink, source
+}
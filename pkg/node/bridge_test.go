@@ -0,0 +1,55 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBridge(t *testing.T) {
+	sink, source := Bridge[int]()
+
+	// first graph
+	start := AsStart(Counter(1, 5))
+	odds := AsMiddle(OddFilter)
+	start.SendsTo(odds)
+	odds.SendsTo(sink)
+	first := NewGraph(start, odds, sink)
+
+	// second graph
+	var received []string
+	msg := AsMiddle(Messager("odd"))
+	term := collectStrings(&received)
+	source.SendsTo(msg)
+	msg.SendsTo(term)
+	second := NewGraph(source, msg, term)
+
+	require.NoError(t, first.Start())
+	require.NoError(t, second.Start())
+	waitDone(t, first.Done())
+	// the completion of the first graph finishes the second one
+	waitDone(t, second.Done())
+	assert.Equal(t, []string{"odd: 1", "odd: 3", "odd: 5"}, received)
+}
+
+func TestBridge_SourceCancelled(t *testing.T) {
+	sink, source := Bridge[int]()
+	start := AsStart(Counter(1, 1000))
+	start.SendsTo(sink)
+
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	source.SendsTo(term)
+	ctx, cancel := context.WithCancel(context.Background())
+	source.StartCtx(ctx)
+	start.Start()
+	cancel()
+
+	// the first graph is not blocked by the cancelled second graph
+	waitDone(t, sink.Done())
+	waitDone(t, term.Done())
+}
@@ -0,0 +1,188 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Broadcaster is a receiver node that forwards the received items to a dynamic set of
+// subscribers, which can attach and detach at any time, even after the node has been started.
+// It retains the last received items, so each new subscriber is replayed the recent history
+// before receiving the live items.
+type Broadcaster[T any] struct {
+	nodeMeta
+	receiverBase[T]
+	subBufferLen int
+	done         chan struct{}
+
+	mt sync.Mutex
+	// ring buffer with the last received items
+	history []T
+	size    int
+	next    int
+	closed  bool
+	subs    map[*subscription[T]]struct{}
+}
+
+type subscription[T any] struct {
+	// guards the sends to ch against its concurrent closing
+	mt        sync.Mutex
+	ch        chan T
+	closed    bool
+	cancelled chan struct{}
+	cancel    sync.Once
+}
+
+// send forwards the item to the subscriber, unless its subscription is cancelled
+func (s *subscription[T]) send(item T) {
+	s.mt.Lock()
+	defer s.mt.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- item:
+	case <-s.cancelled:
+	}
+}
+
+func (s *subscription[T]) close() {
+	s.mt.Lock()
+	defer s.mt.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// ReplayBroadcaster returns a Broadcaster that retains the last history items, which are
+// replayed to each new subscriber before the items that are received after the subscription.
+// A history of 0 does not replay any item.
+// The node.ChannelBufferLen option sets the length of the input channel of the node, and
+// the buffer of each subscriber channel, in addition to the replayed items.
+// It panics if it is created with the node.WithItemPool option, as the pooled items would be
+// retained by the history and by subscribers outside the graph.
+func ReplayBroadcaster[T any](history int, opts ...Option) *Broadcaster[T] {
+	if history < 0 {
+		panic("broadcaster history can't be negative")
+	}
+	options := getOptions(opts...)
+	if options.onFork != nil {
+		panic("broadcaster does not support the WithItemPool option")
+	}
+	receiver := newReceiverBase[T](&options)
+	if options.name == "" {
+		options.name = fmt.Sprintf("Broadcaster[%v]", receiver.inType)
+	}
+	b := &Broadcaster[T]{
+		nodeMeta:     newNodeMeta(&options, KindTerminal, Schema{In: receiver.inType}),
+		receiverBase: receiver,
+		subBufferLen: options.channelBufferLen,
+		done:         make(chan struct{}),
+		history:      make([]T, history),
+		subs:         map[*subscription[T]]struct{}{},
+	}
+	b.inputDone = b.receiverBase.inputClosed
+	return b
+}
+
+// Subscribe returns a channel that first receives the items retained in the history of the
+// Broadcaster, and then all the items that the Broadcaster receives afterwards, without gaps nor
+// duplicates. The channel is closed when the input of the Broadcaster is closed, or when the
+// returned cancel function is invoked.
+// A subscriber that does not read from its channel blocks the Broadcaster and, in cascade, the
+// nodes that send data to it, until it reads or cancels the subscription.
+func (b *Broadcaster[T]) Subscribe() (<-chan T, func()) {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	sub := &subscription[T]{
+		ch:        make(chan T, b.size+b.subBufferLen),
+		cancelled: make(chan struct{}),
+	}
+	// the replayed items always fit in the channel buffer, so this never blocks
+	for i := 0; i < b.size; i++ {
+		sub.ch <- b.history[(b.next-b.size+i+len(b.history))%len(b.history)]
+	}
+	if b.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	b.subs[sub] = struct{}{}
+	return sub.ch, func() { b.unsubscribe(sub) }
+}
+
+func (b *Broadcaster[T]) unsubscribe(sub *subscription[T]) {
+	// unblocks the broadcast to this subscriber, if any, before closing its channel
+	sub.cancel.Do(func() { close(sub.cancelled) })
+	b.mt.Lock()
+	delete(b.subs, sub)
+	b.mt.Unlock()
+	sub.close()
+}
+
+// broadcast records the item in the history and sends it to the current subscribers. The
+// subscribers are sent the item without holding the lock, so a slow subscriber does not block
+// the new subscriptions nor the cancellation of the others. A subscriber that is added
+// meanwhile gets the item from the history.
+func (b *Broadcaster[T]) broadcast(item T) {
+	b.mt.Lock()
+	if len(b.history) > 0 {
+		b.history[b.next] = item
+		b.next = (b.next + 1) % len(b.history)
+		if b.size < len(b.history) {
+			b.size++
+		}
+	}
+	subs := make([]*subscription[T], 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.mt.Unlock()
+	for _, sub := range subs {
+		sub.send(item)
+	}
+}
+
+// Done returns a channel that is closed when the input of the Broadcaster has been closed and
+// all the subscriber channels have been closed.
+func (b *Broadcaster[T]) Done() <-chan struct{} {
+	return b.done
+}
+
+// Kind returns KindTerminal, as the Broadcaster does not forward the data to other nodes of
+// the graph
+func (b *Broadcaster[T]) Kind() NodeKind {
+	return KindTerminal
+}
+
+// Schema returns the input type of the Broadcaster
+func (b *Broadcaster[T]) Schema() Schema {
+	return Schema{In: b.inType}
+}
+
+func (b *Broadcaster[T]) outputs() []graphNode {
+	return nil
+}
+
+func (b *Broadcaster[T]) start(_ context.Context) {
+	if !b.markStarted() {
+		return
+	}
+	go func() {
+		b.notifyStart()
+		for item := range b.inputs.Receiver() {
+			b.broadcast(item)
+		}
+		b.mt.Lock()
+		b.closed = true
+		subs := b.subs
+		b.subs = map[*subscription[T]]struct{}{}
+		b.mt.Unlock()
+		for sub := range subs {
+			sub.close()
+		}
+		b.notifyFinish()
+		close(b.done)
+	}()
+}
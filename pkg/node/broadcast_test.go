@@ -0,0 +1,116 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayBroadcaster(t *testing.T) {
+	input := make(chan int)
+	start := AsStart(func(out chan<- int) {
+		for n := range input {
+			out <- n
+		}
+	})
+	bc := ReplayBroadcaster[int](3)
+	start.SendsTo(bc)
+
+	early, _ := bc.Subscribe()
+	start.Start()
+	for n := 1; n <= 5; n++ {
+		input <- n
+		nodetest.ExpectReceives(t, early, n, timeout)
+	}
+
+	// a late subscriber catches up with the history, then follows the live items
+	late, _ := bc.Subscribe()
+	cancelled, cancel := bc.Subscribe()
+	for _, n := range []int{3, 4, 5} {
+		nodetest.ExpectReceives(t, late, n, timeout)
+	}
+	nodetest.ExpectBlocked(t, late)
+	cancel()
+
+	// a cancelled subscriber does not block the broadcaster
+	input <- 6
+	nodetest.ExpectReceives(t, early, 6, timeout)
+	nodetest.ExpectReceives(t, late, 6, timeout)
+	for range cancelled {
+	}
+
+	close(input)
+	waitDone(t, bc.Done())
+	nodetest.ExpectClosed(t, early, timeout)
+	nodetest.ExpectClosed(t, late, timeout)
+
+	// subscribing after the input has been closed only replays the history
+	after, _ := bc.Subscribe()
+	var replayed []int
+	for n := range after {
+		replayed = append(replayed, n)
+	}
+	assert.Equal(t, []int{4, 5, 6}, replayed)
+}
+
+func TestReplayBroadcaster_NoHistory(t *testing.T) {
+	bc := ReplayBroadcaster[int](0, ChannelBufferLen(10))
+	sub, _ := bc.Subscribe()
+	start := AsStart(Counter(1, 3))
+	start.SendsTo(bc)
+	start.Start()
+	waitDone(t, bc.Done())
+
+	var received []int
+	for n := range sub {
+		received = append(received, n)
+	}
+	assert.Equal(t, []int{1, 2, 3}, received)
+	late, _ := bc.Subscribe()
+	nodetest.ExpectClosed(t, late, timeout)
+}
+
+func TestReplayBroadcaster_SlowSubscriber(t *testing.T) {
+	input := make(chan int)
+	start := AsStart(func(out chan<- int) {
+		for n := range input {
+			out <- n
+		}
+	})
+	bc := ReplayBroadcaster[int](1)
+	start.SendsTo(bc)
+	slow, cancelSlow := bc.Subscribe()
+	start.Start()
+	input <- 1
+	// waits for the broadcaster to record the item, before it blocks sending it to the slow
+	// subscriber, which does not read it
+	require.Eventually(t, func() bool {
+		bc.mt.Lock()
+		defer bc.mt.Unlock()
+		return bc.size == 1
+	}, timeout, time.Millisecond)
+
+	// subscribing does not wait for the slow subscriber
+	subscribed := make(chan (<-chan int))
+	go func() {
+		sub, _ := bc.Subscribe()
+		subscribed <- sub
+	}()
+	var fast <-chan int
+	select {
+	case fast = <-subscribed:
+	case <-time.After(timeout):
+		require.Fail(t, "timeout while subscribing")
+	}
+	nodetest.ExpectReceives(t, fast, 1, timeout)
+
+	cancelSlow()
+	for range slow {
+	}
+	close(input)
+	waitDone(t, bc.Done())
+	nodetest.ExpectClosed(t, fast, timeout)
+}
@@ -0,0 +1,28 @@
+package node
+
+import "github.com/netobserv/gopipes/pkg/node/internal/connect"
+
+// MemoryBudget bounds the accumulated size of the items that are buffered in the input of all the
+// nodes that are created with the node.WithMemoryBudget option, or of all the nodes of a graph
+// through Graph.WithMemoryBudget, providing a single memory safety limit for a whole graph.
+type MemoryBudget struct {
+	budget *connect.Budget
+	sizeOf func(any) int
+}
+
+// NewMemoryBudget creates a MemoryBudget of maxBytes, where the size of each item is estimated by
+// the sizeOf function, which receives the items of all the nodes sharing the budget.
+// Each node always accepts an item when its buffer is empty, so the nodes sharing the budget
+// can't block each other, and the buffered items can exceed maxBytes by at most one item per
+// node.
+func NewMemoryBudget(maxBytes int, sizeOf func(any) int) *MemoryBudget {
+	if maxBytes <= 0 {
+		panic("memory budget must be greater than zero")
+	}
+	return &MemoryBudget{budget: connect.NewBudget(maxBytes), sizeOf: sizeOf}
+}
+
+// Used returns the accumulated size of the items that are currently buffered
+func (m *MemoryBudget) Used() int {
+	return m.budget.Used()
+}
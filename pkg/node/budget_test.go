@@ -0,0 +1,118 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMemoryBudget(t *testing.T) {
+	budget := NewMemoryBudget(10, func(item any) int { return len(item.([]byte)) })
+	release := make(chan struct{})
+	received := make([]int, 2)
+	pipeline := func(idx int) (*Start[[]byte], *Terminal[[]byte]) {
+		start := AsStart(func(out chan<- []byte) {
+			for i := 0; i < 3; i++ {
+				out <- make([]byte, 4)
+			}
+		})
+		term := AsTerminal(func(in <-chan []byte) {
+			<-release
+			for range in {
+				received[idx]++
+			}
+		}, WithMemoryBudget(budget))
+		start.SendsTo(term)
+		return start, term
+	}
+	start1, term1 := pipeline(0)
+	start2, term2 := pipeline(1)
+	start1.Start()
+	start2.Start()
+
+	// the first pipeline fills the budget and the other accepts a single item, as its buffer is
+	// empty, or vice versa
+	buffered := func() int { return term1.Stats().BufferLen + term2.Stats().BufferLen }
+	assert.Eventually(t, func() bool { return buffered() == 4 }, timeout, time.Millisecond)
+	assert.Never(t, func() bool { return buffered() > 4 }, 50*time.Millisecond, 5*time.Millisecond)
+	assert.Equal(t, 16, budget.Used())
+
+	close(release)
+	waitDone(t, term1.Done())
+	waitDone(t, term2.Done())
+	assert.Equal(t, []int{3, 3}, received)
+	assert.Zero(t, budget.Used())
+}
+
+func TestGraph_WithMemoryBudget(t *testing.T) {
+	start := AsStart(func(out chan<- []byte) {
+		for i := 0; i < 20; i++ {
+			out <- make([]byte, 10)
+		}
+	})
+	release := make(chan struct{})
+	middle := AsMiddle(func(in <-chan []byte, out chan<- []byte) {
+		<-release
+		for b := range in {
+			out <- b
+		}
+	}, ChannelBufferLen(15))
+	var received int
+	term := AsTerminal(func(in <-chan []byte) {
+		for range in {
+			received++
+		}
+	})
+	start.SendsTo(middle)
+	middle.SendsTo(term)
+	graph := NewGraph(start, middle, term)
+	budget := graph.WithMemoryBudget(50, func(item any) int { return len(item.([]byte)) })
+	require.NoError(t, graph.Start())
+
+	// the buffer of the node is bounded by the budget of the graph, below its length
+	assert.Eventually(t, func() bool { return middle.Stats().BufferLen == 5 }, timeout, time.Millisecond)
+	assert.Never(t, func() bool { return middle.Stats().BufferLen > 5 }, 50*time.Millisecond, 5*time.Millisecond)
+	assert.Equal(t, 50, budget.Used())
+	assert.Equal(t, 15, middle.Stats().BufferCap)
+	// the unbuffered inputs are not changed
+	assert.Zero(t, term.Stats().BufferCap)
+
+	close(release)
+	waitDone(t, graph.Done())
+	assert.Equal(t, 20, received)
+	assert.Zero(t, budget.Used())
+}
+
+func TestGraph_WithMemoryBudget_BufferLen(t *testing.T) {
+	start := AsStart(Counter(1, 20))
+	release := make(chan struct{})
+	var received []int
+	term := AsTerminal(func(in <-chan int) {
+		<-release
+		for n := range in {
+			received = append(received, n)
+		}
+	}, ChannelBufferLen(3))
+	start.SendsTo(term)
+	graph := NewGraph(start, term)
+	graph.WithMemoryBudget(1000, func(any) int { return 1 })
+	require.NoError(t, graph.Start())
+
+	// the buffer length still bounds the number of items when the budget is not exhausted
+	assert.Eventually(t, func() bool { return term.Stats().BufferLen == 3 }, timeout, time.Millisecond)
+	assert.Never(t, func() bool { return term.Stats().BufferLen > 3 }, 50*time.Millisecond, 5*time.Millisecond)
+
+	close(release)
+	waitDone(t, graph.Done())
+	assert.Len(t, received, 20)
+}
+
+func TestWithMemoryBudget_InvalidOptions(t *testing.T) {
+	budget := NewMemoryBudget(10, func(any) int { return 1 })
+	assert.Panics(t, func() {
+		AsTerminal(func(in <-chan int) {}, WithMemoryBudget(budget), ChannelBufferLen(3), MeasureQueueWait())
+	})
+	assert.Panics(t, func() { NewMemoryBudget(0, func(any) int { return 1 }) })
+}
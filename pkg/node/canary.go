@@ -0,0 +1,173 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Canary is implemented by the synthetic items that Graph.Canary injects into a live graph to
+// verify that they reach a given Terminal node. The Middle nodes between the Start node and the
+// Terminal node must forward the canary items, or transform them into other items that
+// implement Canary with the same identifier.
+type Canary interface {
+	// CanaryID identifies the canary item while it traverses the graph
+	CanaryID() string
+}
+
+// Canary injects a canary item into the first Start node of the graph that has been created with
+// the node.WithCanaries option and whose output type accepts the item. Then it waits for the item
+// to reach the expected Terminal node, which must be also created with the node.WithCanaries
+// option, so it removes the canary items from its input.
+// It returns an error if the item does not reach the Terminal before the timeout or the
+// cancellation of the provided context, or if there is no Start node that accepts the item.
+// It allows verifying end-to-end the liveness of a running graph (e.g. for health checks).
+func (g *Graph) Canary(ctx context.Context, item Canary, expect Node, timeout time.Duration) error {
+	detector, ok := expect.(canaryDetector)
+	if !ok || !detector.detectsCanaries() {
+		return fmt.Errorf("node %s does not detect canaries. Create it with the WithCanaries option",
+			expect.Name())
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	arrived, release := detector.awaitCanary(item.CanaryID())
+	defer release()
+	injected := false
+	for _, n := range g.nodes {
+		if inj, ok := n.(canaryInjector); ok {
+			accepted, err := inj.injectCanary(ctx, item)
+			if err != nil {
+				return fmt.Errorf("injecting canary %s into node %s: %w", item.CanaryID(), n.Name(), err)
+			}
+			if injected = accepted; injected {
+				break
+			}
+		}
+	}
+	if !injected {
+		return fmt.Errorf("no Start node accepts canary %s. Create them with the WithCanaries option",
+			item.CanaryID())
+	}
+	select {
+	case <-arrived:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for canary %s in node %s: %w", item.CanaryID(), expect.Name(), ctx.Err())
+	}
+}
+
+// canaryInjector is implemented by the nodes that accept canary items
+type canaryInjector interface {
+	// injectCanary sends the item into the node output, returning false if the node does not
+	// accept canaries or the item type
+	injectCanary(ctx context.Context, item any) (bool, error)
+}
+
+// canaryDetector is implemented by the nodes that detect canary items. The nodes that send data
+// to them remove the canaries from their input, so they never reach the node function.
+type canaryDetector interface {
+	detectsCanaries() bool
+	// catchCanary returns whether the item is a canary, notifying its arrival
+	catchCanary(item any) bool
+	// awaitCanary returns a channel that is closed when the canary with the provided ID arrives,
+	// and a function to stop waiting for it
+	awaitCanary(id string) (arrived <-chan struct{}, release func())
+}
+
+func (s *Start[OUT]) injectCanary(ctx context.Context, item any) (bool, error) {
+	if s.canaries == nil {
+		return false, nil
+	}
+	c, ok := item.(OUT)
+	if !ok {
+		return false, nil
+	}
+	select {
+	case s.canaries <- c:
+		return true, nil
+	case <-s.done:
+		return false, errors.New("node is finished")
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+func (t *Terminal[IN]) detectsCanaries() bool {
+	return t.canaries != nil
+}
+
+func (t *Terminal[IN]) catchCanary(item any) bool {
+	c, ok := item.(Canary)
+	if ok {
+		t.canaries.arrived(c.CanaryID())
+	}
+	return ok
+}
+
+func (t *Terminal[IN]) awaitCanary(id string) (<-chan struct{}, func()) {
+	return t.canaries.await(id)
+}
+
+// forwardCanaries forwards the canaries into the output of a Start node, until the returned
+// function is invoked. A canary whose forwarding is interrupted by the stop function is
+// discarded. It does nothing if canaries is nil.
+func forwardCanaries[T any](canaries <-chan T, out chan<- T) (stop func()) {
+	if canaries == nil {
+		return func() {}
+	}
+	stopCh, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case c := <-canaries:
+				select {
+				case out <- c:
+				case <-stopCh:
+					return
+				}
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(stopCh)
+		<-stopped
+	}
+}
+
+// canaryTracker notifies the arrival of canary items to the waiters
+type canaryTracker struct {
+	mt      sync.Mutex
+	waiters map[string]chan struct{}
+}
+
+func newCanaryTracker() *canaryTracker {
+	return &canaryTracker{waiters: map[string]chan struct{}{}}
+}
+
+func (c *canaryTracker) await(id string) (<-chan struct{}, func()) {
+	c.mt.Lock()
+	defer c.mt.Unlock()
+	ch := make(chan struct{})
+	c.waiters[id] = ch
+	return ch, func() {
+		c.mt.Lock()
+		defer c.mt.Unlock()
+		if c.waiters[id] == ch {
+			delete(c.waiters, id)
+		}
+	}
+}
+
+func (c *canaryTracker) arrived(id string) {
+	c.mt.Lock()
+	defer c.mt.Unlock()
+	if ch, ok := c.waiters[id]; ok {
+		close(ch)
+		delete(c.waiters, id)
+	}
+}
@@ -0,0 +1,117 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reading interface {
+	value() int
+}
+
+type measure int
+
+func (m measure) value() int { return int(m) }
+
+type probeItem string
+
+func (p probeItem) value() int       { return -1 }
+func (p probeItem) CanaryID() string { return string(p) }
+
+func TestGraph_Canary(t *testing.T) {
+	release := make(chan struct{})
+	start := AsStartCtx(func(ctx context.Context, out chan<- reading) {
+		for i := 1; i <= 3; i++ {
+			out <- measure(i)
+		}
+		// keeps the graph alive until the test ends
+		<-release
+	}, WithCanaries())
+	double := AsMiddle(func(in <-chan reading, out chan<- reading) {
+		for r := range in {
+			if _, ok := r.(Canary); ok {
+				out <- r
+				continue
+			}
+			out <- measure(r.value() * 2)
+		}
+	})
+	var received []int
+	sink := AsTerminal(func(in <-chan reading) {
+		for r := range in {
+			received = append(received, r.value())
+		}
+	}, WithCanaries())
+	start.SendsTo(double)
+	double.SendsTo(sink)
+
+	graph := NewGraph(start, double, sink)
+	require.NoError(t, graph.Start())
+	require.NoError(t, graph.Canary(context.Background(), probeItem("probe-1"), sink, timeout))
+	require.NoError(t, graph.Canary(context.Background(), probeItem("probe-2"), sink, timeout))
+
+	close(release)
+	waitDone(t, graph.Done())
+	// the canaries did not reach the sink function
+	assert.Equal(t, []int{2, 4, 6}, received)
+}
+
+func TestGraph_Canary_Lost(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	start := AsStart(func(out chan<- reading) {
+		<-release
+	}, WithCanaries())
+	// filters out the canaries
+	dropper := AsMiddle(func(in <-chan reading, out chan<- reading) {
+		for r := range in {
+			if _, ok := r.(Canary); !ok {
+				out <- r
+			}
+		}
+	})
+	sink := AsTerminal(func(in <-chan reading) {
+		for range in {
+		}
+	}, WithCanaries(), WithName("sink"))
+	start.SendsTo(dropper)
+	dropper.SendsTo(sink)
+
+	graph := NewGraph(start, dropper, sink)
+	require.NoError(t, graph.Start())
+	err := graph.Canary(context.Background(), probeItem("lost"), sink, 20*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sink")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestForwardCanaries_StopBlocked(t *testing.T) {
+	canaries := make(chan reading, 1)
+	canaries <- probeItem("blocked")
+	// nobody reads from the output
+	stop := forwardCanaries(canaries, make(chan reading))
+	stopped := make(chan struct{})
+	go func() {
+		stop()
+		close(stopped)
+	}()
+	waitDone(t, stopped)
+}
+
+func TestGraph_Canary_NotEnabled(t *testing.T) {
+	start := AsStart(func(out chan<- reading) {}, WithCanaries())
+	sink := AsTerminal(func(in <-chan reading) {})
+	start.SendsTo(sink)
+	graph := NewGraph(start, sink)
+	assert.Error(t, graph.Canary(context.Background(), probeItem("p"), sink, timeout))
+
+	start = AsStart(func(out chan<- reading) {})
+	sink = AsTerminal(func(in <-chan reading) {}, WithCanaries())
+	start.SendsTo(sink)
+	graph = NewGraph(start, sink)
+	assert.Error(t, graph.Canary(context.Background(), probeItem("p"), sink, timeout))
+}
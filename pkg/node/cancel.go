@@ -0,0 +1,16 @@
+package node
+
+import "context"
+
+// OnCancel registers a cleanup function (e.g. closing a connection or flushing a buffer) that
+// runs in its own goroutine when the provided context is cancelled. It is intended for the
+// functions of the nodes that receive the graph context (e.g. the ones created with AsStartCtx
+// or AsMiddleCtx), so they don't need to spawn their own goroutine to watch the context.
+// If the context is already cancelled, the cleanup runs immediately.
+// The returned function unregisters the cleanup (e.g. when the node function returns without
+// cancellation). It returns true if the cleanup has been prevented from running, or false if it
+// had already started or been unregistered.
+// When building with Go 1.21 or newer, it relies on context.AfterFunc.
+func OnCancel(ctx context.Context, cleanup func()) (stop func() bool) {
+	return afterFunc(ctx, cleanup)
+}
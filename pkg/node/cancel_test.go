@@ -0,0 +1,59 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnCancel(t *testing.T) {
+	closed := make(chan struct{})
+	start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		// e.g. a connection that must be closed to unblock the node function
+		conn := make(chan int)
+		OnCancel(ctx, func() { close(conn) })
+		for n := range conn {
+			out <- n
+		}
+		close(closed)
+	})
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	start.SendsTo(term)
+	ctx, cancel := context.WithCancel(context.Background())
+	start.StartCtx(ctx)
+
+	select {
+	case <-closed:
+		require.Fail(t, "the cleanup should not run before the context is cancelled")
+	case <-time.After(10 * time.Millisecond):
+	}
+	cancel()
+	waitDone(t, closed)
+	waitDone(t, term.Done())
+}
+
+func TestOnCancel_Stop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := make(chan struct{})
+	stop := OnCancel(ctx, func() { close(ran) })
+	assert.True(t, stop())
+	assert.False(t, stop())
+	cancel()
+	select {
+	case <-ran:
+		require.Fail(t, "the cleanup should not run after being unregistered")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// the cleanup of an already cancelled context runs immediately
+	ran = make(chan struct{})
+	stop = OnCancel(ctx, func() { close(ran) })
+	waitDone(t, ran)
+	assert.False(t, stop())
+}
@@ -0,0 +1,108 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Channel is the buffer between the nodes that send data to a node and the node itself. The
+// default implementation is a Go channel whose length can be specified with the
+// node.ChannelBufferLen option. Custom implementations (e.g. with different buffering or
+// dropping policies) can be provided with the node.WithChannel option.
+type Channel[T any] interface {
+	// Send returns the channel where the sender nodes write the items
+	Send() chan<- T
+	// Receive returns the channel where the node reads the items
+	Receive() <-chan T
+	// Close is invoked when all the sender nodes have finished. The Receive channel must be
+	// closed after all the pending items have been received.
+	Close()
+	// Len returns the number of items that are queued in the channel
+	Len() int
+	// Cap returns the capacity of the channel
+	Cap() int
+}
+
+// Backpressure reports whether a node is blocked sending data into the graph because the
+// downstream nodes are not able to process it as fast as it is provided.
+// It is safe to invoke its methods concurrently with the node execution.
+type Backpressure struct {
+	clock Clock
+	// guards the accumulated time and the current blocked period, which are read together
+	mt sync.Mutex
+	// accumulated time blocked, not counting the current blocked period
+	blocked time.Duration
+	// start of the current blocked period, if blocking is true
+	since    time.Time
+	blocking bool
+}
+
+// Blocked returns whether the node is currently blocked sending an item into the graph.
+func (b *Backpressure) Blocked() bool {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	return b.blocking
+}
+
+// BlockedTime returns the accumulated time that the node has been blocked sending items into
+// the graph, including the current blocked period.
+func (b *Backpressure) BlockedTime() time.Duration {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	if b.blocking {
+		return b.blocked + b.clock.Now().Sub(b.since)
+	}
+	return b.blocked
+}
+
+func (b *Backpressure) block() {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	b.since, b.blocking = b.clock.Now(), true
+}
+
+func (b *Backpressure) unblock() {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	b.blocked += b.clock.Now().Sub(b.since)
+	b.blocking = false
+}
+
+// FromChannel returns a Start node that forwards into the graph all the items received from an
+// external channel, until the channel is closed or the context passed to the node is cancelled.
+// The returned Backpressure allows the external producer to observe when the node is blocked
+// because the downstream nodes are slow, so it can react (e.g. by throttling).
+// The node.WithClock option allows overriding the source of time of the Backpressure.
+func FromChannel[T any](ch <-chan T, opts ...Option) (*Start[T], *Backpressure) {
+	bp := &Backpressure{clock: getOptions(opts...).clock}
+	start := AsStartCtx(func(ctx context.Context, out chan<- T) {
+		for {
+			var item T
+			var ok bool
+			select {
+			case item, ok = <-ch:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case out <- item:
+				continue
+			default:
+			}
+			bp.block()
+			select {
+			case out <- item:
+			case <-ctx.Done():
+			}
+			bp.unblock()
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}, opts...)
+	return start, bp
+}
@@ -0,0 +1,89 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromChannel(t *testing.T) {
+	external := make(chan int, 10)
+	release := make(chan struct{})
+	start, bp := FromChannel(external)
+	var received []int
+	term := AsTerminal(func(in <-chan int) {
+		<-release
+		for n := range in {
+			received = append(received, n)
+		}
+	})
+	start.SendsTo(term)
+	start.Start()
+
+	assert.False(t, bp.Blocked())
+	external <- 1
+	external <- 2
+	// the terminal does not read, so the adapter gets blocked
+	assert.Eventually(t, bp.Blocked, timeout, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.GreaterOrEqual(t, bp.BlockedTime(), 10*time.Millisecond)
+
+	close(release)
+	assert.Eventually(t, func() bool { return !bp.Blocked() }, timeout, time.Millisecond)
+	blocked := bp.BlockedTime()
+	assert.GreaterOrEqual(t, blocked, 10*time.Millisecond)
+	external <- 3
+	close(external)
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{1, 2, 3}, received)
+	assert.False(t, bp.Blocked())
+}
+
+func TestFromChannel_Cancel(t *testing.T) {
+	external := make(chan int)
+	start, bp := FromChannel(external)
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	start.SendsTo(term)
+	ctx, cancel := context.WithCancel(context.Background())
+	start.StartCtx(ctx)
+	external <- 1
+	cancel()
+
+	waitDone(t, term.Done())
+	assert.False(t, bp.Blocked())
+}
+
+func TestFromChannel_ManualClock(t *testing.T) {
+	clock := nodetest.NewManualClock()
+	external := make(chan int, 10)
+	release := make(chan struct{})
+	start, bp := FromChannel(external, WithClock(clock))
+	term := AsTerminal(func(in <-chan int) {
+		<-release
+		for range in {
+		}
+	})
+	start.SendsTo(term)
+	start.Start()
+
+	external <- 1
+	external <- 2
+	// the blocked period starts at the Unix epoch of the manual clock
+	assert.Eventually(t, bp.Blocked, timeout, time.Millisecond)
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, 5*time.Second, bp.BlockedTime())
+
+	close(release)
+	assert.Eventually(t, func() bool { return !bp.Blocked() }, timeout, time.Millisecond)
+	clock.Advance(time.Second)
+	assert.Equal(t, 5*time.Second, bp.BlockedTime())
+	close(external)
+	waitDone(t, term.Done())
+}
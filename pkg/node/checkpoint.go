@@ -0,0 +1,164 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Checkpointer persists the progress of a pipeline (e.g. the offset of the last processed item
+// of the source), so a restarted pipeline can resume from it instead of processing again all the
+// data from the beginning.
+type Checkpointer[O any] interface {
+	// Load returns the last committed offset, or false if there is no committed offset
+	Load() (offset O, ok bool, err error)
+	// Commit persists the provided offset
+	Commit(offset O) error
+}
+
+// Checkpoints coordinates a source and a sink of a graph to commit checkpoints into a
+// Checkpointer. The source registers the offset of each item when it is emitted, and the sink
+// marks it as handled once the item has been durably processed. An offset is only committed when
+// the item has been handled, as well as all the items that were emitted before it. The items
+// that are in-flight at checkpoint time (emitted but not handled yet) are not covered by the
+// checkpoint, so they are processed again after a restart (at-least-once processing).
+// The nodes that discard items between the source and the sink must mark their offsets as
+// handled. Otherwise, no later checkpoint can be committed.
+// The offsets are committed at most once per the interval provided to NewCheckpoints, and when
+// the sink finishes.
+// Its methods can be invoked concurrently.
+type Checkpoints[O comparable] struct {
+	store    Checkpointer[O]
+	interval time.Duration
+	clock    Clock
+
+	mt sync.Mutex
+	// offsets that have been emitted and not yet committed, in order of emission
+	emitted []O
+	handled map[O]struct{}
+	// last offset whose item, and all the previous items, have been handled
+	watermark    O
+	hasWatermark bool
+	// true if the watermark has changed since the last commit
+	dirty      bool
+	lastCommit time.Time
+	err        error
+}
+
+// NewCheckpoints creates a Checkpoints that commits the offsets into the provided Checkpointer
+// at most once per interval. The node.WithClock option allows overriding its source of time.
+func NewCheckpoints[O comparable](store Checkpointer[O], interval time.Duration, opts ...Option) *Checkpoints[O] {
+	clock := getOptions(opts...).clock
+	return &Checkpoints[O]{
+		store:      store,
+		interval:   interval,
+		clock:      clock,
+		handled:    map[O]struct{}{},
+		lastCommit: clock.Now(),
+	}
+}
+
+// Resume returns the last committed offset, so the source can resume after it, or false if
+// there is no committed offset.
+func (c *Checkpoints[O]) Resume() (O, bool, error) {
+	return c.store.Load()
+}
+
+// Emitted registers the offset of an item that is sent into the graph. The offsets must be
+// unique, and registered in the same order as the items are sent.
+func (c *Checkpoints[O]) Emitted(offset O) {
+	c.mt.Lock()
+	c.emitted = append(c.emitted, offset)
+	c.mt.Unlock()
+}
+
+// Handled marks the offset of an item as durably processed, committing the checkpoint if the
+// interval since the last commit has elapsed.
+func (c *Checkpoints[O]) Handled(offset O) {
+	c.mt.Lock()
+	defer c.mt.Unlock()
+	c.handled[offset] = struct{}{}
+	trimmed := 0
+	for ; trimmed < len(c.emitted); trimmed++ {
+		first := c.emitted[trimmed]
+		if _, ok := c.handled[first]; !ok {
+			break
+		}
+		delete(c.handled, first)
+		c.watermark, c.hasWatermark, c.dirty = first, true, true
+	}
+	if trimmed > 0 {
+		// copies the pending offsets, so the backing array of the committed ones can be freed
+		// instead of growing as long as the source emits items
+		c.emitted = append(make([]O, 0, len(c.emitted)-trimmed), c.emitted[trimmed:]...)
+	}
+	if c.dirty && c.clock.Now().Sub(c.lastCommit) >= c.interval {
+		c.commit()
+	}
+}
+
+// Commit commits the last offset whose item, and all the previous items, have been handled, if
+// it has not been committed yet.
+func (c *Checkpoints[O]) Commit() {
+	c.mt.Lock()
+	defer c.mt.Unlock()
+	if c.dirty {
+		c.commit()
+	}
+}
+
+// Err returns the first error returned by the Checkpointer when committing an offset
+func (c *Checkpoints[O]) Err() error {
+	c.mt.Lock()
+	defer c.mt.Unlock()
+	return c.err
+}
+
+// commit must be invoked with the mutex locked
+func (c *Checkpoints[O]) commit() {
+	c.lastCommit = c.clock.Now()
+	if err := c.store.Commit(c.watermark); err != nil {
+		if c.err == nil {
+			c.err = err
+		}
+		// the commit is retried with the next handled item
+		return
+	}
+	c.dirty = false
+}
+
+// CheckpointSource returns a Start node that works as the node returned by AsStartCtx, and
+// registers the offset of each sent item in the provided Checkpoints. The offset function
+// returns the offset of an item. The function can use the Resume method of the Checkpoints to
+// know where to resume from.
+func CheckpointSource[T any, O comparable](
+	checkpoints *Checkpoints[O], offset func(T) O, fun StartFuncCtx[T], opts ...Option,
+) *Start[T] {
+	return AsStartCtx(func(ctx context.Context, out chan<- T) {
+		items := make(chan T)
+		go func() {
+			defer close(items)
+			fun(ctx, items)
+		}()
+		for item := range items {
+			checkpoints.Emitted(offset(item))
+			out <- item
+		}
+	}, opts...)
+}
+
+// CheckpointSink returns a Terminal node that invokes the provided function for each received
+// item, and then marks the offset of the item as handled in the provided Checkpoints. The
+// function must return once the item has been durably processed. When the input is closed, the
+// last handled offset is committed.
+func CheckpointSink[T any, O comparable](
+	checkpoints *Checkpoints[O], offset func(T) O, fun func(T), opts ...Option,
+) *Terminal[T] {
+	return AsTerminal(func(in <-chan T) {
+		for item := range in {
+			fun(item)
+			checkpoints.Handled(offset(item))
+		}
+		checkpoints.Commit()
+	}, opts...)
+}
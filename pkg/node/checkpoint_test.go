@@ -0,0 +1,126 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memCheckpointer is a Checkpointer that records all the committed offsets
+type memCheckpointer struct {
+	mt        sync.Mutex
+	committed []int
+	fail      bool
+}
+
+func (m *memCheckpointer) Load() (int, bool, error) {
+	m.mt.Lock()
+	defer m.mt.Unlock()
+	if len(m.committed) == 0 {
+		return 0, false, nil
+	}
+	return m.committed[len(m.committed)-1], true, nil
+}
+
+func (m *memCheckpointer) Commit(offset int) error {
+	m.mt.Lock()
+	defer m.mt.Unlock()
+	if m.fail {
+		return errors.New("storage failure")
+	}
+	m.committed = append(m.committed, offset)
+	return nil
+}
+
+func TestCheckpoints_InFlight(t *testing.T) {
+	store := &memCheckpointer{}
+	cp := NewCheckpoints[int](store, 0)
+	cp.Emitted(1)
+	cp.Emitted(2)
+	cp.Emitted(3)
+	// 1 is still in flight, so 2 can't be committed
+	cp.Handled(2)
+	assert.Empty(t, store.committed)
+	cp.Handled(1)
+	assert.Equal(t, []int{2}, store.committed)
+	cp.Handled(3)
+	assert.Equal(t, []int{2, 3}, store.committed)
+	// nothing changed since the last commit
+	cp.Commit()
+	assert.Equal(t, []int{2, 3}, store.committed)
+}
+
+func TestCheckpoints_BoundedMemory(t *testing.T) {
+	cp := NewCheckpoints[int](&memCheckpointer{}, time.Hour)
+	cp.Emitted(0)
+	// a long-running source, with one item in flight
+	for i := 1; i <= 10000; i++ {
+		cp.Emitted(i)
+		cp.Handled(i - 1)
+	}
+	cp.mt.Lock()
+	defer cp.mt.Unlock()
+	assert.Equal(t, []int{10000}, cp.emitted)
+	assert.LessOrEqual(t, cap(cp.emitted), 2)
+}
+
+func TestCheckpoints_Interval(t *testing.T) {
+	store := &memCheckpointer{}
+	cp := NewCheckpoints[int](store, time.Minute, WithClock(fixedClock(time.Now())))
+	cp.Emitted(1)
+	cp.Emitted(2)
+	cp.Handled(1)
+	cp.Handled(2)
+	assert.Empty(t, store.committed)
+	cp.Commit()
+	assert.Equal(t, []int{2}, store.committed)
+
+	store.fail = true
+	cp.Emitted(3)
+	cp.Handled(3)
+	cp.Commit()
+	assert.EqualError(t, cp.Err(), "storage failure")
+	// the failed commit is retried
+	store.fail = false
+	cp.Commit()
+	assert.Equal(t, []int{2, 3}, store.committed)
+}
+
+func intOffset(n int) int { return n }
+
+func TestCheckpointSourceSink(t *testing.T) {
+	store := &memCheckpointer{committed: []int{10}}
+	cp := NewCheckpoints[int](store, 0)
+	start := CheckpointSource(cp, intOffset, func(_ context.Context, out chan<- int) {
+		from, ok, err := cp.Resume()
+		require.NoError(t, err)
+		require.True(t, ok)
+		for n := from + 1; n <= 20; n++ {
+			out <- n
+		}
+	})
+	// the items are handled out of order
+	shuffle := Parallel(4, func(n int) int {
+		time.Sleep(time.Duration(n%3) * time.Millisecond)
+		return n
+	})
+	var handled []int
+	sink := CheckpointSink(cp, intOffset, func(n int) {
+		handled = append(handled, n)
+	})
+	start.SendsTo(shuffle)
+	shuffle.SendsTo(sink)
+	start.Start()
+
+	waitDone(t, sink.Done())
+	assert.ElementsMatch(t, []int{11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, handled)
+	require.NoError(t, cp.Err())
+	assert.Equal(t, 20, store.committed[len(store.committed)-1])
+	// the checkpoints never go backwards
+	assert.IsIncreasing(t, store.committed)
+}
@@ -0,0 +1,51 @@
+package node
+
+import (
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/internal/timing"
+)
+
+// Clock abstracts the source of time for the nodes whose behavior depends on it, so it can be
+// replaced (e.g. for testing purposes) through the node.WithClock option.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// NewTimer creates a Timer that expires after the given duration
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer abstracts a time.Timer, so it can be created by a Clock. It is also implemented by the
+// timers of the nodetest.ManualClock.
+type Timer = timing.Timer
+
+// systemClock is the default Clock, which relies on the time package
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{Timer: time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// resetTimer stops the timer, draining its channel if required, and resets it to expire
+// after the given duration.
+func resetTimer(t Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C():
+		default:
+		}
+	}
+	t.Reset(d)
+}
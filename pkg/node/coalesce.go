@@ -0,0 +1,82 @@
+package node
+
+import (
+	"context"
+	"time"
+)
+
+// ErrorSummary aggregates the identical errors that a CoalesceErrors node has received during a
+// window
+type ErrorSummary struct {
+	// Err is the first received error of the group
+	Err error
+	// Count is the number of identical errors that have been received during the window
+	Count int
+	// First and Last are the times when the first and the last errors of the group were received
+	First, Last time.Time
+}
+
+// CoalesceErrors returns a Middle node that groups the identical errors that it receives during
+// each window, and sends a single ErrorSummary per group when the window ends, instead of
+// flooding the receivers (e.g. an error sink) with the same error under systemic failures.
+// Two errors are considered identical if their Error methods return the same message. The
+// summaries of a window are sent in order of the first occurrence of each error. The windows
+// without errors send nothing. When the input is closed, the summaries of the current window are
+// sent. The same happens when the context of the node is cancelled, after processing the errors
+// that were queued in the input. The rest of the input is discarded. The nil errors are ignored.
+// The node.WithClock option allows overriding the source of time.
+func CoalesceErrors(window time.Duration, opts ...Option) *Middle[error, ErrorSummary] {
+	if window <= 0 {
+		panic("CoalesceErrors window must be greater than zero")
+	}
+	clock := getOptions(opts...).clock
+	coalesce := AsMiddleCtx(func(ctx context.Context, in <-chan error, out chan<- ErrorSummary) {
+		timer := clock.NewTimer(window)
+		defer timer.Stop()
+		// summaries of the current window, in order of first occurrence
+		var summaries []*ErrorSummary
+		byMessage := map[string]*ErrorSummary{}
+		flush := func() {
+			for _, s := range summaries {
+				out <- *s
+			}
+			summaries = nil
+			byMessage = map[string]*ErrorSummary{}
+		}
+		add := func(err error) {
+			if err == nil {
+				return
+			}
+			now := clock.Now()
+			if s, ok := byMessage[err.Error()]; ok {
+				s.Count++
+				s.Last = now
+				return
+			}
+			s := &ErrorSummary{Err: err, Count: 1, First: now, Last: now}
+			byMessage[err.Error()] = s
+			summaries = append(summaries, s)
+		}
+		for {
+			select {
+			case err, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				add(err)
+			case <-timer.C():
+				flush()
+				timer.Reset(window)
+			case <-ctx.Done():
+				for _, err := range takeQueued(in) {
+					add(err)
+				}
+				flush()
+				return
+			}
+		}
+	}, opts...)
+	coalesce.flushOnCancel = true
+	return coalesce
+}
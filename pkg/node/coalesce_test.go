@@ -0,0 +1,98 @@
+package node
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoalesceErrors(t *testing.T) {
+	clock := &manualClock{now: time.Date(2022, 3, 4, 12, 0, 0, 0, time.UTC)}
+	begin := clock.Now()
+	timeoutErr := errors.New("timeout")
+	start := AsStart(func(out chan<- error) {
+		// the nil errors are ignored, but they guarantee that the previous error has been
+		// processed before advancing the clock
+		out <- timeoutErr
+		out <- nil
+		clock.Add(time.Second)
+		out <- errors.New("refused")
+		out <- nil
+		clock.Add(time.Second)
+		// identical to the first error
+		out <- errors.New("timeout")
+		out <- nil
+		clock.Add(time.Second)
+		out <- errors.New("timeout")
+	})
+	// the window is longer than the test, so all the summaries are sent when the input is closed
+	coalesce := CoalesceErrors(time.Hour, WithClock(clock))
+	var summaries []ErrorSummary
+	term := AsTerminal(func(in <-chan ErrorSummary) {
+		for s := range in {
+			summaries = append(summaries, s)
+		}
+	})
+	start.SendsTo(coalesce)
+	coalesce.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	require.Len(t, summaries, 2)
+	assert.Equal(t, ErrorSummary{
+		Err: timeoutErr, Count: 3, First: begin, Last: begin.Add(3 * time.Second),
+	}, summaries[0])
+	assert.Equal(t, "refused", summaries[1].Err.Error())
+	assert.Equal(t, 1, summaries[1].Count)
+	assert.Equal(t, begin.Add(time.Second), summaries[1].First)
+	assert.Equal(t, begin.Add(time.Second), summaries[1].Last)
+}
+
+func TestCoalesceErrors_Windows(t *testing.T) {
+	in := make(chan error)
+	start := AsStart(func(out chan<- error) {
+		for err := range in {
+			out <- err
+		}
+	})
+	coalesce := CoalesceErrors(50 * time.Millisecond)
+	summaries := make(chan ErrorSummary, 10)
+	term := AsTerminal(func(in <-chan ErrorSummary) {
+		for s := range in {
+			summaries <- s
+		}
+	})
+	start.SendsTo(coalesce)
+	coalesce.SendsTo(term)
+	start.Start()
+
+	in <- errors.New("failed")
+	in <- errors.New("failed")
+	// the summary is sent when the window ends, without waiting for the input to be closed
+	select {
+	case s := <-summaries:
+		assert.Equal(t, 2, s.Count)
+	case <-time.After(timeout):
+		require.Fail(t, "timeout while waiting for the error summary")
+	}
+	in <- errors.New("failed")
+	close(in)
+	waitDone(t, term.Done())
+	require.Len(t, summaries, 1)
+	assert.Equal(t, 1, (<-summaries).Count)
+}
+
+func TestCoalesceErrors_Cancel(t *testing.T) {
+	forwarded := runCancelled(t, CoalesceErrors(time.Hour, ChannelBufferLen(10)),
+		errors.New("timeout"), errors.New("refused"), errors.New("timeout"))
+	for _, summaries := range forwarded {
+		require.Len(t, summaries, 2)
+		assert.EqualError(t, summaries[0].Err, "timeout")
+		assert.Equal(t, 2, summaries[0].Count)
+		assert.EqualError(t, summaries[1].Err, "refused")
+		assert.Equal(t, 1, summaries[1].Count)
+	}
+}
@@ -0,0 +1,69 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Codec serializes the items of the nodes that send them outside the process (e.g.
+// NetworkSink and NetworkSource). It allows plugging any encoding (e.g. protobuf) through the
+// node.WithCodec option.
+type Codec[T any] interface {
+	Marshal(item T) ([]byte, error)
+	Unmarshal(b []byte) (T, error)
+}
+
+// GobCodec returns a Codec that uses the encoding/gob package. It is the default Codec. Each
+// item is encoded independently, so it includes the description of its type.
+func GobCodec[T any]() Codec[T] {
+	return gobCodec[T]{}
+}
+
+type gobCodec[T any] struct{}
+
+func (gobCodec[T]) Marshal(item T) ([]byte, error) {
+	return GobEncode(item)
+}
+
+func (gobCodec[T]) Unmarshal(b []byte) (T, error) {
+	return GobDecode[T](b)
+}
+
+// JSONCodec returns a Codec that uses the encoding/json package
+func JSONCodec[T any]() Codec[T] {
+	return jsonCodec[T]{}
+}
+
+type jsonCodec[T any] struct{}
+
+func (jsonCodec[T]) Marshal(item T) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+func (jsonCodec[T]) Unmarshal(b []byte) (T, error) {
+	var item T
+	err := json.Unmarshal(b, &item)
+	return item, err
+}
+
+// nodeCodec is the Codec that has been provided with the node.WithCodec option
+type nodeCodec struct {
+	itemType reflect.Type
+	// codec is a Codec[T], where T is itemType
+	codec any
+}
+
+// codecOf returns the Codec that has been provided with the node.WithCodec option, or a
+// GobCodec if none has been provided
+func codecOf[T any](options *creationOptions) Codec[T] {
+	if options.codec == nil {
+		return GobCodec[T]()
+	}
+	codec, ok := options.codec.codec.(Codec[T])
+	if !ok {
+		panic(fmt.Sprintf("WithCodec item type %v does not match the node item type %v",
+			options.codec.itemType, reflect.TypeOf((*T)(nil)).Elem()))
+	}
+	return codec
+}
@@ -0,0 +1,59 @@
+package node
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecs(t *testing.T) {
+	for name, codec := range map[string]Codec[netEvent]{
+		"gob":  GobCodec[netEvent](),
+		"json": JSONCodec[netEvent](),
+	} {
+		t.Run(name, func(t *testing.T) {
+			b, err := codec.Marshal(netEvent{Name: "a", Bytes: 1})
+			require.NoError(t, err)
+			e, err := codec.Unmarshal(b)
+			require.NoError(t, err)
+			assert.Equal(t, netEvent{Name: "a", Bytes: 1}, e)
+
+			_, err = codec.Unmarshal([]byte("not an event"))
+			assert.Error(t, err)
+		})
+	}
+	b, err := JSONCodec[netEvent]().Marshal(netEvent{Name: "a", Bytes: 1})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Name":"a","Bytes":1}`, string(b))
+}
+
+func TestWithCodec(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	source := NetworkSource[netEvent](listener, nil, WithCodec(JSONCodec[netEvent]()))
+	var received []netEvent
+	collect := ForEach(func(e netEvent) { received = append(received, e) })
+	source.SendsTo(collect)
+	source.Start()
+
+	start := AsStart(func(out chan<- netEvent) {
+		out <- netEvent{Name: "a", Bytes: 1}
+		out <- netEvent{Name: "b", Bytes: 2}
+	})
+	sink, sinkErr := NetworkSink[netEvent](listener.Addr().String(), nil, WithCodec(JSONCodec[netEvent]()))
+	start.SendsTo(sink)
+	start.Start()
+
+	require.NoError(t, sinkErr())
+	waitDone(t, collect.Done())
+	assert.Equal(t, []netEvent{{"a", 1}, {"b", 2}}, received)
+}
+
+func TestWithCodec_TypeMismatch(t *testing.T) {
+	assert.Panics(t, func() {
+		NetworkSink[netEvent]("127.0.0.1:0", nil, WithCodec(JSONCodec[string]()))
+	})
+}
@@ -0,0 +1,33 @@
+package node
+
+// ToMap returns a Terminal node that stores the received items into a map, with the key and the
+// value provided by the key and val functions for each item. If multiple items have the same
+// key, the map keeps the value of the last received item.
+// It also returns a function that provides the map once the Terminal node has finished. As the
+// Terminal function is invoked from a single goroutine, the map is not synchronized, so the
+// function blocks until the Done channel of the Terminal is closed.
+func ToMap[T any, K comparable, V any](key func(T) K, val func(T) V, opts ...Option) (*Terminal[T], func() map[K]V) {
+	m := map[K]V{}
+	term := ForEach(func(item T) {
+		m[key(item)] = val(item)
+	}, opts...)
+	return term, func() map[K]V {
+		<-term.Done()
+		return m
+	}
+}
+
+// CountBy returns a Terminal node that counts the received items by the key provided by the key
+// function (e.g. to count the occurrences of each word).
+// It also returns a function that provides the counts once the Terminal node has finished. As
+// for ToMap, the function blocks until the Done channel of the Terminal is closed.
+func CountBy[T any, K comparable](key func(T) K, opts ...Option) (*Terminal[T], func() map[K]int) {
+	counts := map[K]int{}
+	term := ForEach(func(item T) {
+		counts[key(item)]++
+	}, opts...)
+	return term, func() map[K]int {
+		<-term.Done()
+		return counts
+	}
+}
@@ -0,0 +1,54 @@
+package node
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToMap(t *testing.T) {
+	type reading struct {
+		sensor string
+		value  int
+	}
+	start := AsStart(func(out chan<- reading) {
+		out <- reading{sensor: "a", value: 1}
+		out <- reading{sensor: "b", value: 2}
+		out <- reading{sensor: "a", value: 3}
+	})
+	term, last := ToMap(
+		func(r reading) string { return r.sensor },
+		func(r reading) int { return r.value })
+	start.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, map[string]int{"a": 3, "b": 2}, last())
+}
+
+func TestCountBy(t *testing.T) {
+	start := AsStart(func(out chan<- string) {
+		for _, w := range strings.Fields("the cat and the dog and the bird") {
+			out <- w
+		}
+	})
+	term, counts := CountBy(func(w string) string { return w })
+	start.SendsTo(term)
+
+	// the counts are not available until the Terminal finishes
+	result := make(chan map[string]int)
+	go func() { result <- counts() }()
+	nodetest.ExpectBlocked(t, result)
+
+	start.Start()
+	select {
+	case c := <-result:
+		assert.Equal(t, map[string]int{"the": 3, "cat": 1, "and": 2, "dog": 1, "bird": 1}, c)
+	case <-time.After(timeout):
+		require.Fail(t, "timeout while waiting for the counts")
+	}
+}
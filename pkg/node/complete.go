@@ -0,0 +1,99 @@
+package node
+
+import (
+	"context"
+	"sync"
+)
+
+// Doner is implemented by any element that notifies when it has finished its execution
+// (e.g. node.Terminal or node.Graph).
+type Doner interface {
+	// Done returns a channel that is closed when the execution has finished
+	Done() <-chan struct{}
+}
+
+// AllDone returns a channel that is closed when all the provided elements have finished
+// their execution.
+func AllDone(doners ...Doner) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for _, d := range doners {
+			<-d.Done()
+		}
+		close(done)
+	}()
+	return done
+}
+
+// OnComplete returns a Start node that sends a single signal when all the provided elements
+// (e.g. the Terminal nodes of another graph) have finished. This allows chaining the execution
+// of graphs: the graph that starts from the returned node will start processing data once the
+// previous graph has completed.
+// If the context passed to the returned node is cancelled before, no signal is sent.
+func OnComplete(doners ...Doner) *Start[struct{}] {
+	return AsStartCtx(func(ctx context.Context, out chan<- struct{}) {
+		for _, d := range doners {
+			select {
+			case <-d.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		out <- struct{}{}
+	})
+}
+
+// OnComplete registers a hook that is invoked once, after all the nodes of the graph have
+// finished. The hook receives the first panic that has been recovered from the graph nodes (see
+// node.WithPanicHandler) as a NodePanic error. If no node panicked, it receives the error of the
+// context passed to StartCtx or RunSerial, or nil if the context has not been cancelled. It
+// allows cleaning up the resources of the graph (e.g. closing the files written by the Terminal
+// nodes).
+// The hooks are invoked sequentially, in registration order, each time the graph runs. When the
+// graph runs with RunSerial, they are invoked before RunSerial returns. They must be registered
+// before the graph is started.
+func (g *Graph) OnComplete(hook func(err error)) {
+	g.onComplete = append(g.onComplete, hook)
+}
+
+// panicRecorder keeps the first panic that is recovered from the nodes of a graph
+type panicRecorder struct {
+	mt    sync.Mutex
+	first error
+}
+
+func (r *panicRecorder) record(p NodePanic) {
+	r.mt.Lock()
+	defer r.mt.Unlock()
+	if r.first == nil {
+		r.first = p
+	}
+}
+
+// result returns the first recorded panic or, if no panic has been recorded, the error of the
+// provided context
+func (r *panicRecorder) result(ctx context.Context) error {
+	r.mt.Lock()
+	defer r.mt.Unlock()
+	if r.first != nil {
+		return r.first
+	}
+	return ctx.Err()
+}
+
+// runOnComplete invokes the hooks once all the provided started nodes have finished, passing
+// them the result of the panic recorder
+func runOnComplete(ctx context.Context, started []Node, panics *panicRecorder, hooks []func(error)) {
+	go func() {
+		for _, n := range started {
+			<-n.Done()
+		}
+		invokeHooks(hooks, panics.result(ctx))
+	}()
+}
+
+func invokeHooks(hooks []func(error), err error) {
+	for _, hook := range hooks {
+		hook(err)
+	}
+}
@@ -0,0 +1,170 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnComplete(t *testing.T) {
+	// graph A: two independent pipelines
+	release := make(chan struct{})
+	startA1 := AsStart(func(out chan<- int) {
+		<-release
+		out <- 1
+	})
+	startA2 := AsStart(Counter(1, 3))
+	termA1 := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	termA2 := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	startA1.SendsTo(termA1)
+	startA2.SendsTo(termA2)
+
+	// graph B: starts when graph A finishes
+	signals := 0
+	startB := OnComplete(termA1, termA2)
+	termB := AsTerminal(func(in <-chan struct{}) {
+		for range in {
+			signals++
+		}
+	})
+	startB.SendsTo(termB)
+
+	startB.Start()
+	startA1.Start()
+	startA2.Start()
+
+	waitDone(t, termA2.Done())
+	select {
+	case <-termB.Done():
+		require.Fail(t, "expected that graph B is still waiting for graph A")
+	default: //ok!
+	}
+	close(release)
+	waitDone(t, AllDone(termA1, termA2, termB))
+	assert.Equal(t, 1, signals)
+}
+
+func TestOnComplete_Cancel(t *testing.T) {
+	neverEnds := AsTerminal(func(in <-chan int) {})
+	start := OnComplete(neverEnds)
+	signals := 0
+	term := AsTerminal(func(in <-chan struct{}) {
+		for range in {
+			signals++
+		}
+	})
+	start.SendsTo(term)
+	ctx, cancel := context.WithCancel(context.Background())
+	start.StartCtx(ctx)
+	cancel()
+	waitDone(t, term.Done())
+	assert.Zero(t, signals)
+}
+
+func TestGraph_OnComplete(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(term)
+
+	graph := NewGraph(start, term)
+	var calls []string
+	completed := make(chan struct{})
+	graph.OnComplete(func(err error) {
+		assert.NoError(t, err)
+		// all the nodes have finished
+		assert.Equal(t, []int{1, 2, 3}, received)
+		calls = append(calls, "first")
+	})
+	graph.OnComplete(func(err error) {
+		calls = append(calls, "second")
+		close(completed)
+	})
+	require.NoError(t, graph.Start())
+
+	waitDone(t, completed)
+	assert.Equal(t, []string{"first", "second"}, calls)
+}
+
+func TestGraph_OnComplete_Panic(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+			panic("broken sink")
+		}
+	}, WithName("sink"), WithPanicHandler(func(NodePanic) {}))
+	start.SendsTo(term)
+
+	graph := NewGraph(start, term)
+	errs := make(chan error, 1)
+	graph.OnComplete(func(err error) {
+		errs <- err
+	})
+	require.NoError(t, graph.Start())
+
+	select {
+	case err := <-errs:
+		var p NodePanic
+		require.ErrorAs(t, err, &p)
+		assert.Equal(t, "sink", p.Node.Name)
+		assert.Equal(t, "broken sink", p.Value)
+	case <-time.After(timeout):
+		require.Fail(t, "timeout while waiting for the graph to complete")
+	}
+}
+
+func TestGraph_OnComplete_Cancel(t *testing.T) {
+	start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		out <- 1
+		<-ctx.Done()
+	})
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(term)
+	// an unread probe does not prevent the graph from completing
+	start.Probe()
+
+	graph := NewGraph(start, term)
+	errs := make(chan error, 1)
+	graph.OnComplete(func(err error) {
+		errs <- err
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, graph.StartCtx(ctx))
+	cancel()
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(timeout):
+		require.Fail(t, "timeout while waiting for the graph to complete")
+	}
+}
+
+func TestGraph_OnComplete_Serial(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	var received []int
+	term := ForEach(func(n int) {
+		received = append(received, n)
+	})
+	start.SendsTo(term)
+
+	graph := NewGraph(start, term)
+	var errs []error
+	graph.OnComplete(func(err error) {
+		errs = append(errs, err)
+	})
+	require.NoError(t, graph.RunSerial(context.Background()))
+	// the hooks are invoked before RunSerial returns
+	assert.Equal(t, []error{nil}, errs)
+	assert.Equal(t, []int{1, 2, 3}, received)
+}
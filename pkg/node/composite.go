@@ -0,0 +1,134 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/netobserv/gopipes/pkg/node/internal/connect"
+)
+
+// Composite packages a subgraph of nodes as a single node, which receives items of type IN and
+// sends items of type OUT. It can be connected to other nodes as any Middle node, allowing to
+// build libraries of reusable pipeline fragments.
+// The nodes of the subgraph are started when the Composite is started, and the Composite is
+// done when the last node of the subgraph has finished.
+type Composite[IN, OUT any] struct {
+	nodeMeta
+	// pass-through nodes at the boundaries of the subgraph
+	entry *Middle[IN, IN]
+	exit  *Middle[OUT, OUT]
+}
+
+// Compose creates a Composite node from the subgraph that is wired by the build function. The
+// build function receives the entry of the subgraph, which sends the items received by the
+// Composite, and must connect it to the first nodes of the subgraph. It returns the node whose
+// output is the output of the Composite.
+// The options (e.g. node.WithName or node.ChannelBufferLen) apply to the input of the Composite,
+// except the hook of the node.WithOnFinish option, which is invoked once the last node of the
+// subgraph has finished.
+func Compose[IN, OUT any](build func(in Sender[IN]) Sender[OUT], opts ...Option) *Composite[IN, OUT] {
+	var in IN
+	var out OUT
+	options := getOptions(opts...)
+	if options.name == "" {
+		options.name = fmt.Sprintf("Composite[%v,%v]", reflect.TypeOf(in), reflect.TypeOf(out))
+	}
+	c := &Composite[IN, OUT]{
+		nodeMeta: newNodeMeta(&options, KindMiddle,
+			Schema{In: reflect.TypeOf(in), Out: reflect.TypeOf(out)}),
+		entry: Map(func(i IN) IN { return i },
+			append(opts, WithName(options.name+"/in"), WithOnFinish(nil))...),
+		exit: Map(func(o OUT) OUT { return o },
+			WithName(options.name+"/out"), WithOnFinish(options.onFinish)),
+	}
+	build(c.entry).SendsTo(c.exit)
+	return c
+}
+
+// SendsTo connects the output of the Composite with a group of receivers
+func (c *Composite[IN, OUT]) SendsTo(outputs ...Receiver[OUT]) {
+	c.exit.SendsTo(outputs...)
+}
+
+// OnEnd registers a function that provides a final item, which is sent to the output after all
+// the nodes of the subgraph have finished. It must be invoked before the node is started.
+func (c *Composite[IN, OUT]) OnEnd(trailer func() OUT) {
+	c.exit.OnEnd(trailer)
+}
+
+// Done returns a channel that is closed when the last node of the subgraph has finished.
+func (c *Composite[IN, OUT]) Done() <-chan struct{} {
+	return c.exit.Done()
+}
+
+// State returns the stage of the lifecycle of the Composite: it is the state of its input until
+// the input is closed, then Draining until the last node of the subgraph has finished.
+func (c *Composite[IN, OUT]) State() NodeState {
+	if state := c.exit.State(); state == StateFinished || state == StateFailed {
+		return state
+	}
+	state := c.entry.State()
+	if state == StateFinished {
+		return StateDraining
+	}
+	return state
+}
+
+// Kind returns KindMiddle
+func (c *Composite[IN, OUT]) Kind() NodeKind {
+	return KindMiddle
+}
+
+// Schema returns the input and output types of the Composite
+func (c *Composite[IN, OUT]) Schema() Schema {
+	return Schema{In: c.entry.inType, Out: c.exit.outType}
+}
+
+// Stats returns runtime information about the input of the Composite
+func (c *Composite[IN, OUT]) Stats() Stats {
+	return c.entry.Stats()
+}
+
+// InType returns the inner type of the Composite input channel
+func (c *Composite[IN, OUT]) InType() reflect.Type {
+	return c.entry.InType()
+}
+
+// OutType returns the inner type of the Composite output channel
+func (c *Composite[IN, OUT]) OutType() reflect.Type {
+	return c.exit.OutType()
+}
+
+// outputs returns the first nodes of the subgraph, so the subgraph is part of the graph topology
+func (c *Composite[IN, OUT]) outputs() []graphNode {
+	return c.entry.outputs()
+}
+
+func (c *Composite[IN, OUT]) isStarted() bool {
+	return c.entry.isStarted()
+}
+
+func (c *Composite[IN, OUT]) start(ctx context.Context) {
+	c.entry.start(ctx)
+}
+
+func (c *Composite[IN, OUT]) joiner() *connect.Joiner[IN] {
+	return c.entry.joiner()
+}
+
+func (c *Composite[IN, OUT]) serialCapable() bool {
+	return c.entry.serialCapable()
+}
+
+func (c *Composite[IN, OUT]) beginSerial() bool {
+	return c.entry.beginSerial()
+}
+
+func (c *Composite[IN, OUT]) receiveSerial(item any) {
+	c.entry.receiveSerial(item)
+}
+
+func (c *Composite[IN, OUT]) endSerial() {
+	c.entry.endSerial()
+}
@@ -0,0 +1,107 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func oddMessages() *Composite[int, string] {
+	return Compose(func(in Sender[int]) Sender[string] {
+		odds := AsMiddle(OddFilter)
+		msg := AsMiddle(Messager("odd"))
+		in.SendsTo(odds)
+		odds.SendsTo(msg)
+		return msg
+	}, WithName("oddMessages"))
+}
+
+func TestCompose(t *testing.T) {
+	start := AsStart(Counter(1, 5))
+	composite := oddMessages()
+	var received []string
+	term := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			received = append(received, s)
+		}
+	})
+	start.SendsTo(composite)
+	composite.SendsTo(term)
+
+	assert.Equal(t, reflect.TypeOf(0), composite.InType())
+	assert.Equal(t, reflect.TypeOf(""), composite.OutType())
+	assert.Equal(t, "oddMessages", composite.Name())
+
+	graph := NewGraph(start, composite, term)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	assert.Equal(t, []string{"odd: 1", "odd: 3", "odd: 5"}, received)
+	waitDone(t, composite.Done())
+
+	// the nodes of the subgraph are part of the graph topology
+	order, err := graph.TopoSort()
+	require.NoError(t, err)
+	assert.Len(t, order, 6)
+}
+
+func TestCompose_RunSerial(t *testing.T) {
+	start := AsStart(Counter(1, 5))
+	composite := Compose(func(in Sender[int]) Sender[int] {
+		odds := Filter(func(n int) bool { return n%2 == 1 })
+		in.SendsTo(odds)
+		return odds
+	})
+	var received []int
+	term := ForEach(func(n int) { received = append(received, n) })
+	start.SendsTo(composite)
+	composite.SendsTo(term)
+
+	require.NoError(t, NewGraph(start, composite, term).RunSerial(context.Background()))
+	assert.Equal(t, []int{1, 3, 5}, received)
+	assert.True(t, isClosed(composite.Done()))
+}
+
+func TestCompose_OnStartFinish(t *testing.T) {
+	for name, run := range map[string]func(g *Graph) error{
+		"concurrent": func(g *Graph) error {
+			if err := g.Start(); err != nil {
+				return err
+			}
+			<-g.Done()
+			return nil
+		},
+		"serial": func(g *Graph) error { return g.RunSerial(context.Background()) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var mt sync.Mutex
+			var events []string
+			record := func(event string) func() {
+				return func() {
+					mt.Lock()
+					events = append(events, event)
+					mt.Unlock()
+				}
+			}
+			start := AsStart(Counter(1, 3))
+			composite := Compose(func(in Sender[int]) Sender[int] {
+				double := Map(func(n int) int { return n * 2 }, WithOnFinish(record("inner finish")))
+				in.SendsTo(double)
+				return double
+			}, WithOnStart(record("start")), WithOnFinish(record("finish")))
+			term := ForEach(func(int) {})
+			start.SendsTo(composite)
+			composite.SendsTo(term)
+
+			require.NoError(t, run(NewGraph(start, composite, term)))
+			waitDone(t, composite.Done())
+			mt.Lock()
+			defer mt.Unlock()
+			// the composite finishes after the last node of its subgraph
+			assert.Equal(t, []string{"start", "inner finish", "finish"}, events)
+		})
+	}
+}
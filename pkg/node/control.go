@@ -0,0 +1,73 @@
+package node
+
+// Controlled is an item of an edge that multiplexes data items and out-of-band control messages
+// (e.g. "flush now" or "reset state") for the same receiver, so the control messages don't
+// require a second connection. The data items are created with DataMsg and the control
+// messages with ControlMsg, and the receiver can dispatch them with the Dispatch function.
+// The items of an edge keep their order, so a control message is dispatched after the data
+// items that its sender sent before it, and before the data items that its sender sends after
+// it.
+type Controlled[T, C any] struct {
+	// Data is the data item, if IsControl is false
+	Data T
+	// Control is the control message, if IsControl is true
+	Control C
+	// IsControl tells whether the item is a control message
+	IsControl bool
+}
+
+// DataMsg wraps a data item into a Controlled item, where C is the type of the control messages
+func DataMsg[C, T any](item T) Controlled[T, C] {
+	return Controlled[T, C]{Data: item}
+}
+
+// ControlMsg wraps a control message into a Controlled item, where T is the type of the data
+// items
+func ControlMsg[T, C any](msg C) Controlled[T, C] {
+	return Controlled[T, C]{Control: msg, IsControl: true}
+}
+
+// MuxControl joins the output of a data node and the output of a control node into a Middle node
+// that forwards their items as Controlled items through a single edge. As they come from
+// different senders, only the relative order of the items of each sender is preserved.
+// The returned node must be connected to its outputs through its SendsTo method, and finishes
+// when both senders have closed their output.
+func MuxControl[T, C any](data Sender[T], control Sender[C], opts ...Option) *Middle[Controlled[T, C], Controlled[T, C]] {
+	muxed := AsMiddle(func(in <-chan Controlled[T, C], out chan<- Controlled[T, C]) {
+		for item := range in {
+			out <- item
+		}
+	}, opts...)
+	dataWrapper := AsMiddle(func(in <-chan T, out chan<- Controlled[T, C]) {
+		for item := range in {
+			out <- DataMsg[C](item)
+		}
+	})
+	controlWrapper := AsMiddle(func(in <-chan C, out chan<- Controlled[T, C]) {
+		for msg := range in {
+			out <- ControlMsg[T](msg)
+		}
+	})
+	dataWrapper.SendsTo(muxed)
+	controlWrapper.SendsTo(muxed)
+	data.SendsTo(dataWrapper)
+	control.SendsTo(controlWrapper)
+	return muxed
+}
+
+// Dispatch receives the Controlled items of a channel until it is closed, passing the data
+// items to onData and the control messages to onControl, in order of arrival. It is intended
+// for the functions of the nodes that receive Controlled items:
+//
+//	node.AsTerminal(func(in <-chan node.Controlled[Flow, Command]) {
+//		node.Dispatch(in, aggregate, handleCommand)
+//	})
+func Dispatch[T, C any](in <-chan Controlled[T, C], onData func(T), onControl func(C)) {
+	for item := range in {
+		if item.IsControl {
+			onControl(item.Control)
+		} else {
+			onData(item.Data)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flushCmd struct{}
+
+func TestDispatch(t *testing.T) {
+	// the same node sends data and control messages, so their order is preserved
+	start := AsStart(func(out chan<- Controlled[int, flushCmd]) {
+		out <- DataMsg[flushCmd](1)
+		out <- DataMsg[flushCmd](2)
+		out <- ControlMsg[int](flushCmd{})
+		out <- DataMsg[flushCmd](3)
+	})
+	var batches [][]int
+	var pending []int
+	term := AsTerminal(func(in <-chan Controlled[int, flushCmd]) {
+		Dispatch(in, func(n int) {
+			pending = append(pending, n)
+		}, func(flushCmd) {
+			batches = append(batches, pending)
+			pending = nil
+		})
+	})
+	start.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, [][]int{{1, 2}}, batches)
+	assert.Equal(t, []int{3}, pending)
+}
+
+func TestMuxControl(t *testing.T) {
+	data := AsStart(Counter(1, 3))
+	control := AsStart(func(out chan<- string) {
+		out <- "reset"
+	})
+	muxed := MuxControl[int, string](data, control)
+	var received []int
+	var commands []string
+	term := AsTerminal(func(in <-chan Controlled[int, string]) {
+		Dispatch(in, func(n int) {
+			received = append(received, n)
+		}, func(cmd string) {
+			commands = append(commands, cmd)
+		})
+	})
+	muxed.SendsTo(term)
+	data.Start()
+	control.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{1, 2, 3}, received)
+	assert.Equal(t, []string{"reset"}, commands)
+}
@@ -0,0 +1,103 @@
+package node
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// ApproxDistinct returns a Terminal node that estimates the number of distinct keys of the
+// received items, as returned by the key function, with the HyperLogLog algorithm. This allows
+// estimating the cardinality of large streams (e.g. the number of distinct flows) without storing
+// all their keys: the node only keeps 2^precision registers of one byte. The standard error of
+// the estimation is about 1.04/sqrt(2^precision) (e.g. 0.8% for a precision of 14).
+// The keys are hashed deterministically, so the estimation only depends on the received keys,
+// regardless of their order. The strings and the fixed-size keys (numbers, booleans, and arrays
+// or structs of them) are hashed from their bytes. The rest of keys are hashed from their
+// Go-syntax representation, so the keys containing pointers are hashed by their address.
+// The returned function provides the estimation. It blocks until the Done channel of the
+// Terminal is closed.
+// It panics if precision is not between 4 and 18.
+func ApproxDistinct[T any, K comparable](key func(T) K, precision int, opts ...Option) (*Terminal[T], func() uint64) {
+	if precision < 4 || precision > 18 {
+		panic("ApproxDistinct precision must be between 4 and 18")
+	}
+	registers := make([]uint8, 1<<precision)
+	term := AsTerminal(func(in <-chan T) {
+		for item := range in {
+			hash := hashKey(key(item))
+			idx := hash >> (64 - precision)
+			// the remaining bits can't have more than 64-precision leading zeros
+			rank := uint8(bits.LeadingZeros64(hash<<precision|1<<(precision-1)) + 1)
+			if rank > registers[idx] {
+				registers[idx] = rank
+			}
+		}
+	}, opts...)
+	return term, func() uint64 {
+		<-term.Done()
+		return hllEstimate(registers)
+	}
+}
+
+// hllEstimate returns the HyperLogLog estimation of the cardinality from its registers, using
+// linear counting for the small cardinalities
+func hllEstimate(registers []uint8) uint64 {
+	m := float64(len(registers))
+	sum, zeros := 0.0, 0
+	for _, r := range registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	var alpha float64
+	switch len(registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(estimate))
+}
+
+// hashKey returns a 64-bit hash of the key, which is deterministic across executions
+func hashKey[K comparable](key K) uint64 {
+	h := fnv.New64a()
+	switch k := any(key).(type) {
+	case string:
+		_, _ = h.Write([]byte(k))
+	// the size of int, uint and uintptr depends on the platform, so they are hashed as 64 bits
+	case int:
+		_ = binary.Write(h, binary.LittleEndian, int64(k))
+	case uint:
+		_ = binary.Write(h, binary.LittleEndian, uint64(k))
+	case uintptr:
+		_ = binary.Write(h, binary.LittleEndian, uint64(k))
+	default:
+		if binary.Size(key) >= 0 {
+			_ = binary.Write(h, binary.LittleEndian, key)
+		} else {
+			_, _ = fmt.Fprintf(h, "%#v", key)
+		}
+	}
+	// FNV does not spread well the short inputs over the high bits, which select the register,
+	// so the hash is finalized with the mixer of MurmurHash3
+	hash := h.Sum64()
+	hash ^= hash >> 33
+	hash *= 0xff51afd7ed558ccd
+	hash ^= hash >> 33
+	hash *= 0xc4ceb9fe1a85ec53
+	hash ^= hash >> 33
+	return hash
+}
@@ -0,0 +1,102 @@
+package node
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+)
+
+// runApproxDistinct sends the keys to an ApproxDistinct node and returns its estimation
+func runApproxDistinct[K comparable](t *testing.T, precision int, keys []K) uint64 {
+	t.Helper()
+	start := AsStart(func(out chan<- K) {
+		for _, k := range keys {
+			out <- k
+		}
+	})
+	term, distinct := ApproxDistinct(func(k K) K { return k }, precision)
+	start.SendsTo(term)
+	start.Start()
+	waitDone(t, term.Done())
+	return distinct()
+}
+
+func TestApproxDistinct(t *testing.T) {
+	for _, cardinality := range []int{10, 1000, 20000, 200000} {
+		t.Run(strconv.Itoa(cardinality), func(t *testing.T) {
+			// each key is received twice
+			keys := make([]string, 0, 2*cardinality)
+			for i := 0; i < cardinality; i++ {
+				keys = append(keys, "flow-"+strconv.Itoa(i))
+			}
+			keys = append(keys, keys...)
+			estimate := runApproxDistinct(t, 14, keys)
+			// the standard error for a precision of 14 is 0.8%
+			assert.InEpsilon(t, cardinality, estimate, 0.03)
+		})
+	}
+}
+
+func TestApproxDistinct_NonStringKeys(t *testing.T) {
+	type flow struct {
+		src, dst string
+		port     int
+	}
+	var keys []flow
+	for i := 0; i < 5000; i++ {
+		keys = append(keys, flow{src: "10.0.0.1", dst: "10.0.0.2", port: i})
+	}
+	assert.InEpsilon(t, 5000, runApproxDistinct(t, 12, keys), 0.05)
+	assert.Zero(t, runApproxDistinct(t, 12, []int{}))
+}
+
+func TestApproxDistinct_FixedSizeKeys(t *testing.T) {
+	type flow struct {
+		src, dst [4]byte
+		port     uint16
+	}
+	var keys []flow
+	for i := 0; i < 5000; i++ {
+		keys = append(keys, flow{src: [4]byte{10, 0, 0, 1}, dst: [4]byte{10, 0, 0, 2}, port: uint16(i)})
+	}
+	assert.InEpsilon(t, 5000, runApproxDistinct(t, 12, keys), 0.05)
+	// the keys are hashed from their bytes
+	assert.Equal(t, hashKey(uint64(1234)), hashKey(1234))
+	assert.NotEqual(t, hashKey(int32(1234)), hashKey(1234))
+}
+
+func TestApproxDistinct_WaitsForDone(t *testing.T) {
+	input := make(chan int)
+	start := AsStart(func(out chan<- int) {
+		for n := range input {
+			out <- n
+		}
+	})
+	term, distinct := ApproxDistinct(func(n int) int { return n }, 10)
+	start.SendsTo(term)
+	start.Start()
+	input <- 1
+
+	estimated := make(chan uint64)
+	go func() { estimated <- distinct() }()
+	nodetest.ExpectBlocked(t, estimated)
+	input <- 2
+	close(input)
+	nodetest.ExpectReceives(t, estimated, uint64(2), timeout)
+}
+
+func TestApproxDistinct_OrderIndependent(t *testing.T) {
+	var keys, reversed []int
+	for i := 0; i < 3000; i++ {
+		keys = append(keys, i)
+		reversed = append(reversed, 2999-i)
+	}
+	assert.Equal(t, runApproxDistinct(t, 10, keys), runApproxDistinct(t, 10, reversed))
+}
+
+func TestApproxDistinct_Precision(t *testing.T) {
+	assert.Panics(t, func() { ApproxDistinct(func(n int) int { return n }, 3) })
+	assert.Panics(t, func() { ApproxDistinct(func(n int) int { return n }, 19) })
+}
@@ -0,0 +1,70 @@
+package node
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// AnyStart wraps a StartFunc whose items are sent as any into a Start node, whose output type
+// is declared as outT instead of being inferred from the type parameters. This allows building
+// graphs whose types are not known at compile time (e.g. from plugins).
+// The declared type is reported by the OutType and Schema methods of the node, and is checked
+// by ConnectDynamic. The function must only send items of the declared type.
+func AnyStart(fun StartFunc[any], outT reflect.Type, opts ...Option) *Start[any] {
+	if outT == nil {
+		panic("AnyStart output type can't be nil")
+	}
+	s := AsStart(fun, opts...)
+	s.outType = outT
+	options := getOptions(opts...)
+	s.nodeMeta = newNodeMeta(&options, KindStart, s.Schema())
+	return s
+}
+
+// AnyMiddle wraps a MiddleFunc whose items are received and sent as any into a Middle node,
+// whose input and output types are declared as inT and outT instead of being inferred from the
+// type parameters.
+// The declared types are reported by the InType, OutType and Schema methods of the node, and are
+// checked by ConnectDynamic. The function must only send items of the declared output type.
+func AnyMiddle(fun MiddleFunc[any, any], inT, outT reflect.Type, opts ...Option) *Middle[any, any] {
+	if inT == nil || outT == nil {
+		panic("AnyMiddle input and output types can't be nil")
+	}
+	m := AsMiddle(fun, opts...)
+	m.inType, m.outType = inT, outT
+	options := getOptions(opts...)
+	m.nodeMeta = newNodeMeta(&options, KindMiddle, m.Schema())
+	m.inputDone = m.receiverBase.inputClosed
+	return m
+}
+
+// AnyTerminal wraps a TerminalFunc whose items are received as any into a Terminal node, whose
+// input type is declared as inT instead of being inferred from the type parameters.
+// The declared type is reported by the InType and Schema methods of the node, and is checked
+// by ConnectDynamic.
+func AnyTerminal(fun TerminalFunc[any], inT reflect.Type, opts ...Option) *Terminal[any] {
+	if inT == nil {
+		panic("AnyTerminal input type can't be nil")
+	}
+	t := AsTerminal(fun, opts...)
+	t.inType = inT
+	options := getOptions(opts...)
+	t.nodeMeta = newNodeMeta(&options, KindTerminal, t.Schema())
+	t.inputDone = t.receiverBase.inputClosed
+	return t
+}
+
+// ConnectDynamic connects the sender to the receivers, as the SendsTo method does, after
+// verifying at runtime that the output type of the sender is assignable to the input type of
+// each receiver. If any receiver is incompatible, it returns an error and no connection is made.
+// It is intended to connect the nodes created with AnyStart, AnyMiddle and AnyTerminal.
+func ConnectDynamic(from Sender[any], to ...Receiver[any]) error {
+	outT := from.OutType()
+	for _, r := range to {
+		if inT := r.InType(); !outT.AssignableTo(inT) {
+			return fmt.Errorf("can't connect output type %v to input type %v", outT, inT)
+		}
+	}
+	from.SendsTo(to...)
+	return nil
+}
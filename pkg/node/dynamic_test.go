@@ -0,0 +1,45 @@
+package node
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamicNodes(t *testing.T) {
+	intType, stringType := reflect.TypeOf(0), reflect.TypeOf("")
+	start := AnyStart(func(out chan<- any) {
+		for i := 1; i <= 3; i++ {
+			out <- i
+		}
+	}, intType)
+	format := AnyMiddle(func(in <-chan any, out chan<- any) {
+		for n := range in {
+			out <- fmt.Sprint("n=", n.(int))
+		}
+	}, intType, stringType, WithName("format"))
+	var received []string
+	term := AnyTerminal(func(in <-chan any) {
+		for s := range in {
+			received = append(received, s.(string))
+		}
+	}, stringType)
+	stringer := AnyTerminal(func(in <-chan any) {}, reflect.TypeOf((*fmt.Stringer)(nil)).Elem())
+
+	assert.Equal(t, "Start[int]", start.Name())
+	assert.Equal(t, "format", format.Name())
+	assert.Equal(t, Schema{In: intType, Out: stringType}, format.Schema())
+	assert.Equal(t, Schema{In: stringType}, term.Schema())
+
+	assert.Error(t, ConnectDynamic(start, term))
+	assert.Error(t, ConnectDynamic(format, term, stringer))
+	require.NoError(t, ConnectDynamic(start, format))
+	require.NoError(t, ConnectDynamic(format, term))
+
+	require.NoError(t, NewGraph(start, format, term).Start())
+	waitDone(t, term.Done())
+	assert.Equal(t, []string{"n=1", "n=2", "n=3"}, received)
+}
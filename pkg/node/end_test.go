@@ -0,0 +1,31 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnEnd(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	start.OnEnd(func() int { return -1 })
+	messager := AsMiddle(Messager("num"))
+	messager.OnEnd(func() string { return "EOF" })
+	var collected []string
+	ended := false
+	term := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			collected = append(collected, s)
+		}
+	})
+	term.OnEnd(func() {
+		ended = true
+	})
+	start.SendsTo(messager)
+	messager.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []string{"num: 1", "num: 2", "num: 3", "num: -1", "EOF"}, collected)
+	assert.True(t, ended)
+}
@@ -0,0 +1,200 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CacheConfig configures the cache of the node.Enrich lookups
+type CacheConfig struct {
+	// TTL is the time that a successful lookup is cached. If 0 or lower, the lookups are cached
+	// forever. The failed lookups are never cached.
+	TTL time.Duration
+	// MaxLookups is the maximum number of concurrent invocations of the lookup function (e.g. to
+	// bound the connections to the external store). If 0 or lower, it is unbounded.
+	MaxLookups int
+}
+
+// Enrich returns a Middle node that enriches each received item with extra data from an external
+// store (e.g. the metadata of the IPs of a network flow). For each item, it looks up the extra
+// data for the key provided by the key function, and sends the result of merging the item with
+// the extra data.
+// The lookups are cached according to the provided CacheConfig, and the concurrent lookups of the
+// same key are coalesced into a single invocation of the lookup function. The lookup function
+// receives the context of the node.
+// If a lookup fails, the item is not forwarded, and it is passed to the onError function
+// together with the error. A panic of the lookup function is also passed as an error. The
+// onError function is not invoked concurrently.
+// If the node has a panic handler, the panics of the key, merge and onError functions are
+// recovered: the node fails, and the rest of its input is discarded.
+// The items are looked up concurrently, so they may be sent in a different order from which they
+// were received. The node.WithMaxConcurrency option bounds the number of items that are
+// processed concurrently. By default, it is unbounded. The merge function may be invoked
+// concurrently. The node.WithClock option allows overriding the source of time of the cache.
+func Enrich[IN, OUT any, K comparable, X any](
+	key func(IN) K,
+	lookup func(context.Context, K) (X, error),
+	merge func(IN, X) OUT,
+	cache CacheConfig,
+	onError func(IN, error),
+	opts ...Option,
+) *Middle[IN, OUT] {
+	options := getOptions(opts...)
+	lc := newLookupCache[K, X](cache, options.clock)
+	errMt := sync.Mutex{}
+	var middle *Middle[IN, OUT]
+	middle = AsMiddleCtx(func(ctx context.Context, in <-chan IN, out chan<- OUT) {
+		var slots chan struct{}
+		if options.maxConcurrency > 0 {
+			slots = make(chan struct{}, options.maxConcurrency)
+		}
+		wg := sync.WaitGroup{}
+		for item := range in {
+			if slots != nil {
+				slots <- struct{}{}
+			}
+			// the items are not processed anymore once any of them has panicked
+			if middle.failed() {
+				break
+			}
+			wg.Add(1)
+			go func(item IN) {
+				defer wg.Done()
+				if slots != nil {
+					defer func() { <-slots }()
+				}
+				middle.invoke(middle, func() {
+					extra, err := lc.get(ctx, key(item), lookup)
+					if err != nil {
+						errMt.Lock()
+						defer errMt.Unlock()
+						onError(item, err)
+					} else {
+						out <- merge(item, extra)
+					}
+				})
+			}(item)
+		}
+		wg.Wait()
+	}, opts...)
+	return middle
+}
+
+// lookupCache caches the results of a lookup function with a TTL, coalescing the concurrent
+// lookups of the same key
+type lookupCache[K comparable, X any] struct {
+	ttl   time.Duration
+	clock Clock
+	// nil if the concurrent lookups are unbounded
+	slots chan struct{}
+	mt    sync.Mutex
+	// entries are removed lazily: when they are looked up after expiring, or when the number of
+	// entries reaches the sweep threshold
+	entries  map[K]cacheEntry[X]
+	sweepAt  int
+	inflight map[K]*lookupCall[X]
+}
+
+type cacheEntry[X any] struct {
+	value   X
+	expires time.Time
+}
+
+type lookupCall[X any] struct {
+	done  chan struct{}
+	value X
+	err   error
+}
+
+// the cache is swept for expired entries once it reaches this size, or the double of its size
+// after the last sweep
+const minCacheSweep = 1024
+
+func newLookupCache[K comparable, X any](config CacheConfig, clock Clock) *lookupCache[K, X] {
+	c := &lookupCache[K, X]{
+		ttl:      config.TTL,
+		clock:    clock,
+		entries:  map[K]cacheEntry[X]{},
+		sweepAt:  minCacheSweep,
+		inflight: map[K]*lookupCall[X]{},
+	}
+	if config.MaxLookups > 0 {
+		c.slots = make(chan struct{}, config.MaxLookups)
+	}
+	return c
+}
+
+func (c *lookupCache[K, X]) get(
+	ctx context.Context, key K, lookup func(context.Context, K) (X, error),
+) (X, error) {
+	c.mt.Lock()
+	if e, ok := c.entries[key]; ok {
+		if c.ttl <= 0 || c.clock.Now().Before(e.expires) {
+			c.mt.Unlock()
+			return e.value, nil
+		}
+		delete(c.entries, key)
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.mt.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+	call := &lookupCall[X]{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mt.Unlock()
+
+	c.invoke(ctx, key, lookup, call)
+	return call.value, call.err
+}
+
+// invoke performs the lookup of the call, and caches its result if it succeeds. Even if the
+// lookup panics, the call is removed from the inflight lookups and the waiting lookups of the
+// same key are released.
+func (c *lookupCache[K, X]) invoke(
+	ctx context.Context, key K, lookup func(context.Context, K) (X, error), call *lookupCall[X],
+) {
+	defer close(call.done)
+	defer func() {
+		if r := recover(); r != nil {
+			call.err = fmt.Errorf("lookup of key %v panicked: %v", key, r)
+		}
+		c.mt.Lock()
+		defer c.mt.Unlock()
+		delete(c.inflight, key)
+		if call.err == nil {
+			c.entries[key] = cacheEntry[X]{value: call.value, expires: c.clock.Now().Add(c.ttl)}
+			if len(c.entries) >= c.sweepAt {
+				c.sweep()
+			}
+		}
+	}()
+	if c.slots != nil {
+		select {
+		case c.slots <- struct{}{}:
+			defer func() { <-c.slots }()
+		case <-ctx.Done():
+			call.err = ctx.Err()
+			return
+		}
+	}
+	call.value, call.err = lookup(ctx, key)
+}
+
+// sweep removes the expired entries. It must be invoked with the mutex locked
+func (c *lookupCache[K, X]) sweep() {
+	if c.ttl > 0 {
+		now := c.clock.Now()
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.sweepAt = 2 * len(c.entries)
+	if c.sweepAt < minCacheSweep {
+		c.sweepAt = minCacheSweep
+	}
+}
@@ -0,0 +1,244 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flow struct {
+	ip    string
+	bytes int
+}
+
+func TestEnrich(t *testing.T) {
+	release := make(chan struct{})
+	lookupsMt := sync.Mutex{}
+	lookups := map[string]int{}
+	start := AsStart(func(out chan<- flow) {
+		for _, f := range []flow{{"1.1.1.1", 1}, {"1.1.1.1", 2}, {"8.8.8.8", 3}, {"10.0.0.1", 4}, {"1.1.1.1", 5}} {
+			out <- f
+		}
+	})
+	var failed []flow
+	enrich := Enrich(func(f flow) string { return f.ip },
+		func(_ context.Context, ip string) (string, error) {
+			lookupsMt.Lock()
+			lookups[ip]++
+			lookupsMt.Unlock()
+			<-release
+			if ip == "10.0.0.1" {
+				return "", errors.New("not found")
+			}
+			return "host-" + ip, nil
+		},
+		func(f flow, host string) string { return fmt.Sprintf("%s:%d", host, f.bytes) },
+		CacheConfig{TTL: time.Hour},
+		func(f flow, err error) {
+			assert.EqualError(t, err, "not found")
+			failed = append(failed, f)
+		})
+	var received []string
+	term := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			received = append(received, s)
+		}
+	})
+	start.SendsTo(enrich)
+	enrich.SendsTo(term)
+	start.Start()
+
+	// the concurrent lookups of the same key are coalesced
+	countLookups := func() map[string]int {
+		lookupsMt.Lock()
+		defer lookupsMt.Unlock()
+		cp := map[string]int{}
+		for k, v := range lookups {
+			cp[k] = v
+		}
+		return cp
+	}
+	expected := map[string]int{"1.1.1.1": 1, "8.8.8.8": 1, "10.0.0.1": 1}
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(expected, countLookups())
+	}, timeout, time.Millisecond)
+	close(release)
+
+	waitDone(t, term.Done())
+	assert.Equal(t, expected, countLookups())
+	sort.Strings(received)
+	assert.Equal(t, []string{"host-1.1.1.1:1", "host-1.1.1.1:2", "host-1.1.1.1:5", "host-8.8.8.8:3"}, received)
+	assert.Equal(t, []flow{{"10.0.0.1", 4}}, failed)
+}
+
+type manualClock struct {
+	mt  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mt.Lock()
+	defer c.mt.Unlock()
+	return c.now
+}
+
+func (c *manualClock) NewTimer(d time.Duration) Timer {
+	return systemClock{}.NewTimer(d)
+}
+
+func (c *manualClock) Add(d time.Duration) {
+	c.mt.Lock()
+	c.now = c.now.Add(d)
+	c.mt.Unlock()
+}
+
+func TestEnrich_PanicHandler(t *testing.T) {
+	lookup := func(_ context.Context, n int) (string, error) {
+		if n%2 == 0 {
+			return "", errors.New("not found")
+		}
+		return fmt.Sprint(n), nil
+	}
+	for name, tc := range map[string]struct {
+		merge   func(int, string) string
+		onError func(int, error)
+	}{
+		"merge": {
+			merge:   func(int, string) string { panic("merge failed") },
+			onError: func(int, error) {},
+		},
+		"onError": {
+			merge:   func(_ int, s string) string { return s },
+			onError: func(int, error) { panic("onError failed") },
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			panics := make(chan NodePanic, 10)
+			start := AsStart(Counter(1, 10))
+			enrich := Enrich(func(n int) int { return n }, lookup, tc.merge, CacheConfig{}, tc.onError,
+				WithMaxConcurrency(1), WithPanicHandler(func(p NodePanic) { panics <- p }))
+			var received []string
+			term := collectStrings(&received)
+			start.SendsTo(enrich)
+			enrich.SendsTo(term)
+			start.Start()
+
+			// the panic is recovered, and the rest of the input is discarded
+			waitDone(t, term.Done())
+			assert.Equal(t, StateFailed, enrich.State())
+			require.Len(t, panics, 1)
+			assert.Equal(t, name+" failed", (<-panics).Value)
+		})
+	}
+}
+
+func TestLookupCache(t *testing.T) {
+	clock := &manualClock{now: time.Date(2022, 3, 4, 12, 0, 0, 0, time.UTC)}
+	cache := newLookupCache[string, int](CacheConfig{TTL: time.Minute}, clock)
+	lookups := 0
+	fail := false
+	lookup := func(_ context.Context, k string) (int, error) {
+		lookups++
+		if fail {
+			return 0, errors.New("failed")
+		}
+		return len(k), nil
+	}
+	get := func(k string) (int, error) {
+		return cache.get(context.Background(), k, lookup)
+	}
+
+	v, err := get("foo")
+	require.NoError(t, err)
+	assert.Equal(t, 3, v)
+	clock.Add(30 * time.Second)
+	v, err = get("foo")
+	require.NoError(t, err)
+	assert.Equal(t, 3, v)
+	assert.Equal(t, 1, lookups)
+
+	// the entry expires after the TTL
+	clock.Add(30 * time.Second)
+	fail = true
+	_, err = get("foo")
+	require.Error(t, err)
+	assert.Equal(t, 2, lookups)
+
+	// failed lookups are not cached
+	fail = false
+	v, err = get("foo")
+	require.NoError(t, err)
+	assert.Equal(t, 3, v)
+	assert.Equal(t, 3, lookups)
+}
+
+func TestLookupCache_Panic(t *testing.T) {
+	cache := newLookupCache[string, int](CacheConfig{}, systemClock{})
+	release := make(chan struct{})
+	broken := int32(1)
+	lookup := func(_ context.Context, k string) (int, error) {
+		<-release
+		if atomic.LoadInt32(&broken) == 1 {
+			panic("broken store")
+		}
+		return len(k), nil
+	}
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := cache.get(context.Background(), "foo", lookup)
+			errs <- err
+		}()
+	}
+	close(release)
+	// both the lookup that panicked and the ones that waited for it receive the panic
+	for i := 0; i < 2; i++ {
+		assert.EqualError(t, <-errs, "lookup of key foo panicked: broken store")
+	}
+
+	// the panic is not cached
+	atomic.StoreInt32(&broken, 0)
+	v, err := cache.get(context.Background(), "foo", lookup)
+	require.NoError(t, err)
+	assert.Equal(t, 3, v)
+}
+
+func TestLookupCache_MaxLookups(t *testing.T) {
+	cache := newLookupCache[int, int](CacheConfig{MaxLookups: 2}, systemClock{})
+	release := make(chan struct{})
+	var running, maxRunning int32
+	lookup := func(_ context.Context, k int) (int, error) {
+		r := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if r <= m || atomic.CompareAndSwapInt32(&maxRunning, m, r) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+		return k, nil
+	}
+	wg := sync.WaitGroup{}
+	for k := 0; k < 5; k++ {
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			v, err := cache.get(context.Background(), k, lookup)
+			assert.NoError(t, err)
+			assert.Equal(t, k, v)
+		}(k)
+	}
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&running) == 2 }, timeout, time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.EqualValues(t, 2, maxRunning)
+}
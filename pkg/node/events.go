@@ -0,0 +1,123 @@
+package node
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EventType identifies the lifecycle events of a Graph
+type EventType int
+
+const (
+	// NodeStarted is emitted when a node of the graph is started, before any other event of the
+	// node
+	NodeStarted EventType = iota
+	// NodeFinished is emitted when a node of the graph has finished its processing
+	NodeFinished
+	// NodeErrored is emitted when the function of a node panics. Only the panics of the nodes
+	// created with the node.WithPanicHandler option are recovered and reported.
+	NodeErrored
+	// GraphDrained is emitted when all the nodes of the graph have finished
+	GraphDrained
+)
+
+func (t EventType) String() string {
+	switch t {
+	case NodeStarted:
+		return "NodeStarted"
+	case NodeFinished:
+		return "NodeFinished"
+	case NodeErrored:
+		return "NodeErrored"
+	case GraphDrained:
+		return "GraphDrained"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(t))
+	}
+}
+
+// Event is a lifecycle event of a Graph
+type Event struct {
+	Type EventType
+	// Node that originated the event. It is empty for the GraphDrained event.
+	Node NodeInfo
+	Time time.Time
+	// Panic is the recovered panic of a NodeErrored event
+	Panic *NodePanic
+}
+
+// Events returns a channel that receives the lifecycle events of the next run of the graph: the
+// start and the end of each node, the panics of the nodes, and the end of the graph. The channel
+// is closed after the GraphDrained event is sent. It must be invoked before the graph is started,
+// and each run of the graph requires a new invocation.
+// The events are queued without bound, so the graph is never blocked if the events are not read.
+// The node.WithClock option allows overriding the source of the event times.
+func (g *Graph) Events(opts ...Option) <-chan Event {
+	g.events = make(chan Event)
+	g.eventsClock = getOptions(opts...).clock
+	return g.events
+}
+
+// emitEvents sends to the queue the NodeStarted events of the provided nodes, and then the
+// events of their end as they finish. It must be invoked before the nodes are started, and it
+// closes the queue after all the nodes have finished.
+func emitEvents(nodes []Node, queue chan<- Event, clock Clock) {
+	wg := sync.WaitGroup{}
+	wg.Add(len(nodes))
+	for _, n := range nodes {
+		queue <- Event{Type: NodeStarted, Node: infoOf(n), Time: clock.Now()}
+		go func(n Node) {
+			defer wg.Done()
+			<-n.Done()
+			queue <- Event{Type: NodeFinished, Node: infoOf(n), Time: clock.Now()}
+		}(n)
+	}
+	go func() {
+		wg.Wait()
+		queue <- Event{Type: GraphDrained, Time: clock.Now()}
+		close(queue)
+	}()
+}
+
+// pumpEvents forwards the events from the in channel to the out channel, queueing them while
+// the out channel is not read. It closes the out channel after the in channel is closed and
+// all the events are forwarded.
+func pumpEvents(in <-chan Event, out chan<- Event) {
+	var queue []Event
+	for in != nil || len(queue) > 0 {
+		var send chan<- Event
+		var head Event
+		if len(queue) > 0 {
+			send, head = out, queue[0]
+		}
+		select {
+		case e, ok := <-in:
+			if !ok {
+				in = nil
+				continue
+			}
+			queue = append(queue, e)
+		case send <- head:
+			queue = queue[1:]
+		}
+	}
+	close(out)
+}
+
+// observePanics sends a NodeErrored event to the queue, if not nil, and records the panic in
+// the recorder, if not nil, each time a node recovers from a panic. It replaces the observers of
+// any previous run of the nodes, so it must be invoked before the nodes are started.
+func observePanics(nodes []Node, queue chan<- Event, clock Clock, panics *panicRecorder) {
+	observer := func(p NodePanic) {
+		if queue != nil {
+			queue <- Event{Type: NodeErrored, Node: p.Node, Time: clock.Now(), Panic: &p}
+		}
+		if panics != nil {
+			panics.record(p)
+		}
+	}
+	for _, n := range nodes {
+		n.meta().panicObserver = observer
+	}
+}
@@ -0,0 +1,125 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+)
+
+func TestGraph_Events(t *testing.T) {
+	start := AsStart(Counter(1, 3), WithName("start"))
+	failing := AsMiddle(func(in <-chan int, out chan<- int) {
+		for range in {
+			panic("failed")
+		}
+	}, WithName("failing"), WithPanicHandler(func(NodePanic) {}))
+	term := AsTerminal(func(in <-chan int) {}, WithName("term"))
+	start.SendsTo(failing)
+	failing.SendsTo(term)
+	graph := NewGraph(start, failing, term)
+	events := graph.Events()
+	require.NoError(t, graph.Start())
+
+	var received []Event
+	deadline := time.After(timeout)
+receive:
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				break receive
+			}
+			received = append(received, e)
+		case <-deadline:
+			require.Fail(t, "timeout while waiting for the events channel to be closed")
+		}
+	}
+	require.NotEmpty(t, received)
+	started := map[string]int{}
+	finished := map[string]int{}
+	for i, e := range received {
+		assert.False(t, e.Time.IsZero())
+		switch e.Type {
+		case NodeStarted:
+			started[e.Node.Name] = i
+		case NodeFinished:
+			finished[e.Node.Name] = i
+		case NodeErrored:
+			assert.Equal(t, "failing", e.Node.Name)
+			require.NotNil(t, e.Panic)
+			assert.Equal(t, "failed", e.Panic.Value)
+		}
+	}
+	assert.Equal(t, GraphDrained, received[len(received)-1].Type)
+	for _, name := range []string{"start", "failing", "term"} {
+		require.Contains(t, started, name)
+		require.Contains(t, finished, name)
+		assert.Less(t, started[name], finished[name])
+	}
+	assert.Len(t, received, 8)
+}
+
+func TestGraph_Events_StartedFirst(t *testing.T) {
+	// the ordering would depend on the scheduling of the goroutines, so it is checked on many runs
+	for run := 0; run < 20; run++ {
+		// the failing node is the last of a long graph, so the events of the previous nodes
+		// would give it time to panic before its NodeStarted event is queued
+		start := AsStart(func(out chan<- int) {})
+		nodes := []Node{start}
+		var last Sender[int] = start
+		for i := 0; i < 20; i++ {
+			m := Map(func(n int) int { return n })
+			last.SendsTo(m)
+			nodes = append(nodes, m)
+			last = m
+		}
+		failing := AsTerminal(func(in <-chan int) {
+			panic("failed")
+		}, WithName("failing"), WithPanicHandler(func(NodePanic) {}))
+		last.SendsTo(failing)
+		graph := NewGraph(append(nodes, failing)...)
+		events := graph.Events()
+		require.NoError(t, graph.Start())
+
+		var types []EventType
+		for e := range events {
+			if e.Node.Name == "failing" {
+				types = append(types, e.Type)
+			}
+		}
+		require.Equal(t, []EventType{NodeStarted, NodeErrored, NodeFinished}, types)
+	}
+}
+
+func TestGraph_Events_Clock(t *testing.T) {
+	clock := nodetest.NewManualClock()
+	start := AsStart(Counter(1, 3))
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	start.SendsTo(term)
+	graph := NewGraph(start, term)
+	events := graph.Events(WithClock(clock))
+	require.NoError(t, graph.Start())
+
+	received := 0
+	for e := range events {
+		assert.Equal(t, clock.Now(), e.Time)
+		received++
+	}
+	assert.Equal(t, 5, received)
+
+	// each run requires a new events channel
+	next := graph.Events()
+	assert.NotEqual(t, events, next)
+	select {
+	case <-next:
+		assert.Fail(t, "the events channel of the next run should not be closed")
+	default:
+	}
+}
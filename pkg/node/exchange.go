@@ -0,0 +1,177 @@
+package node
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/netobserv/gopipes/pkg/node/internal/connect"
+)
+
+// Exchange is a node that routes each received item to the receivers whose subscription pattern
+// matches the routing key of the item, as in an AMQP topic exchange. It allows publish-subscribe
+// fan-out by topic within a graph.
+// The receivers are registered with the SubscribePattern method.
+type Exchange[T any] struct {
+	nodeMeta
+	receiverBase[T]
+	key  func(T) string
+	subs []topicSubscription
+	// distinct receivers of all the subscriptions
+	receivers []Receiver[T]
+	done      chan struct{}
+}
+
+type topicSubscription struct {
+	pattern []string
+	// indices of the subscribed receivers in the receivers of the Exchange
+	receivers []int
+}
+
+// TopicExchange creates an Exchange node whose routing key of each item is provided by the key
+// function. The routing keys and the subscription patterns are lists of words separated by dots
+// (e.g. "flows.tcp.eth0").
+// Each item is sent to the receivers of every subscription whose pattern matches its routing key.
+// A receiver gets each item at most once, even if it is subscribed to several patterns that match
+// the routing key. The items that do not match any subscription are discarded.
+// If the key function panics, the node.WithPanicHandler option is honored as in the other nodes.
+// With the node.WithItemPool option, each receiver gets its own reference to the pooled items,
+// and the items that do not match any subscription are released.
+func TopicExchange[T any](key func(T) string, opts ...Option) *Exchange[T] {
+	options := getOptions(opts...)
+	receiver := newReceiverBase[T](&options)
+	e := &Exchange[T]{
+		nodeMeta:     newNodeMeta(&options, KindMiddle, Schema{In: receiver.inType, Out: receiver.inType}),
+		receiverBase: receiver,
+		key:          key,
+		done:         make(chan struct{}),
+	}
+	e.inputDone = e.receiverBase.inputClosed
+	return e
+}
+
+// SubscribePattern registers the receivers of the items whose routing key matches the pattern.
+// In the pattern, "*" matches exactly one word, and "#" matches zero or more words (e.g.
+// "flows.*.eth0" matches "flows.tcp.eth0", and "flows.#" matches "flows" and "flows.tcp.eth0").
+// A receiver can be subscribed to multiple patterns.
+// It returns the passed Exchange, so multiple invocations can be chained. It must be invoked
+// before the Exchange is started.
+func (e *Exchange[T]) SubscribePattern(pattern string, receivers ...Receiver[T]) *Exchange[T] {
+	sub := topicSubscription{pattern: strings.Split(pattern, ".")}
+	for _, r := range receivers {
+		idx := receiverIndex(e.receivers, r)
+		if idx < 0 {
+			e.receivers = connectTo(&e.nodeMeta, e.receivers, []Receiver[T]{r})
+			idx = len(e.receivers) - 1
+		}
+		sub.receivers = append(sub.receivers, idx)
+	}
+	e.subs = append(e.subs, sub)
+	return e
+}
+
+// Done returns a channel that is closed when the Exchange node has ended its processing. This
+// is, when its input has been closed and all its outputs have been closed.
+func (e *Exchange[T]) Done() <-chan struct{} {
+	return e.done
+}
+
+// Kind returns KindMiddle
+func (e *Exchange[T]) Kind() NodeKind {
+	return KindMiddle
+}
+
+// Schema returns the input and output types of the Exchange, which are the same
+func (e *Exchange[T]) Schema() Schema {
+	return Schema{In: e.inType, Out: e.inType}
+}
+
+// Stats returns runtime information about the Exchange node
+func (e *Exchange[T]) Stats() Stats {
+	stats := e.receiverBase.Stats()
+	e.senderStats(&stats)
+	return stats
+}
+
+func (e *Exchange[T]) outputs() []graphNode {
+	return receiversAsNodes(e.receivers)
+}
+
+func (e *Exchange[T]) start(ctx context.Context) {
+	if len(e.outputs()) == 0 {
+		panicNoOutputs(&e.nodeMeta)
+	}
+	if !e.markStarted() {
+		return
+	}
+	forkers := make([]connect.Forker[T], 0, len(e.receivers))
+	for _, r := range e.receivers {
+		// the emitted items are counted by route, as an item can be sent through multiple forkers
+		forkers = append(forkers, forkCounting(ctx, &e.nodeMeta, []Receiver[T]{r}, nil))
+	}
+	go func() {
+		e.notifyStart()
+		if !e.invoke(e, func() { e.route(forkers) }) {
+			drain[T](e.inputs.Receiver())
+		}
+		for _, f := range forkers {
+			f.Close()
+		}
+		e.notifyFinish()
+		close(e.done)
+	}()
+}
+
+// route sends each received item once to each receiver that is subscribed to a pattern matching
+// its routing key
+func (e *Exchange[T]) route(forkers []connect.Forker[T]) {
+	matched := make([]bool, len(forkers))
+	for item := range e.inputs.Receiver() {
+		key := strings.Split(e.key(item), ".")
+		receivers := 0
+		for _, s := range e.subs {
+			if matchTopic(s.pattern, key) {
+				for _, r := range s.receivers {
+					if !matched[r] {
+						matched[r] = true
+						receivers++
+					}
+				}
+			}
+		}
+		// each receiver has its own forker, so the references and the emitted items are counted
+		// here
+		if e.onFork != nil && receivers != 1 {
+			e.onFork(item, receivers)
+		}
+		if e.emitted != nil && receivers > 0 {
+			atomic.AddInt64(e.emitted, 1)
+		}
+		for r, f := range forkers {
+			if matched[r] {
+				matched[r] = false
+				f.Sender() <- item
+			}
+		}
+	}
+}
+
+// matchTopic returns whether the words of a routing key match the words of a pattern
+func matchTopic(pattern, key []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "#" {
+			// "#" matches any number of words, so the rest of the pattern must match any suffix
+			for skip := 0; skip <= len(key); skip++ {
+				if matchTopic(pattern[1:], key[skip:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(key) == 0 || (pattern[0] != "*" && pattern[0] != key[0]) {
+			return false
+		}
+		pattern, key = pattern[1:], key[1:]
+	}
+	return len(key) == 0
+}
@@ -0,0 +1,145 @@
+package node
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicExchange(t *testing.T) {
+	start := AsStart(func(out chan<- string) {
+		for _, topic := range []string{"flows.tcp.eth0", "flows.udp.eth1", "dns.eth0", "flows", "alerts"} {
+			out <- topic
+		}
+	})
+	exchange := TopicExchange(func(topic string) string { return topic })
+	var tcp, eth0, flows []string
+	tcpTerm := collectStrings(&tcp)
+	eth0Term := collectStrings(&eth0)
+	flowsTerm := collectStrings(&flows)
+	start.SendsTo(exchange)
+	exchange.SubscribePattern("flows.tcp.*", tcpTerm).
+		SubscribePattern("#.eth0", eth0Term).
+		SubscribePattern("flows.#", flowsTerm)
+
+	graph := NewGraph(start, exchange, tcpTerm, eth0Term, flowsTerm)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	assert.Equal(t, []string{"flows.tcp.eth0"}, tcp)
+	assert.Equal(t, []string{"flows.tcp.eth0", "dns.eth0"}, eth0)
+	assert.Equal(t, []string{"flows.tcp.eth0", "flows.udp.eth1", "flows"}, flows)
+}
+
+func TestTopicExchange_Amplification(t *testing.T) {
+	start := AsStart(func(out chan<- string) {
+		for _, topic := range []string{"flows.tcp.eth0", "flows.udp.eth1", "dns.eth0", "flows", "alerts"} {
+			out <- topic
+		}
+	})
+	exchange := TopicExchange(func(topic string) string { return topic }, CountEdges())
+	var tcp, eth0, flows []string
+	tcpTerm := collectStrings(&tcp)
+	eth0Term := collectStrings(&eth0)
+	flowsTerm := collectStrings(&flows)
+	start.SendsTo(exchange)
+	exchange.SubscribePattern("flows.tcp.*", tcpTerm).
+		SubscribePattern("#.eth0", eth0Term).
+		SubscribePattern("flows.#", flowsTerm)
+
+	graph := NewGraph(start, exchange, tcpTerm, eth0Term, flowsTerm)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	// each routed item is emitted once, regardless of its number of subscribers, and the items
+	// without subscribers are not emitted
+	stats := exchange.Stats()
+	assert.EqualValues(t, 4, stats.Emitted)
+	assert.EqualValues(t, 6, stats.Delivered)
+	assert.Equal(t, 1.5, stats.Amplification())
+}
+
+func TestTopicExchange_OverlappingPatterns(t *testing.T) {
+	start := AsStart(func(out chan<- string) {
+		for _, topic := range []string{"flows.tcp.eth0", "flows.udp.eth1", "dns.eth0"} {
+			out <- topic
+		}
+	})
+	exchange := TopicExchange(func(topic string) string { return topic })
+	var both, tcp []string
+	bothTerm := collectStrings(&both)
+	tcpTerm := collectStrings(&tcp)
+	start.SendsTo(exchange)
+	exchange.SubscribePattern("flows.#", bothTerm).
+		SubscribePattern("#.eth0", bothTerm, tcpTerm).
+		SubscribePattern("*.tcp.*", tcpTerm)
+
+	graph := NewGraph(start, exchange, bothTerm, tcpTerm)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	assert.Equal(t, []string{"flows.tcp.eth0", "flows.udp.eth1", "dns.eth0"}, both)
+	assert.Equal(t, []string{"flows.tcp.eth0", "dns.eth0"}, tcp)
+}
+
+func TestTopicExchange_PanicHandler(t *testing.T) {
+	start := AsStart(func(out chan<- string) {
+		for _, topic := range []string{"flows.tcp", "", "flows.udp"} {
+			out <- topic
+		}
+	})
+	panics := make(chan NodePanic, 1)
+	exchange := TopicExchange(func(topic string) string {
+		if topic == "" {
+			panic("empty topic")
+		}
+		return topic
+	}, WithPanicHandler(func(p NodePanic) { panics <- p }))
+	var flows []string
+	flowsTerm := collectStrings(&flows)
+	start.SendsTo(exchange)
+	exchange.SubscribePattern("flows.*", flowsTerm)
+
+	graph := NewGraph(start, exchange, flowsTerm)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	assert.Equal(t, []string{"flows.tcp"}, flows)
+	assert.Equal(t, StateFailed, exchange.State())
+	select {
+	case p := <-panics:
+		assert.Equal(t, "empty topic", p.Value)
+	default:
+		require.Fail(t, "the panic handler should have been invoked")
+	}
+}
+
+func TestMatchTopic(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, key string
+		match        bool
+	}{
+		{"a.b.c", "a.b.c", true},
+		{"a.b.c", "a.b", false},
+		{"a.*.c", "a.b.c", true},
+		{"a.*.c", "a.c", false},
+		{"*", "a", true},
+		{"*", "a.b", false},
+		{"#", "a.b.c", true},
+		{"a.#", "a", true},
+		{"a.#.c", "a.c", true},
+		{"a.#.c", "a.b.b.c", true},
+		{"a.#.c", "a.b.d", false},
+		{"#.*", "a", true},
+		{"a.#.*.d", "a.b.c", false},
+	} {
+		assert.Equal(t, tc.match, matchTopic(strings.Split(tc.pattern, "."), strings.Split(tc.key, ".")),
+			"%s -> %s", tc.pattern, tc.key)
+	}
+}
+
+func collectStrings(into *[]string) *Terminal[string] {
+	return AsTerminal(func(in <-chan string) {
+		for s := range in {
+			*into = append(*into, s)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package node
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ExpireBy returns a Middle node that forwards the received items whose deadline, as returned by
+// the provided function, has not yet passed. Expired items are dropped, so the downstream nodes
+// don't waste work on already-stale data.
+// The returned function provides the number of items that have been dropped so far.
+// The node.WithClock option allows overriding the source of time.
+func ExpireBy[T any](deadline func(T) time.Time, opts ...Option) (*Middle[T, T], func() int64) {
+	clock := getOptions(opts...).clock
+	dropped := int64(0)
+	middle := AsMiddle(func(in <-chan T, out chan<- T) {
+		for item := range in {
+			if clock.Now().After(deadline(item)) {
+				atomic.AddInt64(&dropped, 1)
+				continue
+			}
+			out <- item
+		}
+	}, opts...)
+	return middle, func() int64 {
+		return atomic.LoadInt64(&dropped)
+	}
+}
@@ -0,0 +1,49 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func (c fixedClock) NewTimer(d time.Duration) Timer {
+	return systemClock{}.NewTimer(d)
+}
+
+func TestExpireBy(t *testing.T) {
+	type item struct {
+		id       int
+		deadline time.Time
+	}
+	now := time.Date(2022, 3, 4, 12, 0, 0, 0, time.UTC)
+	start := AsStart(func(out chan<- item) {
+		out <- item{id: 1, deadline: now.Add(time.Second)}
+		out <- item{id: 2, deadline: now.Add(-time.Second)}
+		out <- item{id: 3, deadline: now}
+		out <- item{id: 4, deadline: now.Add(-time.Minute)}
+		out <- item{id: 5, deadline: now.Add(time.Minute)}
+	})
+	expire, dropped := ExpireBy(func(i item) time.Time {
+		return i.deadline
+	}, WithClock(fixedClock(now)))
+	var ids []int
+	term := AsTerminal(func(in <-chan item) {
+		for i := range in {
+			ids = append(ids, i.id)
+		}
+	})
+	start.SendsTo(expire)
+	expire.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{1, 3, 5}, ids)
+	assert.EqualValues(t, 2, dropped())
+}
@@ -0,0 +1,23 @@
+package node
+
+// Firehose returns a Start node that sends count items as fast as possible, where the i-th item
+// (starting from 0) is provided by the gen function. Together with Blackhole, it allows
+// measuring the per-item overhead of a graph (e.g. to tune the buffers of its nodes), separately
+// from the logic of the nodes.
+// Unlike Generate, it does not check its context between items, so it can't be stopped early.
+func Firehose[T any](gen func(i int) T, count int, opts ...Option) *Start[T] {
+	return AsStart(func(out chan<- T) {
+		for i := 0; i < count; i++ {
+			out <- gen(i)
+		}
+	}, opts...)
+}
+
+// Blackhole returns a Terminal node that discards all the received items as fast as possible.
+// See Firehose.
+func Blackhole[T any](opts ...Option) *Terminal[T] {
+	return AsTerminal(func(in <-chan T) {
+		for range in {
+		}
+	}, opts...)
+}
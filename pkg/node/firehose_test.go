@@ -0,0 +1,62 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirehose(t *testing.T) {
+	start := Firehose(func(i int) int { return i * 2 }, 100)
+	count := Scan(0, func(acc, _ int) int { return acc + 1 })
+	var last int
+	term := ForEach(func(n int) { last = n })
+	start.SendsTo(count)
+	count.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, 100, last)
+}
+
+func TestBlackhole(t *testing.T) {
+	start := Firehose(func(i int) int { return i }, 1000, CountEdges())
+	hole := Blackhole[int]()
+	start.SendsTo(hole)
+	graph := NewGraph(start, hole)
+	require.NoError(t, graph.Start())
+
+	waitDone(t, graph.Done())
+	assert.EqualValues(t, 1000, start.Stats().Emitted)
+}
+
+// BenchmarkPipeline measures the per-item overhead of a graph with the given number of
+// pass-through Middle stages and input buffer length.
+func BenchmarkPipeline(b *testing.B) {
+	for _, stages := range []int{1, 4} {
+		for _, bufLen := range []int{0, 64} {
+			b.Run(fmt.Sprintf("stages=%d/buffer=%d", stages, bufLen), func(b *testing.B) {
+				start := Firehose(func(i int) int { return i }, b.N)
+				var last Sender[int] = start
+				nodes := []Node{start}
+				for s := 0; s < stages; s++ {
+					stage := Map(func(n int) int { return n }, ChannelBufferLen(bufLen))
+					last.SendsTo(stage)
+					last = stage
+					nodes = append(nodes, stage)
+				}
+				hole := Blackhole[int](ChannelBufferLen(bufLen))
+				last.SendsTo(hole)
+				graph := NewGraph(append(nodes, hole)...)
+
+				b.ResetTimer()
+				if err := graph.Start(); err != nil {
+					b.Fatal(err)
+				}
+				<-graph.Done()
+			})
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGate(t *testing.T) {
+	gate := make(chan struct{})
+	invoked := make(chan struct{})
+	start1 := AsStart(func(out chan<- int) {
+		close(invoked)
+		out <- 1
+	})
+	start2 := AsStart(Counter(2, 3))
+	start1.Gate(gate)
+	start2.Gate(gate)
+	var received []int
+	middleStarted := make(chan struct{})
+	middle := AsMiddleCtx(func(_ context.Context, in <-chan int, out chan<- int) {
+		close(middleStarted)
+		for n := range in {
+			out <- n
+		}
+	})
+	term := collectInts(&received)
+	start1.SendsTo(middle)
+	start2.SendsTo(middle)
+	middle.SendsTo(term)
+	start1.Start()
+	start2.Start()
+
+	// the rest of the graph is live, but the start functions are not invoked
+	nodetest.ExpectClosed(t, middleStarted, timeout)
+	nodetest.ExpectBlocked(t, invoked)
+
+	close(gate)
+	waitDone(t, term.Done())
+	assert.ElementsMatch(t, []int{1, 2, 3}, received)
+}
+
+func TestGate_Cancel(t *testing.T) {
+	invoked := false
+	start := AsStart(func(out chan<- int) {
+		invoked = true
+	})
+	start.Gate(make(chan struct{}))
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	start.SendsTo(term)
+	ctx, cancel := context.WithCancel(context.Background())
+	start.StartCtx(ctx)
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	waitDone(t, term.Done())
+	assert.False(t, invoked)
+}
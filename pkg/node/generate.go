@@ -0,0 +1,123 @@
+package node
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+)
+
+// SourceProgress reports the progress of a finite Start node (e.g. node.Range), which is
+// attached to it with the node.ReportProgress option. It is safe to poll it concurrently with the
+// node execution (e.g. to render a progress bar).
+type SourceProgress struct {
+	done  int64
+	total int64
+}
+
+// NewSourceProgress creates a SourceProgress, whose total is unknown (-1) until the node that
+// reports to it is started.
+func NewSourceProgress() *SourceProgress {
+	return &SourceProgress{total: -1}
+}
+
+// Progress returns the number of items that the node has sent, and the total number of items
+// that it will send, or -1 if the total is unknown (e.g. the node has not started, or it does
+// not know its length).
+func (p *SourceProgress) Progress() (done, total int) {
+	return int(atomic.LoadInt64(&p.done)), int(atomic.LoadInt64(&p.total))
+}
+
+func (p *SourceProgress) begin(total int) {
+	if p != nil {
+		atomic.StoreInt64(&p.total, int64(total))
+	}
+}
+
+func (p *SourceProgress) sent() {
+	if p != nil {
+		atomic.AddInt64(&p.done, 1)
+	}
+}
+
+// Range returns a Start node that sends the integers from the from argument to the to argument,
+// both inclusive, increasing them by step. If step is negative, the integers decrease down to the
+// to argument. The node stops early if its context is cancelled.
+// The node.ReportProgress option allows observing the progress of the node. Its total is unknown
+// if the number of integers does not fit in an int.
+func Range(from, to, step int, opts ...Option) *Start[int] {
+	if step == 0 {
+		panic("Range step can't be zero")
+	}
+	progress := getOptions(opts...).progress
+	return AsStartCtx(func(ctx context.Context, out chan<- int) {
+		total := rangeLen(from, to, step)
+		progress.begin(total)
+		if total == 0 {
+			return
+		}
+		// absolute value of the step, which does not overflow for math.MinInt
+		stride := rangeDistance(0, step, step)
+		for i := from; ; i += step {
+			select {
+			case out <- i:
+				progress.sent()
+			case <-ctx.Done():
+				return
+			}
+			// stops before the next increment can overflow
+			if rangeDistance(i, to, step) < stride {
+				return
+			}
+		}
+	}, opts...)
+}
+
+// rangeDistance returns the distance from i to to, in the direction of step, as an unsigned
+// integer that does not overflow for any pair of ints
+func rangeDistance(i, to, step int) uint {
+	if step < 0 {
+		return uint(i) - uint(to)
+	}
+	return uint(to) - uint(i)
+}
+
+// rangeLen returns the number of integers that Range sends, or -1 if it does not fit in an int
+func rangeLen(from, to, step int) int {
+	if (step > 0 && to < from) || (step < 0 && to > from) {
+		return 0
+	}
+	n := rangeDistance(from, to, step) / rangeDistance(0, step, step)
+	if n >= math.MaxInt {
+		return -1
+	}
+	return int(n) + 1
+}
+
+// Generate returns a Start node that sends n items, where the i-th item (starting from 0) is
+// provided by the gen function. The node stops early if its context is cancelled.
+// The node.ReportProgress option allows observing the progress of the node.
+func Generate[T any](n int, gen func(i int) T, opts ...Option) *Start[T] {
+	progress := getOptions(opts...).progress
+	return AsStartCtx(func(ctx context.Context, out chan<- T) {
+		total := n
+		if total < 0 {
+			total = 0
+		}
+		progress.begin(total)
+		for i := 0; i < n; i++ {
+			select {
+			case out <- gen(i):
+				progress.sent()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, opts...)
+}
+
+// FromSlice returns a Start node that sends the items of the provided slice, in order. The node
+// stops early if its context is cancelled.
+// The node.ReportProgress option allows observing the progress of the node.
+func FromSlice[T any](items []T, opts ...Option) *Start[T] {
+	return Generate(len(items), func(i int) T { return items[i] }, opts...)
+}
@@ -0,0 +1,140 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRange(t *testing.T) {
+	for _, tc := range []struct {
+		from, to, step int
+		expected       []int
+	}{
+		{from: 1, to: 5, step: 1, expected: []int{1, 2, 3, 4, 5}},
+		{from: 0, to: 9, step: 3, expected: []int{0, 3, 6, 9}},
+		{from: 0, to: 10, step: 3, expected: []int{0, 3, 6, 9}},
+		{from: 5, to: 1, step: -2, expected: []int{5, 3, 1}},
+		{from: 5, to: 1, step: 1, expected: nil},
+		// the integers don't overflow at the limits of int
+		{from: math.MaxInt - 1, to: math.MaxInt, step: 1, expected: []int{math.MaxInt - 1, math.MaxInt}},
+		{from: math.MaxInt - 3, to: math.MaxInt, step: 2, expected: []int{math.MaxInt - 3, math.MaxInt - 1}},
+		{from: math.MinInt + 1, to: math.MinInt, step: -1, expected: []int{math.MinInt + 1, math.MinInt}},
+		{from: math.MinInt, to: math.MaxInt, step: math.MaxInt, expected: []int{math.MinInt, -1, math.MaxInt - 1}},
+		{from: math.MaxInt, to: math.MinInt, step: math.MinInt, expected: []int{math.MaxInt, -1}},
+	} {
+		start := Range(tc.from, tc.to, tc.step)
+		var received []int
+		term := collectInts(&received)
+		start.SendsTo(term)
+		start.Start()
+		waitDone(t, term.Done())
+		assert.Equal(t, tc.expected, received, "%+v", tc)
+	}
+	assert.Panics(t, func() {
+		Range(1, 5, 0)
+	})
+}
+
+func TestRangeLen_Overflow(t *testing.T) {
+	assert.Equal(t, math.MaxInt, rangeLen(1, math.MaxInt, 1))
+	assert.Equal(t, math.MaxInt, rangeLen(math.MinInt, -2, 1))
+	// the number of integers does not fit in an int
+	assert.Equal(t, -1, rangeLen(0, math.MaxInt, 1))
+	assert.Equal(t, -1, rangeLen(math.MaxInt, math.MinInt, -1))
+	assert.Equal(t, -1, rangeLen(math.MinInt, math.MaxInt, 2))
+	assert.Equal(t, 2, rangeLen(math.MaxInt, math.MinInt, math.MinInt))
+}
+
+func TestGenerate(t *testing.T) {
+	start := Generate(3, func(i int) string {
+		return fmt.Sprint("item ", i)
+	})
+	probe := start.Probe()
+	start.Start()
+	items, err := probe.Expect(3, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"item 0", "item 1", "item 2"}, items)
+	_, ok := probe.Next(timeout)
+	assert.False(t, ok)
+}
+
+func TestRangeGenerate_Cancel(t *testing.T) {
+	for name, start := range map[string]*Start[int]{
+		"Range":    Range(0, 1_000_000, 1),
+		"Generate": Generate(1_000_000, func(i int) int { return i }),
+	} {
+		t.Run(name, func(t *testing.T) {
+			probe := start.Probe()
+			ctx, cancel := context.WithCancel(context.Background())
+			start.StartCtx(ctx)
+			items, err := probe.Expect(2, timeout)
+			require.NoError(t, err)
+			assert.Equal(t, []int{0, 1}, items)
+
+			cancel()
+			waitDone(t, start.Done())
+			// only the items that were already in the probe buffer are received, plus the
+			// ones that were being sent when the node and its output observed the cancellation
+			remaining := 0
+			for _, ok := probe.Next(timeout); ok; _, ok = probe.Next(timeout) {
+				remaining++
+			}
+			assert.LessOrEqual(t, remaining, probeBufferLen+2)
+		})
+	}
+}
+
+func TestFromSlice_Progress(t *testing.T) {
+	progress := NewSourceProgress()
+	done, total := progress.Progress()
+	assert.Zero(t, done)
+	assert.Equal(t, -1, total)
+
+	start := FromSlice([]string{"a", "b", "c"}, ReportProgress(progress))
+	probe := start.Probe()
+	start.Start()
+
+	items, err := probe.Expect(2, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, items)
+	// the third item may be already sent
+	done, total = progress.Progress()
+	assert.GreaterOrEqual(t, done, 2)
+	assert.Equal(t, 3, total)
+
+	items, err = probe.Expect(1, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, items)
+	waitDone(t, start.Done())
+	done, total = progress.Progress()
+	assert.Equal(t, 3, done)
+	assert.Equal(t, 3, total)
+}
+
+func TestRange_Progress(t *testing.T) {
+	for _, tc := range []struct {
+		from, to, step, total int
+	}{
+		{from: 0, to: 10, step: 3, total: 4},
+		{from: 5, to: 1, step: -2, total: 3},
+		{from: 5, to: 1, step: 1, total: 0},
+		{from: math.MinInt, to: math.MaxInt, step: math.MaxInt, total: 3},
+		{from: math.MaxInt - 1, to: math.MaxInt, step: 1, total: 2},
+	} {
+		progress := NewSourceProgress()
+		start := Range(tc.from, tc.to, tc.step, ReportProgress(progress))
+		var received []int
+		term := collectInts(&received)
+		start.SendsTo(term)
+		start.Start()
+		waitDone(t, term.Done())
+		done, total := progress.Progress()
+		assert.Equal(t, tc.total, total, "%+v", tc)
+		assert.Equal(t, tc.total, done, "%+v", tc)
+	}
+}
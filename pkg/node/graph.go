@@ -0,0 +1,569 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// graphNode is implemented by all the node types, allowing to inspect the topology of a graph
+// regardless of the types of the data that is sent across its nodes.
+type graphNode interface {
+	// outputs returns the nodes that are directly connected to the output of this node
+	outputs() []graphNode
+}
+
+// starter is implemented by the nodes that are the source of a graph (e.g. node.Start)
+type starter interface {
+	Node
+	StartCtx(ctx context.Context)
+	Stop()
+}
+
+// Graph groups the nodes of a pipeline, allowing to validate its topology and to start all
+// its Start nodes at once.
+// Connecting the nodes is still done through the SendsTo method of each node.
+type Graph struct {
+	nodes   []Node
+	cancel  context.CancelFunc
+	latency *LatencyHistogram
+	// queue of the events of the next run, and the clock of their times
+	events      chan Event
+	eventsClock Clock
+	onComplete  []func(error)
+	// if not nil, bounds the buffered inputs of all the nodes
+	memoryBudget *MemoryBudget
+}
+
+// NewGraph creates a Graph containing the provided nodes (e.g. *node.Start, *node.Middle or
+// *node.Terminal).
+func NewGraph(nodes ...Node) *Graph {
+	g := &Graph{}
+	g.Add(nodes...)
+	return g
+}
+
+// Add the provided nodes to the graph.
+func (g *Graph) Add(nodes ...Node) {
+	g.nodes = append(g.nodes, nodes...)
+}
+
+// Nodes returns the nodes of the graph, in the same order as they were added.
+func (g *Graph) Nodes() []Node {
+	return append([]Node{}, g.nodes...)
+}
+
+// NodesLabeled returns the nodes of the graph that have the provided label key and value, in
+// the same order as they were added.
+func (g *Graph) NodesLabeled(key, value string) []Node {
+	var nodes []Node
+	for _, n := range g.nodes {
+		if v, ok := n.meta().labels[key]; ok && v == value {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// EnableLatency enables the tracking of the end-to-end latency of the graph, creating its
+// histogram with the provided bucket bounds, or with the default bounds if none is provided. It
+// returns the histogram, which is also returned by Latency. It must be invoked before Latency,
+// while the graph is built.
+func (g *Graph) EnableLatency(bounds ...time.Duration) *LatencyHistogram {
+	g.latency = NewLatencyHistogram(bounds...)
+	return g.latency
+}
+
+// WithMemoryBudget bounds the accumulated size of the items that are buffered in the inputs of
+// all the nodes of the graph, so the total memory of the buffered items can't exceed maxBytes
+// regardless of which node is backed up. The size of each item is estimated by the sizeOf
+// function, which receives the items of all the nodes. When the budget is exhausted, the senders
+// are blocked until some buffered items are processed.
+// The budget applies, when the graph is started, to the nodes whose input is buffered with the
+// default buffer of the node.ChannelBufferLen option, which keeps bounding their number of items.
+// The unbuffered inputs don't hold items, and the nodes with other buffer options (e.g.
+// node.WithByteBuffer or their own node.WithMemoryBudget) keep their buffers.
+// It returns the budget, which allows observing the memory in use. See node.NewMemoryBudget.
+func (g *Graph) WithMemoryBudget(maxBytes int, sizeOf func(any) int) *MemoryBudget {
+	g.memoryBudget = NewMemoryBudget(maxBytes, sizeOf)
+	return g.memoryBudget
+}
+
+// Latency returns the histogram of the end-to-end latency of the graph, which records the
+// latency of the items that traverse the graph between a node.Timestamp node and a
+// node.RecordLatency node created with this histogram, e.g.:
+//
+//	graph.EnableLatency()
+//	record := node.RecordLatency[T](graph.Latency())
+//
+// It returns nil if the latency tracking has not been enabled with EnableLatency, so the
+// RecordLatency nodes do not record anything.
+func (g *Graph) Latency() *LatencyHistogram {
+	return g.latency
+}
+
+// Validate verifies that the graph is properly wired, returning an error otherwise. This is:
+// the graph has at least one Start node, all the Start and Middle nodes have outputs, all the
+// Middle and Terminal nodes have an inbound connection from any Start node of the graph, and
+// the nodes do not form a cycle.
+// Unconnected Middle and Terminal nodes would never start, so a Terminal Done channel would never
+// be closed.
+func (g *Graph) Validate() error {
+	var sources []Node
+	for _, n := range g.nodes {
+		if _, ok := n.(starter); ok {
+			sources = append(sources, n)
+		}
+	}
+	if len(sources) == 0 {
+		return errors.New("graph must have at least one Start node")
+	}
+	reachable := map[graphNode]struct{}{}
+	for _, src := range sources {
+		markReachable(src, reachable)
+	}
+	if err := validateSubgraphs(g.nodes, reachable); err != nil {
+		return err
+	}
+	var noOutputs []string
+	for i, n := range g.nodes {
+		if n.Kind() != KindTerminal && len(n.outputs()) == 0 {
+			noOutputs = append(noOutputs, describe(i, n))
+		}
+	}
+	if len(noOutputs) > 0 {
+		return fmt.Errorf("nodes without outputs: %s", strings.Join(noOutputs, ", "))
+	}
+	var orphans []string
+	for i, n := range g.nodes {
+		if _, ok := reachable[n]; !ok {
+			orphans = append(orphans, describe(i, n))
+		}
+	}
+	if len(orphans) > 0 {
+		return fmt.Errorf("nodes not connected from any Start node: %s", strings.Join(orphans, ", "))
+	}
+	_, err := g.TopoSort()
+	return err
+}
+
+// TopoSort returns the nodes of the graph in topological order: each node goes after all the
+// nodes that send data to it. The returned nodes also include the nodes that have not been
+// added to the graph but receive data from any of its nodes.
+// It returns an error if the graph contains a cycle, as the nodes in a cycle would never
+// finish.
+func (g *Graph) TopoSort() ([]Node, error) {
+	return topologicalOrder(g.nodes)
+}
+
+// Edge is a connection between two nodes of a graph.
+type Edge struct {
+	From NodeInfo
+	To   NodeInfo
+	// Counted is true if the sender node has been created with the node.CountEdges option
+	Counted bool
+	// Count is the number of items that have been sent through the edge, if Counted is true
+	Count int64
+}
+
+// Edges returns the connections between the nodes of the graph, including the unlisted nodes
+// that receive data from any of its nodes. The edges are ordered by sender node, in the order
+// in which the nodes were added to the graph and connected.
+// The items that each node sends to its receivers are only counted if the node has been created
+// with the node.CountEdges option. Edges can be invoked while the graph is running, to get the
+// current counts.
+func (g *Graph) Edges() []Edge {
+	var edges []Edge
+	for _, n := range discoverNodes(g.nodes) {
+		from := infoOf(n)
+		counts := n.meta().edgeCounts
+		visited := map[graphNode]struct{}{}
+		for _, out := range n.outputs() {
+			// a node can be connected multiple times to the same receiver (e.g. from different
+			// cases of a Switch)
+			if _, ok := visited[out]; ok {
+				continue
+			}
+			visited[out] = struct{}{}
+			edge := Edge{From: from, To: infoOf(out.(Node))}
+			if counter, ok := counts[out]; ok {
+				edge.Counted = true
+				edge.Count = atomic.LoadInt64(counter)
+			}
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// Start validates the graph and, if it is valid, starts all its Start nodes.
+func (g *Graph) Start() error {
+	return g.StartCtx(context.TODO())
+}
+
+// StartCtx validates the graph and, if it is valid, starts all its Start nodes with the
+// provided context. The context passed to the nodes carries a new GraphContext, which is
+// accessible through the GraphContextFrom function. It is cancelled once all the nodes of the
+// graph have finished, to release its resources.
+func (g *Graph) StartCtx(ctx context.Context) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+	var events chan Event
+	// the graph is valid, so it has no cycles
+	order, _ := g.TopoSort()
+	clock := g.eventsClock
+	if g.events != nil {
+		events = make(chan Event)
+		go pumpEvents(events, g.events)
+		// the next run requires a new invocation of Events
+		g.events = nil
+	}
+	var panics *panicRecorder
+	if len(g.onComplete) > 0 {
+		panics = &panicRecorder{}
+		runOnComplete(ctx, order, panics, g.onComplete)
+	}
+	if events != nil || panics != nil {
+		observePanics(order, events, clock, panics)
+	}
+	if g.memoryBudget != nil {
+		for _, n := range order {
+			if r, ok := n.(interface{ useMemoryBudget(*MemoryBudget) }); ok {
+				r.useMemoryBudget(g.memoryBudget)
+			}
+		}
+	}
+	if events != nil {
+		// the NodeStarted events are queued before starting the nodes, so they precede any other
+		// event of the nodes (e.g. the NodeErrored event of a node that panics as soon as it starts)
+		emitEvents(order, events, clock)
+	}
+	ctx, cancel := context.WithCancel(WithGraphContext(ctx))
+	g.cancel = cancel
+	for _, n := range g.nodes {
+		if s, ok := n.(starter); ok {
+			s.StartCtx(ctx)
+		}
+	}
+	// release the resources of the context once all the nodes have finished
+	go func() {
+		for _, n := range order {
+			<-n.Done()
+		}
+		cancel()
+	}()
+	return nil
+}
+
+// RunForResult starts the graph with the provided context, waits for all its Terminal nodes to
+// finish, and returns the value provided by the result function. The result function is
+// usually the getter of a Terminal node that computes the result of the graph (e.g. the
+// function returned by node.ToMap), so a batch graph can be invoked as a function call.
+// Go does not allow methods with type parameters, so it is a function instead of a Graph method.
+// It returns an error if the graph is not valid, or if the context is cancelled before the graph
+// finishes. In the latter case, it waits for the graph to finish before returning, so the nodes
+// are not left running. The Start nodes that do not stop when their context is cancelled (e.g.
+// the ones created with AsStart) will prevent RunForResult from returning.
+func RunForResult[R any](ctx context.Context, g *Graph, result func() R) (R, error) {
+	var zero R
+	if err := g.StartCtx(ctx); err != nil {
+		return zero, err
+	}
+	done := g.Done()
+	select {
+	case <-done:
+		return result(), nil
+	case <-ctx.Done():
+	}
+	<-done
+	return zero, fmt.Errorf("waiting for the graph to finish: %w", ctx.Err())
+}
+
+// RunFor starts the graph, stops its Start nodes after the provided duration (e.g. for a
+// sampling run that processes data for a limited time), and waits for the graph to finish.
+// If the graph completes before the duration, RunFor returns nil. Otherwise, the Start nodes are
+// stopped as with their Stop method, and RunFor waits for the rest of nodes to process the data
+// that was still flowing through the graph before returning an error that wraps
+// context.DeadlineExceeded.
+// The Start nodes that do not stop when their context is cancelled (e.g. the ones created with
+// AsStart) will prevent RunFor from returning. It also returns an error if the graph is not valid.
+func (g *Graph) RunFor(d time.Duration) error {
+	if err := g.StartCtx(context.Background()); err != nil {
+		return err
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	done := g.Done()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+	}
+	g.stopSources()
+	<-done
+	return fmt.Errorf("graph run limited to %v: %w", d, context.DeadlineExceeded)
+}
+
+// Done returns a channel that is closed when all the Terminal nodes of the graph have finished
+// their processing.
+func (g *Graph) Done() <-chan struct{} {
+	var terminals []Doner
+	for _, n := range g.nodes {
+		if n.Kind() == KindTerminal {
+			terminals = append(terminals, n)
+		}
+	}
+	return AllDone(terminals...)
+}
+
+// Shutdown stops the Start nodes of the graph, as with their Stop method, and waits for all the
+// nodes to finish, in topological order: first the Start nodes, then the nodes that receive data
+// from them, and so on, until the Terminal nodes finish after processing all the data that was
+// still flowing through the graph.
+// The Start nodes that do not stop when their context is cancelled (e.g. the ones created with
+// AsStart) will prevent the graph from finishing.
+// If the provided context is cancelled before the graph finishes, Shutdown cancels the context
+// of the whole graph, so the nodes that send data to multiple receivers stop forwarding it, and
+// returns an error containing the name of the first unfinished node.
+// The behavior of Shutdown can be customized with ShutdownOption arguments (e.g.
+// WithDrainProgress).
+func (g *Graph) Shutdown(ctx context.Context, opts ...ShutdownOption) error {
+	if g.cancel == nil {
+		return errors.New("graph is not started")
+	}
+	options := shutdownOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	order, err := g.TopoSort()
+	if err != nil {
+		return err
+	}
+	g.stopSources()
+	stopProgress := func() {}
+	if options.drainProgress != nil {
+		stop, stopped := make(chan struct{}), make(chan struct{})
+		go func() {
+			reportDrainProgress(order, options.drainProgress, stop)
+			close(stopped)
+		}()
+		stopProgress = func() {
+			close(stop)
+			<-stopped
+		}
+	}
+	for _, n := range order {
+		select {
+		case <-n.Done():
+		case <-ctx.Done():
+			// give up draining: the nodes that are still running stop forwarding data
+			g.cancel()
+			stopProgress()
+			return fmt.Errorf("waiting for node %s to finish: %w", n.Name(), ctx.Err())
+		}
+	}
+	stopProgress()
+	if options.drainProgress != nil {
+		options.drainProgress(bufferedItems(order))
+	}
+	return nil
+}
+
+// stopSources invokes the Stop method of all the Start nodes of the graph, so they finish without
+// cancelling the context of the rest of nodes, which can process the data still flowing
+func (g *Graph) stopSources() {
+	for _, n := range g.nodes {
+		if s, ok := n.(starter); ok {
+			s.Stop()
+		}
+	}
+}
+
+// drainProgressInterval is the period at which Shutdown reports the drain progress
+const drainProgressInterval = 100 * time.Millisecond
+
+// ShutdownOption customizes the behavior of Graph.Shutdown
+type ShutdownOption func(*shutdownOptions)
+
+type shutdownOptions struct {
+	drainProgress func(remaining int)
+}
+
+// WithDrainProgress is a ShutdownOption that periodically invokes the provided function while
+// the graph is shutting down, with the number of items that are still waiting in the input
+// buffers of the graph nodes (e.g. to show the progress of the shutdown to the user). It is
+// invoked a last time once all the nodes have finished.
+// The items that are being processed by the node functions are not counted.
+func WithDrainProgress(progress func(remaining int)) ShutdownOption {
+	return func(options *shutdownOptions) {
+		options.drainProgress = progress
+	}
+}
+
+func reportDrainProgress(nodes []Node, progress func(remaining int), stop <-chan struct{}) {
+	ticker := time.NewTicker(drainProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			progress(bufferedItems(nodes))
+		}
+	}
+}
+
+func bufferedItems(nodes []Node) int {
+	items := 0
+	for _, n := range nodes {
+		items += n.Stats().BufferLen
+	}
+	return items
+}
+
+// topologicalOrder returns all the nodes that are reachable from the provided nodes, ordered in
+// a way that each node goes after all the nodes that send data to it.
+// It returns an error if the nodes form a cycle.
+func topologicalOrder(nodes []Node) ([]Node, error) {
+	all := discoverNodes(nodes)
+	inbound := map[graphNode]int{}
+	for _, n := range all {
+		for _, out := range n.outputs() {
+			inbound[out]++
+		}
+	}
+	order := make([]Node, 0, len(all))
+	for _, n := range all {
+		if inbound[n] == 0 {
+			order = append(order, n)
+		}
+	}
+	for i := 0; i < len(order); i++ {
+		for _, out := range order[i].outputs() {
+			inbound[out]--
+			if inbound[out] == 0 {
+				order = append(order, out.(Node))
+			}
+		}
+	}
+	if len(order) < len(all) {
+		var cycle []string
+		for _, n := range cycleNodes(all, inbound) {
+			cycle = append(cycle, n.Name())
+		}
+		return nil, fmt.Errorf("graph contains a cycle involving nodes: %s", strings.Join(cycle, ", "))
+	}
+	return order, nil
+}
+
+// discoverNodes returns the provided nodes and the unlisted nodes that receive data from them,
+// in order of discovery.
+func discoverNodes(nodes []Node) []Node {
+	var all []Node
+	discovered := map[graphNode]struct{}{}
+	var discover func(n Node)
+	discover = func(n Node) {
+		if _, ok := discovered[n]; ok {
+			return
+		}
+		discovered[n] = struct{}{}
+		all = append(all, n)
+		for _, out := range n.outputs() {
+			discover(out.(Node))
+		}
+	}
+	for _, n := range nodes {
+		discover(n)
+	}
+	return all
+}
+
+// cycleNodes returns the nodes that are part of a cycle, given the nodes that could not be
+// sorted topologically (inbound > 0). Those include the nodes that only receive data from a
+// cycle, which are discarded by iteratively removing the nodes whose outputs are not
+// part of a cycle.
+func cycleNodes(all []Node, inbound map[graphNode]int) []Node {
+	remaining := map[graphNode]struct{}{}
+	for _, n := range all {
+		if inbound[n] > 0 {
+			remaining[n] = struct{}{}
+		}
+	}
+	for removed := true; removed; {
+		removed = false
+		for n := range remaining {
+			sendsToCycle := false
+			for _, out := range n.outputs() {
+				if _, ok := remaining[out]; ok {
+					sendsToCycle = true
+					break
+				}
+			}
+			if !sendsToCycle {
+				delete(remaining, n)
+				removed = true
+			}
+		}
+	}
+	var cycle []Node
+	for _, n := range all {
+		if _, ok := remaining[n]; ok {
+			cycle = append(cycle, n)
+		}
+	}
+	return cycle
+}
+
+// validateSubgraphs validates the subgraphs whose ports are among the graph nodes or the nodes
+// reachable from them, in order of subgraph name
+func validateSubgraphs(nodes []Node, reachable map[graphNode]struct{}) error {
+	subgraphs := map[*Subgraph]struct{}{}
+	for _, n := range nodes {
+		if sg := n.meta().subgraph; sg != nil {
+			subgraphs[sg] = struct{}{}
+		}
+	}
+	for n := range reachable {
+		if n, ok := n.(Node); ok && n.meta().subgraph != nil {
+			subgraphs[n.meta().subgraph] = struct{}{}
+		}
+	}
+	sorted := make([]*Subgraph, 0, len(subgraphs))
+	for sg := range subgraphs {
+		sorted = append(sorted, sg)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	for _, sg := range sorted {
+		if err := sg.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func markReachable(n graphNode, reachable map[graphNode]struct{}) {
+	if _, ok := reachable[n]; ok {
+		return
+	}
+	reachable[n] = struct{}{}
+	for _, out := range n.outputs() {
+		markReachable(out, reachable)
+	}
+}
+
+func describe(index int, n Node) string {
+	return fmt.Sprintf("#%d (%s)", index, n.Name())
+}
+
+func receiversAsNodes[T any](receivers []Receiver[T]) []graphNode {
+	nodes := make([]graphNode, 0, len(receivers))
+	for _, r := range receivers {
+		nodes = append(nodes, r)
+	}
+	return nodes
+}
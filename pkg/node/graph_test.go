@@ -0,0 +1,429 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	odds := AsMiddle(OddFilter)
+	oddsMsg := AsMiddle(Messager("odd"))
+	var collected []string
+	collector := AsTerminal(func(strs <-chan string) {
+		for str := range strs {
+			collected = append(collected, str)
+		}
+	})
+	start.SendsTo(odds)
+	odds.SendsTo(oddsMsg)
+	oddsMsg.SendsTo(collector)
+
+	g := NewGraph(start, odds, oddsMsg, collector)
+	require.NoError(t, g.Validate())
+	require.NoError(t, g.Start())
+
+	waitDone(t, g.Done())
+	assert.Equal(t, []string{"odd: 1", "odd: 3"}, collected)
+}
+
+func TestGraph_UnbufferedEdge(t *testing.T) {
+	sent := make(chan int, 10)
+	start := AsStart(func(out chan<- int) {
+		for n := 1; n <= 3; n++ {
+			out <- n
+			sent <- n
+		}
+	})
+	release := make(chan struct{})
+	var received []int
+	term := AsTerminal(func(in <-chan int) {
+		<-release
+		for n := range in {
+			received = append(received, n)
+		}
+	})
+	start.SendsTo(term)
+	graph := NewGraph(start, term)
+	require.NoError(t, graph.Start())
+
+	// the edge is unbuffered, also under the cancellable context of the graph, so no item is
+	// sent until the receiver reads
+	nodetest.ExpectBlocked(t, sent)
+	close(release)
+	waitDone(t, graph.Done())
+	assert.Equal(t, []int{1, 2, 3}, received)
+}
+
+func TestGraph_Validate_OrphanNodes(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	odds := AsMiddle(OddFilter)
+	evens := AsMiddle(EvenFilter, WithName("evens"))
+	oddsTerm := AsTerminal(func(in <-chan int) {})
+	evensTerm := AsTerminal(func(in <-chan int) {})
+	start.SendsTo(odds)
+	odds.SendsTo(oddsTerm)
+	// evens is never connected from any source, so evens and evensTerm would never start
+	evens.SendsTo(evensTerm)
+
+	g := NewGraph(start, odds, evens, oddsTerm, evensTerm)
+	err := g.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "#2 (evens)")
+	assert.Contains(t, err.Error(), "#4 (Terminal[int])")
+	assert.NotContains(t, err.Error(), "#1")
+	assert.NotContains(t, err.Error(), "#3")
+
+	// the graph is not started if it is invalid
+	assert.Error(t, g.Start())
+}
+
+func TestGraph_Validate_WiringErrors(t *testing.T) {
+	t.Run("no start nodes", func(t *testing.T) {
+		assert.Error(t, NewGraph(AsTerminal(func(in <-chan int) {})).Validate())
+	})
+	t.Run("start without outputs", func(t *testing.T) {
+		assert.Error(t, NewGraph(AsStart(Counter(1, 3))).Validate())
+	})
+	t.Run("middle without outputs", func(t *testing.T) {
+		start := AsStart(Counter(1, 3))
+		odds := AsMiddle(OddFilter)
+		start.SendsTo(odds)
+		assert.Error(t, NewGraph(start, odds).Validate())
+	})
+}
+
+func TestGraph_ReleasesContext(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	ctxs := make(chan context.Context, 1)
+	forward := AsMiddleCtx(func(ctx context.Context, in <-chan int, out chan<- int) {
+		ctxs <- ctx
+		for n := range in {
+			out <- n
+		}
+	})
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(forward)
+	forward.SendsTo(term)
+
+	graph := NewGraph(start, forward, term)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	assert.Equal(t, []int{1, 2, 3}, received)
+	// the graph context is cancelled once all the nodes have finished
+	waitDone(t, (<-ctxs).Done())
+}
+
+func TestGraph_Shutdown(t *testing.T) {
+	sent := 0
+	start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- sent:
+				sent++
+			}
+		}
+	}, WithName("generator"))
+	double := AsMiddle(func(in <-chan int, out chan<- int) {
+		for n := range in {
+			out <- n * 2
+		}
+	}, ChannelBufferLen(10))
+	received := 0
+	finished := map[string]bool{}
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+			received++
+		}
+		// when the terminal finishes, the previous stages have finished
+		finished["start"] = isClosed(start.Done())
+		finished["double"] = isClosed(double.Done())
+	}, ChannelBufferLen(10))
+	start.SendsTo(double)
+	double.SendsTo(term)
+
+	graph := NewGraph(start, double, term)
+	require.NoError(t, graph.Start())
+	assert.Eventually(t, func() bool {
+		return term.Stats().BufferLen > 0
+	}, timeout, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	require.NoError(t, graph.Shutdown(ctx))
+	for _, n := range graph.Nodes() {
+		assert.True(t, isClosed(n.Done()), n.Name())
+	}
+	// all the data that was flowing through the graph has been processed
+	assert.Equal(t, sent, received)
+	assert.Equal(t, map[string]bool{"start": true, "double": true}, finished)
+}
+
+func TestGraph_Shutdown_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	start := AsStart(func(out chan<- int) {
+		// ignores the context cancellation
+		<-release
+	}, WithName("stubborn"))
+	term := AsTerminal(func(in <-chan int) {})
+	start.SendsTo(term)
+
+	graph := NewGraph(start, term)
+	assert.Error(t, graph.Shutdown(context.Background()), "graph is not started")
+	require.NoError(t, graph.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := graph.Shutdown(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stubborn")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestGraph_Shutdown_DrainProgress(t *testing.T) {
+	start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		for n := 0; ; n++ {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- n:
+			}
+		}
+	})
+	slow := AsTerminal(func(in <-chan int) {
+		for range in {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}, ChannelBufferLen(50))
+	start.SendsTo(slow)
+
+	graph := NewGraph(start, slow)
+	require.NoError(t, graph.Start())
+	assert.Eventually(t, func() bool {
+		return slow.Stats().BufferLen == 50
+	}, timeout, time.Millisecond)
+
+	var progress []int
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	require.NoError(t, graph.Shutdown(ctx, WithDrainProgress(func(remaining int) {
+		progress = append(progress, remaining)
+	})))
+	require.Greater(t, len(progress), 1)
+	assert.Greater(t, progress[0], 0)
+	assert.IsNonIncreasing(t, progress)
+	assert.Zero(t, progress[len(progress)-1])
+}
+
+func TestRunForResult(t *testing.T) {
+	start := AsStart(func(out chan<- string) {
+		for _, w := range []string{"a", "b", "a", "c", "a"} {
+			out <- w
+		}
+	})
+	count, counts := CountBy(func(w string) string { return w })
+	start.SendsTo(count)
+
+	result, err := RunForResult(context.Background(), NewGraph(start, count), counts)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 3, "b": 1, "c": 1}, result)
+}
+
+func TestRunForResult_Errors(t *testing.T) {
+	// invalid graph
+	_, err := RunForResult(context.Background(), NewGraph(AsStart(Counter(1, 3))), func() int { return 1 })
+	assert.Error(t, err)
+
+	start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		out <- 1
+		<-ctx.Done()
+	})
+	term, counts := CountBy(func(n int) int { return n })
+	start.SendsTo(term)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = RunForResult(ctx, NewGraph(start, term), counts)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	// the graph has finished before returning the error
+	select {
+	case <-term.Done():
+	default:
+		assert.Fail(t, "the graph should have finished")
+	}
+	assert.Equal(t, map[int]int{1: 1}, counts())
+}
+
+func TestGraph_RunFor(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(term)
+
+	// the graph completes before the deadline
+	require.NoError(t, NewGraph(start, term).RunFor(timeout))
+	assert.Equal(t, []int{1, 2, 3}, received)
+}
+
+func TestGraph_RunFor_Deadline(t *testing.T) {
+	sent := 0
+	start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- sent:
+				sent++
+			}
+		}
+	})
+	received := 0
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+			received++
+			time.Sleep(time.Millisecond)
+		}
+	}, ChannelBufferLen(10))
+	start.SendsTo(term)
+
+	begin := time.Now()
+	err := NewGraph(start, term).RunFor(20 * time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, time.Since(begin), 20*time.Millisecond)
+	// the graph has been drained before returning
+	assert.True(t, isClosed(term.Done()))
+	assert.Equal(t, sent, received)
+
+	// invalid graph
+	assert.Error(t, NewGraph(AsStart(Counter(1, 3))).RunFor(timeout))
+}
+
+func TestGraph_TopoSort(t *testing.T) {
+	start1 := AsStart(Counter(1, 3), WithName("start1"))
+	start2 := AsStart(Counter(1, 3), WithName("start2"))
+	odds := AsMiddle(OddFilter, WithName("odds"))
+	evens := AsMiddle(EvenFilter, WithName("evens"))
+	oddsMsg := AsMiddle(Messager("odd"), WithName("oddsMsg"))
+	evensMsg := AsMiddle(Messager("even"), WithName("evensMsg"))
+	printer := AsTerminal(func(in <-chan string) {}, WithName("printer"))
+	start1.SendsTo(evens, odds)
+	start2.SendsTo(evens, odds)
+	odds.SendsTo(oddsMsg)
+	evens.SendsTo(evensMsg)
+	oddsMsg.SendsTo(printer)
+	evensMsg.SendsTo(printer)
+
+	// unlisted nodes are also returned
+	order, err := NewGraph(printer, evensMsg, start2, start1).TopoSort()
+	require.NoError(t, err)
+	position := map[string]int{}
+	for i, n := range order {
+		position[n.Name()] = i
+	}
+	require.Len(t, position, 7)
+	for _, edge := range [][2]string{
+		{"start1", "evens"}, {"start1", "odds"}, {"start2", "evens"}, {"start2", "odds"},
+		{"odds", "oddsMsg"}, {"evens", "evensMsg"}, {"oddsMsg", "printer"}, {"evensMsg", "printer"},
+	} {
+		assert.Less(t, position[edge[0]], position[edge[1]], "%s -> %s", edge[0], edge[1])
+	}
+}
+
+func TestGraph_TopoSort_Cycle(t *testing.T) {
+	start := AsStart(Counter(1, 3), WithName("start"))
+	m1 := AsMiddle(OddFilter, WithName("m1"))
+	m2 := AsMiddle(OddFilter, WithName("m2"))
+	term := AsTerminal(func(in <-chan int) {}, WithName("term"))
+	start.SendsTo(m1)
+	m1.SendsTo(m2)
+	m2.SendsTo(m1, term)
+
+	graph := NewGraph(start, m1, m2, term)
+	_, err := graph.TopoSort()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "m1, m2")
+	assert.NotContains(t, err.Error(), "start")
+	assert.NotContains(t, err.Error(), "term")
+	assert.Equal(t, err, graph.Validate())
+}
+
+func TestGraph_Edges(t *testing.T) {
+	start := AsStart(func(out chan<- any) {
+		for _, item := range []any{1, "a", 2, 3, "b"} {
+			out <- item
+		}
+	}, WithName("start"), CountEdges())
+	route := TypeSwitch[any](WithName("route"), CountEdges())
+	ints := AsMiddle(func(in <-chan int, out chan<- int) {
+		for n := range in {
+			out <- n
+		}
+	}, WithName("ints"))
+	intsTerm := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	}, WithName("intsTerm"))
+	strs := AsTerminal(func(in <-chan string) {
+		for range in {
+		}
+	}, WithName("strs"))
+	start.SendsTo(route)
+	Case[int](route, ints)
+	Case[string](route, strs)
+	ints.SendsTo(intsTerm)
+
+	graph := NewGraph(start, route, ints, intsTerm, strs)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+
+	type edge struct {
+		from, to string
+		counted  bool
+		count    int64
+	}
+	var edges []edge
+	for _, e := range graph.Edges() {
+		edges = append(edges, edge{from: e.From.Name, to: e.To.Name, counted: e.Counted, count: e.Count})
+	}
+	assert.Equal(t, []edge{
+		{from: "start", to: "route", counted: true, count: 5},
+		{from: "route", to: "ints", counted: true, count: 3},
+		{from: "route", to: "strs", counted: true, count: 2},
+		{from: "ints", to: "intsTerm"},
+	}, edges)
+}
+
+func TestGraph_StartWithSourceTimeout(t *testing.T) {
+	fast := AsStart(Counter(1, 3), WithName("fast"))
+	empty := AsStart(func(out chan<- int) {}, WithName("empty"))
+	hanging := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		// e.g. a source that never connects
+		<-ctx.Done()
+	}, WithName("hanging"))
+	var received []int
+	term := collectInts(&received)
+	fast.SendsTo(term)
+	empty.SendsTo(term)
+	hanging.SendsTo(term)
+
+	graph := NewGraph(fast, empty, hanging, term)
+	statuses, err := graph.StartWithSourceTimeout(context.Background(), 20*time.Millisecond)
+	require.NoError(t, err)
+	ready := map[string]bool{}
+	for _, s := range statuses {
+		ready[s.Node.Name] = s.Ready
+	}
+	assert.Equal(t, map[string]bool{"fast": true, "empty": true, "hanging": false}, ready)
+
+	// the graph finishes with the sources that came up
+	waitDone(t, graph.Done())
+	assert.Equal(t, []int{1, 2, 3}, received)
+}
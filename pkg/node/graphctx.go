@@ -0,0 +1,50 @@
+package node
+
+import (
+	"context"
+	"sync"
+)
+
+// graphContextKey is the key of the GraphContext in a context.Context
+type graphContextKey struct{}
+
+// GraphContext is a concurrency-safe storage of values that is shared by all the nodes of a
+// graph run, allowing them to coordinate without global variables (e.g. sharing a rate limiter
+// or a cache). As with context.Context values, the keys should be of unexported types defined
+// by the package that stores the values, to avoid collisions.
+// A new GraphContext is created each time a Graph is started, and is accessible from the
+// context passed to the nodes through the GraphContextFrom function.
+type GraphContext struct {
+	values sync.Map
+}
+
+// WithGraphContext returns a copy of the parent context that carries a new, empty GraphContext.
+// Graph.StartCtx invokes it automatically, so it only needs to be invoked explicitly when
+// starting the Start nodes individually.
+func WithGraphContext(parent context.Context) context.Context {
+	return context.WithValue(parent, graphContextKey{}, &GraphContext{})
+}
+
+// GraphContextFrom returns the GraphContext that is carried by the provided context, or nil if
+// the context does not carry any.
+func GraphContextFrom(ctx context.Context) *GraphContext {
+	gc, _ := ctx.Value(graphContextKey{}).(*GraphContext)
+	return gc
+}
+
+// Set stores the value for the provided key, replacing any previous value.
+func (g *GraphContext) Set(key, value any) {
+	g.values.Store(key, value)
+}
+
+// Get returns the value that is stored for the provided key, and whether it was found.
+func (g *GraphContext) Get(key any) (any, bool) {
+	return g.values.Load(key)
+}
+
+// GetOrSet returns the value that is stored for the provided key. If there is no value, it
+// stores and returns the provided value. The loaded result is true if the value was already
+// stored. It allows multiple nodes to lazily create a shared value.
+func (g *GraphContext) GetOrSet(key, value any) (actual any, loaded bool) {
+	return g.values.LoadOrStore(key, value)
+}
@@ -0,0 +1,56 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type offsetKey struct{}
+
+func TestGraphContext(t *testing.T) {
+	start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		GraphContextFrom(ctx).Set(offsetKey{}, 100)
+		for i := 1; i <= 3; i++ {
+			out <- i
+		}
+	})
+	add := AsMiddleCtx(func(ctx context.Context, in <-chan int, out chan<- int) {
+		gc := GraphContextFrom(ctx)
+		for n := range in {
+			// the start node has set the value before sending the first item
+			offset, _ := gc.Get(offsetKey{})
+			out <- n + offset.(int)
+		}
+	})
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(add)
+	add.SendsTo(term)
+
+	graph := NewGraph(start, add, term)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	assert.Equal(t, []int{101, 102, 103}, received)
+}
+
+func TestGraphContext_PerRun(t *testing.T) {
+	ctx := WithGraphContext(context.Background())
+	gc := GraphContextFrom(ctx)
+	require.NotNil(t, gc)
+	_, ok := gc.Get(offsetKey{})
+	assert.False(t, ok)
+	actual, loaded := gc.GetOrSet(offsetKey{}, 1)
+	assert.False(t, loaded)
+	assert.Equal(t, 1, actual)
+	actual, loaded = gc.GetOrSet(offsetKey{}, 2)
+	assert.True(t, loaded)
+	assert.Equal(t, 1, actual)
+
+	// each new graph context is empty
+	_, ok = GraphContextFrom(WithGraphContext(ctx)).Get(offsetKey{})
+	assert.False(t, ok)
+	assert.Nil(t, GraphContextFrom(context.Background()))
+}
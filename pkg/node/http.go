@@ -0,0 +1,51 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// HTTPHandler returns an http.Handler that runs a new graph for each request, to expose a
+// pipeline as an HTTP endpoint. For each request, the build function receives the request context
+// and body, and returns a graph whose Start nodes read the body, and the node whose output is
+// streamed as the response. Each item sent by that node is written as a line of JSON
+// (Content-Type: application/x-ndjson) and flushed immediately.
+// The graph is started with the request context, so it is cancelled when the client disconnects.
+// The handler returns when all the Terminal nodes of the graph have finished, so the Start nodes
+// must stop when the context is cancelled (e.g. by using AsStartCtx).
+// If the graph is not valid, the handler responds with a 500 status code. If an item can't be
+// encoded as JSON or the client disconnects, the rest of items are discarded.
+func HTTPHandler[OUT any](build func(ctx context.Context, body io.Reader) (*Graph, Sender[OUT])) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		graph, results := build(r.Context(), r.Body)
+		flusher, _ := w.(http.Flusher)
+		encoder := json.NewEncoder(w)
+		failed := false
+		respond := ForEach(func(item OUT) {
+			if failed {
+				return
+			}
+			if err := encoder.Encode(item); err != nil {
+				failed = true
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}, WithName("HTTPResponse"))
+		results.SendsTo(respond)
+		graph.Add(respond)
+		if err := graph.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		if err := graph.StartCtx(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		<-graph.Done()
+	})
+}
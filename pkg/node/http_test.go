@@ -0,0 +1,89 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeInts(body io.Reader) *Start[int] {
+	return AsStartCtx(func(ctx context.Context, out chan<- int) {
+		dec := json.NewDecoder(body)
+		for {
+			var n int
+			if err := dec.Decode(&n); err != nil {
+				return
+			}
+			select {
+			case out <- n:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+func TestHTTPHandler(t *testing.T) {
+	handler := HTTPHandler(func(_ context.Context, body io.Reader) (*Graph, Sender[string]) {
+		start := decodeInts(body)
+		odds := AsMiddle(OddFilter)
+		msg := AsMiddle(Messager("odd"))
+		start.SendsTo(odds)
+		odds.SendsTo(msg)
+		return NewGraph(start, odds, msg), msg
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("1\n2\n3\n")))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "\"odd: 1\"\n\"odd: 3\"\n", rec.Body.String())
+	assert.True(t, rec.Flushed)
+}
+
+func TestHTTPHandler_InvalidGraph(t *testing.T) {
+	handler := HTTPHandler(func(_ context.Context, body io.Reader) (*Graph, Sender[int]) {
+		start := decodeInts(body)
+		odds := AsMiddle(OddFilter)
+		start.SendsTo(odds)
+		// the Start node is not part of the graph
+		return NewGraph(odds), odds
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("1\n")))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestHTTPHandler_ClientDisconnect(t *testing.T) {
+	handler := HTTPHandler(func(_ context.Context, _ io.Reader) (*Graph, Sender[int]) {
+		start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+			for n := 0; ; n++ {
+				select {
+				case out <- n:
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+		forward := Map(func(n int) int { return n })
+		start.SendsTo(forward)
+		return NewGraph(start, forward), forward
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	served := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		close(served)
+	}()
+	cancel()
+	waitDone(t, served)
+}
@@ -0,0 +1,116 @@
+package connect
+
+import (
+	"sync"
+)
+
+// Budget bounds the accumulated size of the items that are queued in one or more Channels, so
+// the memory of the buffers can be limited globally, regardless of which buffer is backed up.
+// It is safe for concurrent use.
+type Budget struct {
+	mt   sync.Mutex
+	max  int
+	used int
+	// closed and replaced when some bytes are released, if any queue is waiting for it
+	freed   chan struct{}
+	waiting bool
+}
+
+// NewBudget creates a Budget of maxBytes
+func NewBudget(maxBytes int) *Budget {
+	return &Budget{max: maxBytes, freed: make(chan struct{})}
+}
+
+// Used returns the accumulated size of the items that are currently queued
+func (b *Budget) Used() int {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	return b.used
+}
+
+// available returns whether the budget is not exhausted. If it is exhausted, it also returns a
+// channel that is closed when some bytes are released.
+func (b *Budget) available() (bool, <-chan struct{}) {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	if b.used < b.max {
+		return true, nil
+	}
+	b.waiting = true
+	return false, b.freed
+}
+
+func (b *Budget) acquire(bytes int) {
+	b.mt.Lock()
+	b.used += bytes
+	b.mt.Unlock()
+}
+
+func (b *Budget) release(bytes int) {
+	b.mt.Lock()
+	b.used -= bytes
+	if b.waiting {
+		close(b.freed)
+		b.freed = make(chan struct{})
+		b.waiting = false
+	}
+	b.mt.Unlock()
+}
+
+// byteQueue is a Channel whose buffer is bounded by the accumulated size of the queued items,
+// which is accounted in a Budget that can be shared with other queues.
+// At least one item is always accepted when the queue is empty, so the queues sharing a budget
+// can't block each other, and the accumulated size can exceed the maximum by at most one item
+// per queue. If maxItems > 0, the number of queued items is also bounded by it.
+type byteQueue[T any] struct {
+	*queue[T, sizedItem[T]]
+	budget   *Budget
+	sizeOf   func(T) int
+	maxItems int
+}
+
+type sizedItem[T any] struct {
+	item T
+	size int
+}
+
+func newByteQueue[T any](budget *Budget, sizeOf func(T) int) *byteQueue[T] {
+	q := &byteQueue[T]{
+		budget: budget,
+		sizeOf: sizeOf,
+	}
+	q.queue = newQueue[T, sizedItem[T]](q)
+	return q
+}
+
+// Cap returns the maximum number of items, or 0 if the capacity is not bounded by a number of
+// items
+func (q *byteQueue[T]) Cap() int {
+	return q.maxItems
+}
+
+// accepts items while the accumulated size of the items in the budget is below the maximum, or
+// waits until some bytes are released. If the queue is full of items, it waits until some of
+// them are dequeued.
+func (q *byteQueue[T]) accepts(queued int) (bool, <-chan struct{}) {
+	if q.maxItems > 0 && queued >= q.maxItems {
+		return false, nil
+	}
+	return q.budget.available()
+}
+
+// enqueue accounts the size of the item in the budget
+func (q *byteQueue[T]) enqueue(item T) sizedItem[T] {
+	size := q.sizeOf(item)
+	q.budget.acquire(size)
+	return sizedItem[T]{item: item, size: size}
+}
+
+func (q *byteQueue[T]) item(entry sizedItem[T]) T {
+	return entry.item
+}
+
+// dequeued releases the size of the item from the budget
+func (q *byteQueue[T]) dequeued(entry sizedItem[T]) {
+	q.budget.release(entry.size)
+}
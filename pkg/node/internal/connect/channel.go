@@ -0,0 +1,39 @@
+package connect
+
+// Channel is the buffer between the senders and the receiver of a Joiner.
+type Channel[T any] interface {
+	// Send returns the channel where the senders write the items
+	Send() chan<- T
+	// Receive returns the channel where the receiver reads the items
+	Receive() <-chan T
+	// Close is invoked when all the senders have finished. The Receive channel must be closed
+	// after all the pending items have been received.
+	Close()
+	// Len returns the number of items that are queued in the channel
+	Len() int
+	// Cap returns the capacity of the channel
+	Cap() int
+}
+
+// goChannel is the default Channel, which is directly a Go channel
+type goChannel[T any] chan T
+
+func (c goChannel[T]) Send() chan<- T {
+	return c
+}
+
+func (c goChannel[T]) Receive() <-chan T {
+	return c
+}
+
+func (c goChannel[T]) Close() {
+	close(c)
+}
+
+func (c goChannel[T]) Len() int {
+	return len(c)
+}
+
+func (c goChannel[T]) Cap() int {
+	return cap(c)
+}
@@ -1,45 +1,143 @@
 package connect
 
 import (
+	"context"
 	"sync/atomic"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/internal/timing"
 )
 
 // Joiner provides shared access to the input channel of a node of the type IN
 type Joiner[IN any] struct {
 	totalSenders int32
-	bufLen       int
-	channel      chan IN
+	// 1 once the channel has been closed
+	closed int32
+	// 1 once any sender has been registered, even if it has released the channel afterwards
+	wired   int32
+	channel Channel[IN]
 }
 
 // NewJoiner creates a joiner for a given channel type and buffer length
 func NewJoiner[IN any](bufferLength int) Joiner[IN] {
-	return Joiner[IN]{
-		bufLen:  bufferLength,
-		channel: make(chan IN, bufferLength),
+	return NewChannelJoiner[IN](make(goChannel[IN], bufferLength))
+}
+
+// NewByteJoiner creates a joiner whose buffer is bounded by the accumulated size of the queued
+// items, as estimated by the sizeOf function, instead of by their number.
+func NewByteJoiner[IN any](maxBytes int, sizeOf func(IN) int) Joiner[IN] {
+	return NewChannelJoiner[IN](newByteQueue(NewBudget(maxBytes), sizeOf))
+}
+
+// NewBudgetJoiner creates a joiner whose buffer is bounded by a Budget, which can be shared with
+// the joiners of other nodes, so their buffers are limited together.
+func NewBudgetJoiner[IN any](budget *Budget, sizeOf func(IN) int) Joiner[IN] {
+	return NewChannelJoiner[IN](newByteQueue(budget, sizeOf))
+}
+
+// NewTimedJoiner creates a joiner whose buffer measures the time that each item waits in it,
+// from it is sent until the receiver picks it up, and reports it to the observe function.
+// The time is provided by the now function.
+func NewTimedJoiner[IN any](bufferLength int, now func() time.Time, observe func(time.Duration)) Joiner[IN] {
+	return NewChannelJoiner[IN](newTimedQueue[IN](bufferLength, now, observe))
+}
+
+// NewResizableJoiner creates a joiner whose buffer length can be changed with SetCap while the
+// items are flowing through it.
+func NewResizableJoiner[IN any](bufferLength int) Joiner[IN] {
+	return NewChannelJoiner[IN](newResizableQueue[IN](bufferLength))
+}
+
+// NewChannelJoiner creates a joiner whose items are passed through the provided Channel
+// implementation.
+func NewChannelJoiner[IN any](channel Channel[IN]) Joiner[IN] {
+	return Joiner[IN]{channel: channel}
+}
+
+// UseBudget replaces the buffer of the joiner, if it is a buffered Go channel, by a queue of the
+// same capacity whose items are also bounded by their accumulated size in the budget, as
+// estimated by the sizeOf function. It returns false if the buffer has not been replaced.
+// It must be invoked before any sender or receiver accesses the channel.
+func (j *Joiner[IN]) UseBudget(budget *Budget, sizeOf func(IN) int) bool {
+	ch, ok := j.channel.(goChannel[IN])
+	if !ok || cap(ch) == 0 {
+		return false
 	}
+	q := newByteQueue(budget, sizeOf)
+	q.maxItems = cap(ch)
+	j.channel = q
+	return true
 }
 
 // Receiver gets access to the channel as a receiver
-func (j *Joiner[IN]) Receiver() chan IN {
-	return j.channel
+func (j *Joiner[IN]) Receiver() <-chan IN {
+	return j.channel.Receive()
+}
+
+// Len returns the number of items that are queued in the channel
+func (j *Joiner[IN]) Len() int {
+	return j.channel.Len()
 }
 
-// AcquireSender gets acces to the channel as a sender. The acquirer must finally invoke
-// ReleaseSender to make sure that the channel is closed when all the senders released it.
-func (j *Joiner[IN]) AcquireSender() chan IN {
+// Cap returns the capacity of the channel buffer. It is 0 for the joiners whose buffer is bounded
+// by size instead of by number of items.
+func (j *Joiner[IN]) Cap() int {
+	return j.channel.Cap()
+}
+
+// SetCap changes the capacity of the channel buffer. It returns false if the joiner has not been
+// created with NewResizableJoiner.
+func (j *Joiner[IN]) SetCap(capacity int) bool {
+	q, ok := j.channel.(interface{ setCap(int) })
+	if ok {
+		q.setCap(capacity)
+	}
+	return ok
+}
+
+// AddSender registers a sender of the channel. It must be invoked when the sender is connected
+// to the joiner, before any sender starts, so the channel is not closed until all the registered
+// senders have invoked ReleaseSender, even if some senders finish before others start.
+func (j *Joiner[IN]) AddSender() {
+	atomic.StoreInt32(&j.wired, 1)
 	atomic.AddInt32(&j.totalSenders, 1)
-	return j.channel
 }
 
-// ReleaseSender will close the channel when all the invokers of the AcquireSender have invoked
+// Wired returns whether any sender has been registered with AddSender, even if all the senders
+// have already released the channel
+func (j *Joiner[IN]) Wired() bool {
+	return atomic.LoadInt32(&j.wired) == 1
+}
+
+// Senders returns the number of registered senders that have not released the channel yet
+func (j *Joiner[IN]) Senders() int {
+	return int(atomic.LoadInt32(&j.totalSenders))
+}
+
+// AcquireSender gets acces to the channel as a sender. The acquirer must have been registered with
+// AddSender, and must finally invoke ReleaseSender to make sure that the channel is closed when
+// all the senders released it.
+func (j *Joiner[IN]) AcquireSender() chan<- IN {
+	return j.channel.Send()
+}
+
+// ReleaseSender will close the channel when all the senders registered with AddSender have invoked
 // this function
 func (j *Joiner[IN]) ReleaseSender() {
 	// if no senders, we close the main channel
 	if atomic.AddInt32(&j.totalSenders, -1) == 0 {
-		close(j.channel)
+		atomic.StoreInt32(&j.closed, 1)
+		j.channel.Close()
 	}
 }
 
+// Closed returns whether all the registered senders have released the channel, so it has been
+// closed. Unlike checking that there are no senders, it is false for a joiner that has never
+// been connected to any sender.
+func (j *Joiner[IN]) Closed() bool {
+	return atomic.LoadInt32(&j.closed) == 1
+}
+
 // Releaser is a function that will allow releasing a forked channel.
 type Releaser func()
 
@@ -47,49 +145,162 @@ type Releaser func()
 // one node, this will work as a single channel. When a node sends to N nodes,
 // it will spawn N channels that are cloned from the original channel in a goroutine.
 type Forker[OUT any] struct {
-	sendCh         chan OUT
+	sendCh         chan<- OUT
 	releaseChannel Releaser
 }
 
+// ForkOptions customizes the behavior of a Forker
+type ForkOptions struct {
+	// if not nil, Counters[i] is atomically incremented after each item is forwarded to the
+	// joiner i. Counting the items requires forwarding them from an intermediate goroutine, even
+	// if there is only one joiner.
+	Counters []*int64
+	// if not nil and Filters[i] is not nil, the items for which Filters[i] returns false are not
+	// forwarded to the joiner i. As the Counters, it requires forwarding the items from an
+	// intermediate goroutine.
+	Filters []func(item any) bool
+	// if > 0, maximum time that the forker keeps forwarding the pending items after it has been
+	// closed. After then, the joiners are released and the pending items are discarded, so a
+	// blocked joiner does not prevent the rest of joiners from being closed.
+	CloseTimeout time.Duration
+	// creates the timer of the CloseTimeout. It is required if CloseTimeout > 0
+	NewTimer func(d time.Duration) timing.Timer
+	// if not nil, it is atomically incremented for each item that has not been forwarded to all
+	// the joiners, because the context was cancelled or the close timeout expired
+	Abandoned *int64
+	// if not nil, it is atomically incremented for each item that is sent to the forker. As the
+	// Counters, it requires forwarding the items from an intermediate goroutine.
+	Sent *int64
+	// if not nil, it is invoked for each item that is forwarded to multiple joiners, before
+	// forwarding it, with the number of joiners (e.g. to count the references to the item)
+	OnFork func(item any, joiners int)
+}
+
 // Fork provides connection to a group of output Nodes, accessible through their respective
-// Joiner instances.
-func Fork[T any](joiners ...*Joiner[T]) Forker[T] {
+// Joiner instances. The forker must have been registered as a sender of each joiner with
+// AddSender.
+// When the items are forwarded through an intermediate goroutine (e.g. there are multiple
+// joiners), they are forwarded until the provided context is cancelled. After then, the joiners
+// are released and the items sent to the forker are discarded, so a blocked receiver does not
+// prevent the sender from finishing. A single joiner without options receives the items
+// directly from the sender, which must watch the context by itself.
+func Fork[T any](ctx context.Context, joiners ...*Joiner[T]) Forker[T] {
+	return ForkWith(ctx, ForkOptions{}, joiners...)
+}
+
+// ForkWith works as Fork, with the behavior customized by the provided options.
+func ForkWith[T any](ctx context.Context, opts ForkOptions, joiners ...*Joiner[T]) Forker[T] {
 	if len(joiners) == 0 {
 		panic("can't fork 0 joiners")
 	}
-	// if there is only one joiner, we directly send the data to the channel, without intermediation
-	if len(joiners) == 1 {
+	counters := opts.Counters
+	if counters != nil && len(counters) != len(joiners) {
+		panic("the number of counters must match the number of joiners")
+	}
+	filters := opts.Filters
+	if filters != nil && len(filters) != len(joiners) {
+		panic("the number of filters must match the number of joiners")
+	}
+	// if there is only one joiner and there is no close timeout, we directly send the data to the
+	// channel, without intermediation. The sender must watch the context by itself when it is
+	// blocked sending
+	if len(joiners) == 1 && counters == nil && filters == nil && opts.Sent == nil &&
+		opts.CloseTimeout <= 0 {
 		return Forker[T]{
 			sendCh:         joiners[0].AcquireSender(),
 			releaseChannel: joiners[0].ReleaseSender,
 		}
 	}
 	// channel used as input from the source Node
-	sendCh := make(chan T, joiners[0].bufLen)
+	sendCh := make(chan T, joiners[0].Cap())
+	// closed when the forker is closed. It is nil (blocks forever) if there is no timeout
+	var closing chan struct{}
+	if opts.CloseTimeout > 0 {
+		closing = make(chan struct{})
+	}
+	abandon := func() {
+		if opts.Abandoned != nil {
+			atomic.AddInt64(opts.Abandoned, 1)
+		}
+	}
 
 	// channels that clone the contents of the sendCh
-	forwarders := make([]chan T, len(joiners))
+	forwarders := make([]chan<- T, len(joiners))
 	for i := 0; i < len(joiners); i++ {
 		forwarders[i] = joiners[i].AcquireSender()
 	}
 	go func() {
+		release := func() {
+			for i := 0; i < len(joiners); i++ {
+				joiners[i].ReleaseSender()
+			}
+		}
+		// the close timeout starts when the forker observes that it has been closed
+		var timer timing.Timer
+		var expired <-chan time.Time
+		interrupted := false
+	forward:
 		for in := range sendCh {
+			if opts.Sent != nil {
+				atomic.AddInt64(opts.Sent, 1)
+			}
+			if opts.OnFork != nil && len(joiners) > 1 {
+				opts.OnFork(in, len(joiners))
+			}
 			for i := 0; i < len(joiners); i++ {
-				forwarders[i] <- in
+				if filters != nil && filters[i] != nil && !filters[i](in) {
+					continue
+				}
+			send:
+				for {
+					select {
+					case forwarders[i] <- in:
+						if counters != nil {
+							atomic.AddInt64(counters[i], 1)
+						}
+						break send
+					case <-closing:
+						closing = nil
+						timer = opts.NewTimer(opts.CloseTimeout)
+						expired = timer.C()
+					case <-ctx.Done():
+						interrupted = true
+					case <-expired:
+						interrupted = true
+					}
+					if interrupted {
+						abandon()
+						break forward
+					}
+				}
 			}
 		}
-		for i := 0; i < len(joiners); i++ {
-			joiners[i].ReleaseSender()
+		if timer != nil {
+			timer.Stop()
+		}
+		release()
+		if interrupted {
+			// drain the items that are still sent, until the sender closes the forker
+			for range sendCh {
+				abandon()
+			}
 		}
 	}()
+	closeCh := func() { close(sendCh) }
+	if closing != nil {
+		closeCh = func() {
+			close(sendCh)
+			close(closing)
+		}
+	}
 	return Forker[T]{
 		sendCh:         sendCh,
-		releaseChannel: func() { close(sendCh) },
+		releaseChannel: closeCh,
 	}
 }
 
 // Sender acquires the channel that will receive the data from the source node
-func (f *Forker[OUT]) Sender() chan OUT {
+func (f *Forker[OUT]) Sender() chan<- OUT {
 	return f.sendCh
 }
 
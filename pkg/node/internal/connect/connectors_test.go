@@ -1,9 +1,13 @@
 package connect
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/netobserv/gopipes/pkg/node/internal/timing"
 	helpers "github.com/netobserv/gopipes/pkg/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -12,6 +16,11 @@ const timeout = 2 * time.Second
 
 func TestJoiner(t *testing.T) {
 	j := NewJoiner[int](20)
+	// a joiner without senders is not closed
+	assert.False(t, j.Closed())
+	j.AddSender()
+	j.AddSender()
+	j.AddSender()
 	finished := helpers.AsyncWait(1)
 
 	go func() {
@@ -34,6 +43,7 @@ func TestJoiner(t *testing.T) {
 	finished.Wait(t, timeout)
 
 	assert.Equal(t, map[int]struct{}{1: {}, 2: {}, 3: {}}, set)
+	assert.True(t, j.Closed())
 
 	// check that all the channels have been closed
 	assert.Panics(t, func() {
@@ -47,7 +57,10 @@ func TestForker(t *testing.T) {
 	joiner2 := NewJoiner[int](20)
 	joiner3 := NewJoiner[int](20)
 
-	f := Fork(&joiner1, &joiner2, &joiner3)
+	joiner1.AddSender()
+	joiner2.AddSender()
+	joiner3.AddSender()
+	f := Fork(context.Background(), &joiner1, &joiner2, &joiner3)
 	sender := f.Sender()
 	sender <- 1
 	sender <- 2
@@ -85,16 +98,299 @@ func TestForker(t *testing.T) {
 	assert.Panics(t, func() {
 		f.Sender() <- 1
 	})
-	assert.Panics(t, func() {
-		r := joiner1.Receiver()
-		close(r)
-	})
-	assert.Panics(t, func() {
-		r := joiner2.Receiver()
-		close(r)
-	})
-	assert.Panics(t, func() {
-		r := joiner3.Receiver()
-		close(r)
-	})
+	for _, j := range []*Joiner[int]{&joiner1, &joiner2, &joiner3} {
+		_, ok := <-j.Receiver()
+		assert.False(t, ok)
+	}
+}
+
+func TestForker_Cancel(t *testing.T) {
+	// nobody reads from the unbuffered joiner, so the forker gets blocked
+	blocked := NewJoiner[int](0)
+	buffered := NewJoiner[int](20)
+	blocked.AddSender()
+	buffered.AddSender()
+	ctx, cancel := context.WithCancel(context.Background())
+	f := Fork(ctx, &blocked, &buffered)
+
+	sent := helpers.AsyncWait(1)
+	go func() {
+		for i := 0; i < 10; i++ {
+			f.Sender() <- i
+		}
+		f.Close()
+		sent.Done()
+	}()
+	cancel()
+	// after the cancellation, the sender is not blocked and the joiners are released
+	sent.Wait(t, timeout)
+	finished := helpers.AsyncWait(2)
+	go func() {
+		for range blocked.Receiver() {
+		}
+		finished.Done()
+	}()
+	go func() {
+		for range buffered.Receiver() {
+		}
+		finished.Done()
+	}()
+	finished.Wait(t, timeout)
+}
+
+func TestForker_SingleJoinerDirect(t *testing.T) {
+	// nobody reads from the unbuffered joiner
+	blocked := NewJoiner[int](0)
+	blocked.AddSender()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := Fork(ctx, &blocked)
+
+	// the sender writes directly to the joiner channel, even if the context can be cancelled, so
+	// the unbuffered joiner does not accept any item until it is read
+	select {
+	case f.Sender() <- 1:
+		assert.Fail(t, "the unbuffered joiner should not accept items")
+	default:
+	}
+	received := helpers.AsyncWait(1)
+	go func() {
+		assert.Equal(t, 2, <-blocked.Receiver())
+		received.Done()
+	}()
+	f.Sender() <- 2
+	received.Wait(t, timeout)
+	f.Close()
+	_, ok := <-blocked.Receiver()
+	assert.False(t, ok)
+}
+
+func TestForkWith_Counters(t *testing.T) {
+	joiner1 := NewJoiner[int](20)
+	joiner2 := NewJoiner[int](20)
+	joiner1.AddSender()
+	joiner2.AddSender()
+	var count1, count2 int64
+	f := ForkWith(context.Background(), ForkOptions{Counters: []*int64{&count1, &count2}}, &joiner1, &joiner2)
+	for i := 0; i < 3; i++ {
+		f.Sender() <- i
+	}
+	f.Close()
+	for _, j := range []*Joiner[int]{&joiner1, &joiner2} {
+		for range j.Receiver() {
+		}
+	}
+	assert.EqualValues(t, 3, count1)
+	assert.EqualValues(t, 3, count2)
+
+	// a single joiner is also counted
+	single := NewJoiner[int](20)
+	single.AddSender()
+	var count int64
+	f = ForkWith(context.Background(), ForkOptions{Counters: []*int64{&count}}, &single)
+	f.Sender() <- 1
+	f.Close()
+	for range single.Receiver() {
+	}
+	assert.EqualValues(t, 1, count)
+}
+
+func TestForkWith_Filters(t *testing.T) {
+	joiner1 := NewJoiner[int](20)
+	joiner2 := NewJoiner[int](20)
+	joiner1.AddSender()
+	joiner2.AddSender()
+	odd := func(item any) bool { return item.(int)%2 == 1 }
+	f := ForkWith(context.Background(), ForkOptions{Filters: []func(any) bool{odd, nil}},
+		&joiner1, &joiner2)
+	for i := 0; i < 4; i++ {
+		f.Sender() <- i
+	}
+	f.Close()
+	var received1, received2 []int
+	for i := range joiner1.Receiver() {
+		received1 = append(received1, i)
+	}
+	for i := range joiner2.Receiver() {
+		received2 = append(received2, i)
+	}
+	assert.Equal(t, []int{1, 3}, received1)
+	assert.Equal(t, []int{0, 1, 2, 3}, received2)
+}
+
+func TestForkWith_CloseTimeout(t *testing.T) {
+	// nobody reads from the unbuffered joiner, so the forker gets blocked
+	buffered := NewJoiner[int](20)
+	blocked := NewJoiner[int](0)
+	buffered.AddSender()
+	blocked.AddSender()
+	var abandoned int64
+	f := ForkWith(context.Background(), ForkOptions{
+		CloseTimeout: 10 * time.Millisecond,
+		NewTimer:     newSystemTimer,
+		Abandoned:    &abandoned,
+	}, &buffered, &blocked)
+	for i := 0; i < 3; i++ {
+		f.Sender() <- i
+	}
+	f.Close()
+
+	// after the timeout, the joiners are released even if one of them is blocked
+	finished := helpers.AsyncWait(1)
+	go func() {
+		for range buffered.Receiver() {
+		}
+		finished.Done()
+	}()
+	finished.Wait(t, timeout)
+	assert.EqualValues(t, 3, atomic.LoadInt64(&abandoned))
+	_, ok := <-blocked.Receiver()
+	assert.False(t, ok)
+}
+
+func TestForkWith_CloseTimeoutSingleJoiner(t *testing.T) {
+	// nobody reads from the joiner, so the forker gets blocked
+	blocked := NewJoiner[int](0)
+	blocked.AddSender()
+	var abandoned int64
+	f := ForkWith(context.Background(), ForkOptions{
+		CloseTimeout: 10 * time.Millisecond,
+		NewTimer:     newSystemTimer,
+		Abandoned:    &abandoned,
+	}, &blocked)
+	f.Sender() <- 1
+	f.Close()
+
+	// after the timeout, the item is abandoned and the joiner is released
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt64(&abandoned) == 1
+	}, timeout, time.Millisecond)
+	_, ok := <-blocked.Receiver()
+	assert.False(t, ok)
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func newSystemTimer(d time.Duration) timing.Timer {
+	return systemTimer{Timer: time.NewTimer(d)}
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+func TestByteJoiner(t *testing.T) {
+	j := NewByteJoiner(10, func(s string) int { return len(s) })
+	sent := make(chan string, 10)
+	j.AddSender()
+	go func() {
+		sender := j.AcquireSender()
+		for _, s := range []string{"aaaa", "bbbb", "cccc", "dddd"} {
+			sender <- s
+			sent <- s
+		}
+		j.ReleaseSender()
+		close(sent)
+	}()
+	recv := j.Receiver()
+
+	// the fourth item is not accepted until the accumulated size gets below the maximum
+	for _, expected := range []string{"aaaa", "bbbb", "cccc"} {
+		select {
+		case s := <-sent:
+			assert.Equal(t, expected, s)
+		case <-time.After(timeout):
+			assert.Fail(t, "timeout while waiting for the item to be sent")
+		}
+	}
+	select {
+	case s := <-sent:
+		assert.Fail(t, "expected that sender is blocked", "sent: %s", s)
+	case <-time.After(20 * time.Millisecond):
+		// ok!
+	}
+	assert.Equal(t, 3, j.Len())
+
+	var received []string
+	finished := helpers.AsyncWait(1)
+	go func() {
+		for s := range recv {
+			received = append(received, s)
+		}
+		finished.Done()
+	}()
+	finished.Wait(t, timeout)
+	assert.Equal(t, []string{"aaaa", "bbbb", "cccc", "dddd"}, received)
+	assert.Zero(t, j.Len())
+}
+
+func TestTimedJoiner(t *testing.T) {
+	var mt sync.Mutex
+	now := time.Unix(0, 0)
+	clock := func() time.Time {
+		mt.Lock()
+		defer mt.Unlock()
+		return now
+	}
+	var waits []time.Duration
+	j := NewTimedJoiner[int](2, clock, func(d time.Duration) { waits = append(waits, d) })
+	j.AddSender()
+	recv := j.Receiver()
+	sender := j.AcquireSender()
+	sender <- 1
+	sender <- 2
+	assert.Eventually(t, func() bool { return j.Len() == 2 }, timeout, time.Millisecond)
+	assert.Equal(t, 2, j.Cap())
+
+	mt.Lock()
+	now = now.Add(time.Second)
+	mt.Unlock()
+	assert.Equal(t, 1, <-recv)
+	sender <- 3
+	mt.Lock()
+	now = now.Add(time.Second)
+	mt.Unlock()
+	j.ReleaseSender()
+	var received []int
+	for n := range recv {
+		received = append(received, n)
+	}
+	assert.Equal(t, []int{2, 3}, received)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, time.Second}, waits)
+}
+
+func TestResizableJoiner(t *testing.T) {
+	j := NewResizableJoiner[int](1)
+	j.AddSender()
+	recv := j.Receiver()
+	sender := j.AcquireSender()
+	go func() {
+		for i := 1; i <= 5; i++ {
+			sender <- i
+		}
+		j.ReleaseSender()
+	}()
+	assert.Eventually(t, func() bool { return j.Len() == 1 }, timeout, time.Millisecond)
+	assert.Never(t, func() bool { return j.Len() > 1 }, 20*time.Millisecond, time.Millisecond)
+
+	// growing the buffer unblocks the sender
+	assert.True(t, j.SetCap(3))
+	assert.Equal(t, 3, j.Cap())
+	assert.Eventually(t, func() bool { return j.Len() == 3 }, timeout, time.Millisecond)
+
+	// shrinking the buffer keeps the queued items
+	assert.True(t, j.SetCap(0))
+	assert.Equal(t, 1, <-recv)
+	assert.Eventually(t, func() bool { return j.Len() == 2 }, timeout, time.Millisecond)
+	assert.Never(t, func() bool { return j.Len() > 2 }, 20*time.Millisecond, time.Millisecond)
+	var received []int
+	for n := range recv {
+		received = append(received, n)
+	}
+	assert.Equal(t, []int{2, 3, 4, 5}, received)
+
+	fixed := NewJoiner[int](1)
+	assert.False(t, fixed.SetCap(3))
 }
@@ -0,0 +1,106 @@
+package connect
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// queue is the base of the Channels whose buffering policy can't be implemented with a Go
+// channel. The senders and the receiver use fixed unbuffered channels, and a pump goroutine
+// queues the items in between, as entries of type E, following the policy.
+// The Channel implementations embed the queue and provide the Cap method.
+type queue[T, E any] struct {
+	policy queuePolicy[T, E]
+	in     chan T
+	out    chan T
+	start  sync.Once
+	items  int32
+}
+
+// queuePolicy decides when a queue accepts new items, and wraps them into entries
+type queuePolicy[T, E any] interface {
+	// accepts returns whether the queue accepts a new item while it holds the provided number
+	// of entries, which is at least 1, as an empty queue always accepts one item. If it does
+	// not, it can return a channel that notifies when it must be asked again.
+	accepts(queued int) (bool, <-chan struct{})
+	// enqueue wraps an item that has been accepted into an entry
+	enqueue(item T) E
+	// item returns the item of an entry
+	item(entry E) T
+	// dequeued is invoked after the item of an entry has been forwarded to the receiver
+	dequeued(entry E)
+}
+
+func newQueue[T, E any](policy queuePolicy[T, E]) *queue[T, E] {
+	return &queue[T, E]{
+		policy: policy,
+		in:     make(chan T),
+		out:    make(chan T),
+	}
+}
+
+func (q *queue[T, E]) Send() chan<- T {
+	return q.in
+}
+
+// Receive returns the channel where the queued items are forwarded, starting the queue
+// the first time it is invoked.
+func (q *queue[T, E]) Receive() <-chan T {
+	q.start.Do(func() {
+		go q.pump(q.in)
+	})
+	return q.out
+}
+
+func (q *queue[T, E]) Close() {
+	close(q.in)
+}
+
+func (q *queue[T, E]) Len() int {
+	return int(atomic.LoadInt32(&q.items))
+}
+
+// pump accepts items from the input channel while the policy allows it, and forwards them to
+// the output channel. At least one item is always accepted when the queue is empty, so an
+// unbuffered queue behaves as a channel with a single slot.
+// When the input channel is closed, the output channel is closed after all the queued items have
+// been forwarded.
+func (q *queue[T, E]) pump(in <-chan T) {
+	var entries []E
+	for in != nil || len(entries) > 0 {
+		var recv <-chan T
+		var wake <-chan struct{}
+		if in != nil {
+			if len(entries) == 0 {
+				recv = in
+			} else if ok, notify := q.policy.accepts(len(entries)); ok {
+				recv = in
+			} else {
+				wake = notify
+			}
+		}
+		var send chan<- T
+		var head T
+		if len(entries) > 0 {
+			send = q.out
+			head = q.policy.item(entries[0])
+		}
+		select {
+		case item, ok := <-recv:
+			if !ok {
+				in = nil
+				continue
+			}
+			entries = append(entries, q.policy.enqueue(item))
+		case send <- head:
+			q.policy.dequeued(entries[0])
+			var zero E
+			entries[0] = zero
+			entries = entries[1:]
+		case <-wake:
+			// ask the policy again
+		}
+		atomic.StoreInt32(&q.items, int32(len(entries)))
+	}
+	close(q.out)
+}
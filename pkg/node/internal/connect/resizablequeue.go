@@ -0,0 +1,54 @@
+package connect
+
+import (
+	"sync/atomic"
+)
+
+// resizableQueue is a Channel whose capacity can be changed while the items are flowing
+// through it. As the Go channels can't be resized, the items are queued by a pump goroutine.
+type resizableQueue[T any] struct {
+	*queue[T, T]
+	capacity int32
+	// notifies the pump that the capacity has changed
+	resized chan struct{}
+}
+
+func newResizableQueue[T any](capacity int) *resizableQueue[T] {
+	q := &resizableQueue[T]{
+		capacity: int32(capacity),
+		resized:  make(chan struct{}, 1),
+	}
+	q.queue = newQueue[T, T](q)
+	return q
+}
+
+func (q *resizableQueue[T]) Cap() int {
+	return int(atomic.LoadInt32(&q.capacity))
+}
+
+// setCap changes the capacity of the queue. If the queue holds more items than the new
+// capacity, they are kept, and no more items are accepted until the queue is below it.
+func (q *resizableQueue[T]) setCap(capacity int) {
+	atomic.StoreInt32(&q.capacity, int32(capacity))
+	select {
+	case q.resized <- struct{}{}:
+	default:
+		// the pump has a pending notification
+	}
+}
+
+// accepts items while the queue has less items than its current capacity, or until the
+// capacity changes
+func (q *resizableQueue[T]) accepts(queued int) (bool, <-chan struct{}) {
+	return queued < q.Cap(), q.resized
+}
+
+func (q *resizableQueue[T]) enqueue(item T) T {
+	return item
+}
+
+func (q *resizableQueue[T]) item(entry T) T {
+	return entry
+}
+
+func (q *resizableQueue[T]) dequeued(T) {}
@@ -0,0 +1,52 @@
+package connect
+
+import (
+	"time"
+)
+
+// timedQueue is a Channel that measures the time that each item waits in the queue, from it is
+// sent until the receiver picks it up.
+type timedQueue[T any] struct {
+	*queue[T, timedItem[T]]
+	capacity int
+	now      func() time.Time
+	observe  func(time.Duration)
+}
+
+type timedItem[T any] struct {
+	item     T
+	enqueued time.Time
+}
+
+func newTimedQueue[T any](capacity int, now func() time.Time, observe func(time.Duration)) *timedQueue[T] {
+	q := &timedQueue[T]{
+		capacity: capacity,
+		now:      now,
+		observe:  observe,
+	}
+	q.queue = newQueue[T, timedItem[T]](q)
+	return q
+}
+
+func (q *timedQueue[T]) Cap() int {
+	return q.capacity
+}
+
+// accepts items while the queue has less items than its capacity
+func (q *timedQueue[T]) accepts(queued int) (bool, <-chan struct{}) {
+	return queued < q.capacity, nil
+}
+
+// enqueue timestamps the item
+func (q *timedQueue[T]) enqueue(item T) timedItem[T] {
+	return timedItem[T]{item: item, enqueued: q.now()}
+}
+
+func (q *timedQueue[T]) item(entry timedItem[T]) T {
+	return entry.item
+}
+
+// dequeued observes the time that the item waited
+func (q *timedQueue[T]) dequeued(entry timedItem[T]) {
+	q.observe(q.now().Sub(entry.enqueued))
+}
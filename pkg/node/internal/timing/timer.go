@@ -0,0 +1,18 @@
+// Package timing contains the time abstractions that are shared by the node package and its
+// testing utilities.
+package timing
+
+import "time"
+
+// Timer abstracts a time.Timer, so it can be created by a Clock.
+type Timer interface {
+	// C returns the channel where the current time is sent when the Timer expires
+	C() <-chan time.Time
+	// Stop prevents the Timer from firing. It returns false if the Timer has already expired or
+	// been stopped.
+	Stop() bool
+	// Reset changes the Timer to expire after the given duration. It returns true if the Timer
+	// had been active. As with time.Timer, it should be invoked only on stopped or expired timers
+	// whose channel has been drained.
+	Reset(d time.Duration) bool
+}
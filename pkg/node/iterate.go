@@ -0,0 +1,69 @@
+//go:build go1.23
+
+package node
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync/atomic"
+)
+
+// Iterate connects the provided sender node of the graph to a new Terminal node, which is added
+// to the graph, and returns an iterator over the items that the Terminal receives:
+//
+//	for item, err := range node.Iterate(ctx, graph, lastNode) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// The graph is started with the provided context when the iteration begins, and the iteration
+// ends when the graph completes (this is, when the input of the Terminal is closed). If the loop
+// breaks early, the context of the graph is cancelled, and the items that still reach the
+// Terminal are discarded. Graph.Done allows waiting for the cancelled graph to finish.
+// If the graph can't be started (e.g. because it is not valid, or it has already been started),
+// the iterator yields the error as its only element. As a graph can't be started multiple times,
+// the returned iterator can be used only once: the next iterations yield an error.
+// The options are passed to the created Terminal node. Iterate must be invoked before the graph
+// is started.
+// Go does not allow methods with type parameters, so it is a function instead of a Graph method.
+func Iterate[T any](ctx context.Context, g *Graph, from Sender[T], opts ...Option) iter.Seq2[T, error] {
+	items := make(chan T)
+	stop := make(chan struct{})
+	term := AsTerminal(func(in <-chan T) {
+		defer close(items)
+		for item := range in {
+			select {
+			case items <- item:
+			case <-stop:
+				drain(in)
+				return
+			}
+		}
+	}, opts...)
+	from.SendsTo(term)
+	g.Add(term)
+	iterated := int32(0)
+	return func(yield func(T, error) bool) {
+		var zero T
+		if !atomic.CompareAndSwapInt32(&iterated, 0, 1) {
+			yield(zero, errors.New("the graph iterator can be used only once"))
+			return
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		if err := g.StartCtx(ctx); err != nil {
+			yield(zero, err)
+			return
+		}
+		// stops forwarding the items before cancelling the graph
+		defer close(stop)
+		for item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
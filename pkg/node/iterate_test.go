@@ -0,0 +1,77 @@
+//go:build go1.23
+
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIterate(t *testing.T) {
+	start := AsStart(Counter(1, 5))
+	odds := AsMiddle(OddFilter)
+	start.SendsTo(odds)
+	graph := NewGraph(start, odds)
+
+	seq := Iterate[int](context.Background(), graph, odds)
+	var received []int
+	for n, err := range seq {
+		require.NoError(t, err)
+		received = append(received, n)
+	}
+	assert.Equal(t, []int{1, 3, 5}, received)
+	waitDone(t, graph.Done())
+
+	// the graph can't be started again, so the second iteration only yields an error
+	var errs []error
+	for n, err := range seq {
+		assert.Zero(t, n)
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 1)
+	assert.Error(t, errs[0])
+}
+
+func TestIterate_Break(t *testing.T) {
+	start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		for n := 0; ; n++ {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- n:
+			}
+		}
+	})
+	graph := NewGraph(start)
+
+	var received []int
+	for n, err := range Iterate[int](context.Background(), graph, start) {
+		require.NoError(t, err)
+		received = append(received, n)
+		if n == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []int{0, 1, 2}, received)
+	// breaking the loop cancels the graph
+	waitDone(t, graph.Done())
+	waitDone(t, start.Done())
+}
+
+func TestIterate_InvalidGraph(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	odds := AsMiddle(OddFilter)
+	start.SendsTo(odds)
+	// the orphan node makes the graph invalid
+	graph := NewGraph(start, odds, AsMiddle(EvenFilter))
+	var errs []error
+	for _, err := range Iterate[int](context.Background(), graph, odds) {
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 1)
+	assert.Error(t, errs[0])
+	assert.Equal(t, graph.Validate(), errs[0])
+}
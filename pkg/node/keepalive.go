@@ -0,0 +1,68 @@
+package node
+
+import (
+	"context"
+	"time"
+)
+
+// Keepalive returns a Middle node that forwards all the received items and, if no item has been
+// received for the given interval, sends a keepalive item, as returned by the provided function.
+// It keeps alive the connections of the downstream consumers that time out when they don't
+// receive data. The keepalive items stop when the input is closed or the context passed to the
+// node is cancelled.
+// The node.WithClock option allows overriding the source of time.
+func Keepalive[T any](interval time.Duration, keepalive func() T, opts ...Option) *Middle[T, T] {
+	clock := getOptions(opts...).clock
+	return AsMiddleCtx(func(ctx context.Context, in <-chan T, out chan<- T) {
+		timer := clock.NewTimer(interval)
+		defer timer.Stop()
+		timeout := timer.C()
+		cancelled := ctx.Done()
+		for {
+			select {
+			case item, ok := <-in:
+				if !ok {
+					return
+				}
+				out <- item
+				if timeout != nil {
+					resetTimer(timer, interval)
+				}
+			case <-timeout:
+				out <- keepalive()
+				timer.Reset(interval)
+			case <-cancelled:
+				// stop sending keepalives, but keep forwarding the input until it is closed
+				timer.Stop()
+				timeout, cancelled = nil, nil
+			}
+		}
+	}, opts...)
+}
+
+// Punctuate returns a Middle node that forwards all the received items and, every interval,
+// sends a punctuation item, as returned by the provided function for the current time,
+// interleaved with the received items in their arrival order. Unlike Keepalive, the punctuation
+// items are sent regardless of the input activity, so downstream nodes can rely on them to
+// trigger time-based operations (e.g. flushing a window).
+// The punctuation items stop, and the node output is closed, when the input is closed.
+// The node.WithClock option allows overriding the source of time.
+func Punctuate[T any](interval time.Duration, mark func(time.Time) T, opts ...Option) *Middle[T, T] {
+	clock := getOptions(opts...).clock
+	return AsMiddle(func(in <-chan T, out chan<- T) {
+		timer := clock.NewTimer(interval)
+		defer timer.Stop()
+		for {
+			select {
+			case item, ok := <-in:
+				if !ok {
+					return
+				}
+				out <- item
+			case now := <-timer.C():
+				out <- mark(now)
+				timer.Reset(interval)
+			}
+		}
+	}, opts...)
+}
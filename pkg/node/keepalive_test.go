@@ -0,0 +1,93 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepalive(t *testing.T) {
+	start := AsStart(func(out chan<- string) {
+		out <- "hello"
+		time.Sleep(100 * time.Millisecond)
+		out <- "world"
+	})
+	keepalive := Keepalive(20*time.Millisecond, func() string { return "ping" })
+	var received []string
+	term := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			received = append(received, s)
+		}
+	})
+	start.SendsTo(keepalive)
+	keepalive.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	require.GreaterOrEqual(t, len(received), 3)
+	assert.Equal(t, "hello", received[0])
+	assert.Equal(t, "world", received[len(received)-1])
+	for _, s := range received[1 : len(received)-1] {
+		assert.Equal(t, "ping", s)
+	}
+}
+
+func TestKeepalive_Cancel(t *testing.T) {
+	release := make(chan struct{})
+	start := AsStartCtx(func(_ context.Context, out chan<- string) {
+		out <- "hello"
+		<-release
+		out <- "world"
+	})
+	keepalive := Keepalive(10*time.Millisecond, func() string { return "ping" })
+	probe := keepalive.Probe()
+	start.SendsTo(keepalive)
+	ctx, cancel := context.WithCancel(context.Background())
+	start.StartCtx(ctx)
+
+	items, err := probe.Expect(2, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hello", "ping"}, items)
+
+	cancel()
+	// after cancelling, keepalives are not sent (except the one that could have been sent
+	// before the node observed the cancellation), and the output is closed
+	pings := 0
+	for item, ok := probe.Next(timeout); ok; item, ok = probe.Next(timeout) {
+		require.Equal(t, "ping", item)
+		pings++
+	}
+	assert.LessOrEqual(t, pings, 1)
+	close(release)
+	waitDone(t, keepalive.Done())
+}
+
+func TestPunctuate(t *testing.T) {
+	release := make(chan struct{})
+	start := AsStart(func(out chan<- string) {
+		out <- "a"
+		out <- "b"
+		<-release
+		out <- "c"
+	})
+	punctuate := Punctuate(10*time.Millisecond, func(time.Time) string { return "flush" })
+	probe := punctuate.Probe()
+	start.SendsTo(punctuate)
+	start.Start()
+
+	items, err := probe.Expect(4, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "flush", "flush"}, items)
+
+	close(release)
+	waitDone(t, punctuate.Done())
+	var tail []string
+	for item, ok := probe.Next(10 * time.Millisecond); ok; item, ok = probe.Next(10 * time.Millisecond) {
+		tail = append(tail, item)
+	}
+	require.NotEmpty(t, tail)
+	assert.Equal(t, "c", tail[len(tail)-1])
+}
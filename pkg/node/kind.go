@@ -0,0 +1,298 @@
+package node
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// NodeKind identifies the role of a node within a graph
+type NodeKind int
+
+const (
+	// KindStart identifies the node.Start nodes
+	KindStart NodeKind = iota
+	// KindMiddle identifies the node.Middle nodes
+	KindMiddle
+	// KindTerminal identifies the node.Terminal nodes
+	KindTerminal
+)
+
+func (k NodeKind) String() string {
+	switch k {
+	case KindStart:
+		return "Start"
+	case KindMiddle:
+		return "Middle"
+	case KindTerminal:
+		return "Terminal"
+	default:
+		return fmt.Sprintf("NodeKind(%d)", int(k))
+	}
+}
+
+// NodeState is the stage of the lifecycle of a node
+type NodeState int32
+
+const (
+	// StateNotStarted identifies the nodes that have not been started yet
+	StateNotStarted NodeState = iota
+	// StateRunning identifies the nodes that are processing their input
+	StateRunning
+	// StateDraining identifies the nodes whose input has been closed, but that are still
+	// processing the items that they had received, or closing their outputs
+	StateDraining
+	// StateFinished identifies the nodes that have finished their processing
+	StateFinished
+	// StateFailed identifies the nodes whose function has panicked, and the panic has been
+	// recovered by the node.WithPanicHandler option
+	StateFailed
+)
+
+func (s NodeState) String() string {
+	switch s {
+	case StateNotStarted:
+		return "NotStarted"
+	case StateRunning:
+		return "Running"
+	case StateDraining:
+		return "Draining"
+	case StateFinished:
+		return "Finished"
+	case StateFailed:
+		return "Failed"
+	default:
+		return fmt.Sprintf("NodeState(%d)", int(s))
+	}
+}
+
+// Schema describes the types of the data that a node receives and sends.
+type Schema struct {
+	// In is the inner type of the node input channel, or nil if the node does not receive data
+	In reflect.Type
+	// Out is the inner type of the node output channel, or nil if the node does not send data
+	Out reflect.Type
+}
+
+// Node is the common interface to all the node types: node.Start, node.Middle and node.Terminal.
+// It allows inspecting the nodes regardless of the types of the data they receive and send.
+type Node interface {
+	graphNode
+	// Name of the node, as provided by the node.WithName option. If not provided, the name
+	// is derived from the node kind and types.
+	Name() string
+	// Kind of the node: KindStart, KindMiddle or KindTerminal
+	Kind() NodeKind
+	// Schema describes the types of the data that the node receives and sends
+	Schema() Schema
+	// Stats provides runtime information about the node
+	Stats() Stats
+	// Labels returns the key/value labels of the node, as provided by the node.WithLabels option
+	Labels() map[string]string
+	// Done returns a channel that is closed when the node has finished its processing
+	Done() <-chan struct{}
+	// State returns the current stage of the lifecycle of the node. It can be invoked
+	// concurrently with the node execution.
+	State() NodeState
+	// meta returns the information that is common to all the node types
+	meta() *nodeMeta
+}
+
+// Stats provides runtime information about a node.
+type Stats struct {
+	// BufferLen is the number of items that are waiting in the node input channel.
+	// It is always 0 for node.Start.
+	BufferLen int
+	// BufferCap is the capacity of the node input channel. It is 0 for unbuffered channels
+	// and for node.Start.
+	BufferCap int
+	// Abandoned is the number of items that the node could not forward to all its receivers,
+	// because the graph context was cancelled or the node.WithCloseTimeout timeout expired.
+	Abandoned int64
+	// Emitted is the number of items that the node has sent to its outputs, and Delivered is the
+	// number of items that its receivers have got from it. They are only counted for the nodes
+	// created with the node.CountEdges option. Otherwise, they are 0. The counts of each
+	// receiver are reported by Graph.Edges.
+	Emitted   int64
+	Delivered int64
+	// QueueWait is the histogram of the time that the items waited in the node input buffer
+	// before being processed. It is nil unless the node is created with the
+	// node.MeasureQueueWait option.
+	QueueWait *HistogramSnapshot
+}
+
+// nodeMeta contains the information that is common to all the node types
+type nodeMeta struct {
+	name string
+	// if not nil, the panics of the node function are recovered and passed to this function
+	panicHandler func(NodePanic)
+	// if not nil, it is also notified of the recovered panics
+	panicObserver func(NodePanic)
+	// if true, connecting the node to a receiver that is already connected is ignored
+	dedupeReceivers bool
+	labels          map[string]string
+	// if not nil, counters of the items sent to each receiver
+	edgeCounts map[graphNode]*int64
+	// if edgeCounts is not nil, counter of the items sent to the outputs
+	emitted      *int64
+	closeTimeout time.Duration
+	// source of the timer of the closeTimeout
+	clock Clock
+	// number of items that could not be forwarded to all the receivers. Allocated separately to
+	// guarantee the 64-bit alignment of atomic operations
+	abandoned *int64
+	// if not nil, invoked for each item that is forwarded to multiple receivers, or that a routing
+	// node discards without forwarding it (with 0 receivers)
+	onFork func(item any, receivers int)
+	// 1 once the node has been started and its receivers can't be modified
+	forked int32
+	// maximum number of goroutines that run the node function concurrently. 0 means 1
+	parallelism int
+	// if not nil, invoked when the node starts and finishes its processing
+	onStart  func()
+	onFinish func()
+	// NodeState of the node, as set by its goroutine
+	state int32
+	// if not nil, returns whether the input of the node has been closed
+	inputDone func() bool
+	// if true, the outputs of the node keep forwarding its items after its context is cancelled
+	// (e.g. because the node sends its pending items on cancellation)
+	flushOnCancel bool
+	// if not nil, the Subgraph that declares the node as one of its ports
+	subgraph *Subgraph
+}
+
+func (m *nodeMeta) Name() string {
+	return m.name
+}
+
+// State returns the current stage of the lifecycle of the node. It can be invoked concurrently
+// with the node execution.
+func (m *nodeMeta) State() NodeState {
+	state := NodeState(atomic.LoadInt32(&m.state))
+	if state == StateRunning && m.inputDone != nil && m.inputDone() {
+		return StateDraining
+	}
+	return state
+}
+
+// failed returns whether the node function has panicked
+func (m *nodeMeta) failed() bool {
+	return atomic.LoadInt32(&m.state) == int32(StateFailed)
+}
+
+// Labels returns a copy of the labels of the node, or nil if the node has no labels
+func (m *nodeMeta) Labels() map[string]string {
+	return copyLabels(m.labels)
+}
+
+func (m *nodeMeta) abandonedItems() int64 {
+	if m.abandoned == nil {
+		return 0
+	}
+	return atomic.LoadInt64(m.abandoned)
+}
+
+// senderStats fills the statistics about the items that the node has sent to its receivers
+func (m *nodeMeta) senderStats(stats *Stats) {
+	stats.Abandoned = m.abandonedItems()
+	if m.edgeCounts == nil {
+		return
+	}
+	stats.Emitted = atomic.LoadInt64(m.emitted)
+	for _, counter := range m.edgeCounts {
+		stats.Delivered += atomic.LoadInt64(counter)
+	}
+}
+
+// Amplification returns the average number of receivers that got each item sent by the node,
+// which is the ratio between the Delivered and the Emitted items (e.g. 3 for a node that sends
+// all its items to 3 receivers). It allows estimating the extra load that each new receiver of
+// a node causes to the graph. It is 0 if no items have been emitted or counted.
+func (s Stats) Amplification() float64 {
+	if s.Emitted == 0 {
+		return 0
+	}
+	return float64(s.Delivered) / float64(s.Emitted)
+}
+
+func (m *nodeMeta) meta() *nodeMeta {
+	return m
+}
+
+func newNodeMeta(options *creationOptions, kind NodeKind, schema Schema) nodeMeta {
+	name := options.name
+	if name == "" {
+		name = defaultName(kind, schema)
+	}
+	meta := nodeMeta{
+		name:            name,
+		panicHandler:    options.panicHandler,
+		dedupeReceivers: options.dedupeReceivers,
+		labels:          copyLabels(options.labels),
+		closeTimeout:    options.closeTimeout,
+		clock:           options.clock,
+		abandoned:       new(int64),
+		onFork:          options.onFork,
+		onStart:         options.onStart,
+		onFinish:        options.onFinish,
+	}
+	if options.countEdges {
+		meta.edgeCounts = map[graphNode]*int64{}
+		meta.emitted = new(int64)
+	}
+	return meta
+}
+
+// notifyStart sets the node as running, and invokes the hook of the node.WithOnStart option, if
+// any
+func (m *nodeMeta) notifyStart() {
+	atomic.CompareAndSwapInt32(&m.state, int32(StateNotStarted), int32(StateRunning))
+	if m.onStart != nil {
+		m.onStart()
+	}
+}
+
+// notifyFinish sets the node as finished, unless it has failed, and invokes the hook of the
+// node.WithOnFinish option, if any
+func (m *nodeMeta) notifyFinish() {
+	atomic.CompareAndSwapInt32(&m.state, int32(StateRunning), int32(StateFinished))
+	if m.onFinish != nil {
+		m.onFinish()
+	}
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	cp := make(map[string]string, len(labels))
+	for k, v := range labels {
+		cp[k] = v
+	}
+	return cp
+}
+
+func defaultName(kind NodeKind, schema Schema) string {
+	switch kind {
+	case KindStart:
+		return fmt.Sprintf("%s[%v]", kind, schema.Out)
+	case KindTerminal:
+		return fmt.Sprintf("%s[%v]", kind, schema.In)
+	default:
+		return fmt.Sprintf("%s[%v,%v]", kind, schema.In, schema.Out)
+	}
+}
+
+var _ Node = (*Start[any])(nil)
+var _ Node = (*Middle[any, any])(nil)
+var _ Node = (*Terminal[any])(nil)
+var _ Node = (*Probe[any])(nil)
+var _ Node = (*Switch[any])(nil)
+var _ Node = (*Broadcaster[any])(nil)
+var _ Node = (*Exchange[any])(nil)
+var _ Node = (*Partition[any])(nil)
+var _ Node = (*LookupJoiner[any, any, int, any])(nil)
+var _ Node = (*Meter[any])(nil)
+var _ Node = (*Composite[any, any])(nil)
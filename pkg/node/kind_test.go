@@ -0,0 +1,257 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeInterface(t *testing.T) {
+	intType, stringType := reflect.TypeOf(1), reflect.TypeOf("")
+	nodes := []Node{
+		AsStart(Counter(1, 3)),
+		AsStart(Counter(1, 3), WithName("counter")),
+		AsMiddle(Messager("msg")),
+		AsMiddle(Messager("msg"), WithName("messager")),
+		AsTerminal(func(in <-chan string) {}),
+		AsTerminal(func(in <-chan string) {}, WithName("printer")),
+	}
+	type info struct {
+		name   string
+		kind   NodeKind
+		schema Schema
+	}
+	var infos []info
+	for _, n := range nodes {
+		infos = append(infos, info{name: n.Name(), kind: n.Kind(), schema: n.Schema()})
+	}
+	assert.Equal(t, []info{
+		{name: "Start[int]", kind: KindStart, schema: Schema{Out: intType}},
+		{name: "counter", kind: KindStart, schema: Schema{Out: intType}},
+		{name: "Middle[int,string]", kind: KindMiddle, schema: Schema{In: intType, Out: stringType}},
+		{name: "messager", kind: KindMiddle, schema: Schema{In: intType, Out: stringType}},
+		{name: "Terminal[string]", kind: KindTerminal, schema: Schema{In: stringType}},
+		{name: "printer", kind: KindTerminal, schema: Schema{In: stringType}},
+	}, infos)
+}
+
+func TestNodeKind_String(t *testing.T) {
+	assert.Equal(t, "Start", KindStart.String())
+	assert.Equal(t, "Middle", KindMiddle.String())
+	assert.Equal(t, "Terminal", KindTerminal.String())
+	assert.Equal(t, "NodeKind(7)", NodeKind(7).String())
+}
+
+func TestNodeState_String(t *testing.T) {
+	assert.Equal(t, "NotStarted", StateNotStarted.String())
+	assert.Equal(t, "Running", StateRunning.String())
+	assert.Equal(t, "Draining", StateDraining.String())
+	assert.Equal(t, "Finished", StateFinished.String())
+	assert.Equal(t, "Failed", StateFailed.String())
+	assert.Equal(t, "NodeState(9)", NodeState(9).String())
+}
+
+func TestNodeState(t *testing.T) {
+	release, step := make(chan struct{}), make(chan struct{})
+	start := AsStart(func(out chan<- int) {
+		out <- 1
+		<-release
+	})
+	middle := AsMiddle(func(in <-chan int, out chan<- int) {
+		for n := range in {
+			<-step
+			out <- n
+		}
+	})
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	start.SendsTo(middle)
+	middle.SendsTo(term)
+	states := func() []NodeState {
+		return []NodeState{start.State(), middle.State(), term.State()}
+	}
+	assert.Equal(t, []NodeState{StateNotStarted, StateNotStarted, StateNotStarted}, states())
+
+	start.Start()
+	assert.Eventually(t, func() bool {
+		return reflect.DeepEqual([]NodeState{StateRunning, StateRunning, StateRunning}, states())
+	}, timeout, time.Millisecond)
+
+	// the middle node is still processing an item after its input is closed
+	close(release)
+	waitDone(t, start.Done())
+	assert.Equal(t, []NodeState{StateFinished, StateDraining, StateRunning}, states())
+
+	close(step)
+	waitDone(t, term.Done())
+	assert.Equal(t, []NodeState{StateFinished, StateFinished, StateFinished}, states())
+}
+
+func TestNodeState_Failed(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	middle := AsMiddle(func(in <-chan int, out chan<- int) {
+		panic("boom")
+	}, WithPanicHandler(func(NodePanic) {}))
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	start.SendsTo(middle)
+	middle.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	waitDone(t, middle.Done())
+	assert.Equal(t, StateFailed, middle.State())
+	assert.Equal(t, StateFinished, term.State())
+}
+
+func TestNodeState_RunSerial(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	var states []NodeState
+	double := Map(func(n int) int { return n * 2 })
+	term := ForEach(func(int) {
+		states = append(states, double.State())
+	})
+	start.SendsTo(double)
+	double.SendsTo(term)
+	assert.Equal(t, StateNotStarted, double.State())
+
+	require.NoError(t, NewGraph(start, double, term).RunSerial(context.Background()))
+	assert.Equal(t, []NodeState{StateRunning, StateRunning, StateRunning}, states)
+	for _, n := range []Node{start, double, term} {
+		assert.Equal(t, StateFinished, n.State(), n.Name())
+	}
+}
+
+func TestNodeState_Composite(t *testing.T) {
+	release := make(chan struct{})
+	start := AsStart(Counter(1, 1))
+	composite := Compose(func(in Sender[int]) Sender[int] {
+		slow := AsMiddle(func(in <-chan int, out chan<- int) {
+			for n := range in {
+				<-release
+				out <- n
+			}
+		})
+		in.SendsTo(slow)
+		return slow
+	})
+	term := ForEach(func(int) {})
+	start.SendsTo(composite)
+	composite.SendsTo(term)
+	assert.Equal(t, StateNotStarted, composite.State())
+
+	start.Start()
+	// the input of the composite is closed, but its subgraph is still processing an item
+	waitDone(t, start.Done())
+	assert.Eventually(t, func() bool {
+		return composite.State() == StateDraining
+	}, timeout, time.Millisecond)
+
+	close(release)
+	waitDone(t, composite.Done())
+	assert.Equal(t, StateFinished, composite.State())
+}
+
+func TestNodeState_Probe(t *testing.T) {
+	release := make(chan struct{})
+	start := AsStart(func(out chan<- int) {
+		out <- 1
+		<-release
+	})
+	probe := start.Probe()
+	assert.Equal(t, StateNotStarted, probe.State())
+
+	start.Start()
+	_, ok := probe.Next(timeout)
+	require.True(t, ok)
+	assert.Equal(t, StateRunning, probe.State())
+
+	// the probe finishes once its input is closed
+	close(release)
+	waitDone(t, probe.Done())
+	assert.Equal(t, StateFinished, probe.State())
+}
+
+func TestNodeStats(t *testing.T) {
+	release := make(chan struct{})
+	start := AsStart(Counter(1, 3))
+	middle := AsMiddle(func(in <-chan int, out chan<- int) {
+		<-release
+		for i := range in {
+			out <- i
+		}
+	}, ChannelBufferLen(5))
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	start.SendsTo(middle)
+	middle.SendsTo(term)
+	graph := NewGraph(start, middle, term)
+	require.NoError(t, graph.Start())
+
+	// the middle node is blocked, so all the items are waiting in its input buffer
+	assert.Eventually(t, func() bool {
+		return middle.Stats() == Stats{BufferLen: 3, BufferCap: 5}
+	}, timeout, 10*time.Millisecond)
+	assert.Equal(t, Stats{}, start.Stats())
+	assert.Equal(t, Stats{}, term.Stats())
+
+	close(release)
+	waitDone(t, graph.Done())
+	assert.Equal(t, Stats{BufferCap: 5}, middle.Stats())
+	for _, n := range graph.Nodes() {
+		assert.Zero(t, n.Stats().BufferLen, n.Name())
+	}
+}
+
+func TestNodeStats_Amplification(t *testing.T) {
+	start := AsStart(Counter(1, 4))
+	tap := AsMiddle(func(in <-chan int, out chan<- int) {
+		for n := range in {
+			out <- n
+		}
+	}, CountEdges())
+	var t1, t2, t3 []int
+	term1, term2, term3 := collectInts(&t1), collectInts(&t2), collectInts(&t3)
+	start.SendsTo(tap)
+	tap.SendsTo(term1, term2, term3)
+	start.Start()
+
+	for _, term := range []*Terminal[int]{term1, term2, term3} {
+		waitDone(t, term.Done())
+	}
+	stats := tap.Stats()
+	assert.EqualValues(t, 4, stats.Emitted)
+	assert.EqualValues(t, 12, stats.Delivered)
+	assert.Equal(t, 3.0, stats.Amplification())
+	// the nodes that don't count their edges don't report amplification
+	assert.Zero(t, start.Stats().Amplification())
+}
+
+func TestNodeLabels(t *testing.T) {
+	labels := map[string]string{"team": "net"}
+	start := AsStart(Counter(1, 3), WithLabels(labels), WithLabels(map[string]string{"tier": "ingest"}))
+	enrich := AsMiddle(OddFilter, WithLabels(map[string]string{"team": "net", "tier": "enrichment"}))
+	term := AsTerminal(func(in <-chan int) {})
+	// modifying the original map does not modify the labels
+	labels["team"] = "other"
+
+	assert.Equal(t, map[string]string{"team": "net", "tier": "ingest"}, start.Labels())
+	assert.Nil(t, term.Labels())
+	start.Labels()["team"] = "modified"
+	assert.Equal(t, "net", start.Labels()["team"])
+
+	graph := NewGraph(start, enrich, term)
+	assert.Equal(t, []Node{start, enrich}, graph.NodesLabeled("team", "net"))
+	assert.Equal(t, []Node{enrich}, graph.NodesLabeled("tier", "enrichment"))
+	assert.Empty(t, graph.NodesLabeled("team", "other"))
+}
@@ -0,0 +1,120 @@
+package node
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultLatencyBounds are the upper bounds of the buckets of a LatencyHistogram, if not
+// specified
+var defaultLatencyBounds = []time.Duration{
+	100 * time.Microsecond, time.Millisecond, 10 * time.Millisecond,
+	100 * time.Millisecond, time.Second, 10 * time.Second,
+}
+
+// Timestamped wraps an item together with the time it entered the graph, which allows measuring
+// the time it takes to traverse the graph.
+type Timestamped[T any] struct {
+	Time  time.Time
+	Value T
+}
+
+// Timestamp returns a Middle node that wraps each received item into a Timestamped item with the
+// current time. It is intended to be connected right after the Start nodes, so the latency
+// measured by node.RecordLatency is the end-to-end latency of the graph.
+// The node.WithClock option allows overriding the source of time.
+func Timestamp[T any](opts ...Option) *Middle[T, Timestamped[T]] {
+	clock := getOptions(opts...).clock
+	return Map(func(item T) Timestamped[T] {
+		return Timestamped[T]{Time: clock.Now(), Value: item}
+	}, opts...)
+}
+
+// RecordLatency returns a Middle node that records in the provided histogram the time elapsed
+// since each received item was timestamped, and forwards the unwrapped item. It is intended to be
+// connected right before the Terminal nodes.
+// The latency is recorded per output: if a Timestamped item is sent to multiple branches of the
+// graph, the latency is recorded for each RecordLatency node it reaches. The intermediate nodes
+// that aggregate or split items are responsible for propagating the timestamps (e.g. keeping the
+// earliest timestamp of the aggregated items).
+// If the histogram is nil (e.g. the Latency of a Graph whose latency tracking is not enabled), the
+// items are unwrapped without recording their latency.
+// The node.WithClock option allows overriding the source of time.
+func RecordLatency[T any](h *LatencyHistogram, opts ...Option) *Middle[Timestamped[T], T] {
+	clock := getOptions(opts...).clock
+	return Map(func(item Timestamped[T]) T {
+		if h != nil {
+			h.Observe(clock.Now().Sub(item.Time))
+		}
+		return item.Value
+	}, opts...)
+}
+
+// LatencyHistogram counts latency observations in buckets. It is safe for concurrent use.
+type LatencyHistogram struct {
+	mt     sync.Mutex
+	bounds []time.Duration
+	counts []uint64
+	sum    time.Duration
+}
+
+// HistogramSnapshot is a copy of the state of a LatencyHistogram
+type HistogramSnapshot struct {
+	// Bounds are the inclusive upper bounds of the buckets, in increasing order
+	Bounds []time.Duration
+	// Counts contains the number of observations of each bucket. Its last element counts the
+	// observations that are greater than the last bound, so it has one more element than Bounds.
+	Counts []uint64
+	// Count is the total number of observations
+	Count uint64
+	// Sum is the sum of all the observations
+	Sum time.Duration
+}
+
+// NewLatencyHistogram creates a LatencyHistogram with the provided bucket upper bounds, which
+// must be in increasing order. If no bounds are provided, it uses exponential bounds from
+// 100µs to 10s.
+func NewLatencyHistogram(bounds ...time.Duration) *LatencyHistogram {
+	if len(bounds) == 0 {
+		bounds = defaultLatencyBounds
+	}
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i] <= bounds[i-1] {
+			panic("histogram bounds must be in increasing order")
+		}
+	}
+	return &LatencyHistogram{
+		bounds: append([]time.Duration{}, bounds...),
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// Observe records a latency observation
+func (h *LatencyHistogram) Observe(d time.Duration) {
+	bucket := len(h.bounds)
+	for i, b := range h.bounds {
+		if d <= b {
+			bucket = i
+			break
+		}
+	}
+	h.mt.Lock()
+	h.counts[bucket]++
+	h.sum += d
+	h.mt.Unlock()
+}
+
+// Snapshot returns a copy of the current state of the histogram
+func (h *LatencyHistogram) Snapshot() HistogramSnapshot {
+	h.mt.Lock()
+	defer h.mt.Unlock()
+	s := HistogramSnapshot{
+		Bounds: append([]time.Duration{}, h.bounds...),
+		Counts: append([]uint64{}, h.counts...),
+		Sum:    h.sum,
+	}
+	for _, c := range h.counts {
+		s.Count += c
+	}
+	return s
+}
@@ -0,0 +1,113 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatency(t *testing.T) {
+	now := time.Date(2022, 3, 4, 12, 0, 0, 0, time.UTC)
+	start := AsStart(Counter(1, 4))
+	stamp := Timestamp[int](WithClock(fixedClock(now)))
+	graph := NewGraph(start, stamp)
+	graph.EnableLatency()
+	record := RecordLatency[int](graph.Latency(), WithClock(fixedClock(now.Add(5*time.Millisecond))))
+	// a second branch that reaches another output
+	slowRecord := RecordLatency[int](graph.Latency(), WithClock(fixedClock(now.Add(2*time.Second))))
+	var received []int
+	term := collectInts(&received)
+	discard := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	start.SendsTo(stamp)
+	stamp.SendsTo(record, slowRecord)
+	record.SendsTo(term)
+	slowRecord.SendsTo(discard)
+	graph.Add(record, slowRecord, term, discard)
+
+	assert.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	assert.Equal(t, []int{1, 2, 3, 4}, received)
+	assert.Equal(t, HistogramSnapshot{
+		Bounds: defaultLatencyBounds,
+		Counts: []uint64{0, 0, 4, 0, 0, 4, 0},
+		Count:  8,
+		Sum:    4*5*time.Millisecond + 4*2*time.Second,
+	}, graph.Latency().Snapshot())
+}
+
+func TestLatency_Disabled(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	stamp := Timestamp[int]()
+	graph := NewGraph(start, stamp)
+	require.Nil(t, graph.Latency())
+	record := RecordLatency[int](graph.Latency())
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(stamp)
+	stamp.SendsTo(record)
+	record.SendsTo(term)
+	graph.Add(record, term)
+
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	// the items are forwarded without recording their latency
+	assert.Equal(t, []int{1, 2, 3}, received)
+	assert.Nil(t, graph.Latency())
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	h := NewLatencyHistogram(time.Millisecond, time.Second)
+	h.Observe(time.Millisecond)
+	h.Observe(2 * time.Millisecond)
+	h.Observe(time.Minute)
+	assert.Equal(t, HistogramSnapshot{
+		Bounds: []time.Duration{time.Millisecond, time.Second},
+		Counts: []uint64{1, 1, 1},
+		Count:  3,
+		Sum:    time.Minute + 3*time.Millisecond,
+	}, h.Snapshot())
+
+	assert.Panics(t, func() {
+		NewLatencyHistogram(time.Second, time.Millisecond)
+	})
+}
+
+func TestMeasureQueueWait(t *testing.T) {
+	start := AsStart(Counter(1, 5))
+	var received []int
+	slow := AsTerminal(func(in <-chan int) {
+		for n := range in {
+			time.Sleep(5 * time.Millisecond)
+			received = append(received, n)
+		}
+	}, ChannelBufferLen(5), MeasureQueueWait(time.Millisecond, time.Hour))
+	fast := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	start.SendsTo(slow, fast)
+	start.Start()
+
+	waitDone(t, slow.Done())
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, received)
+	queueWait := slow.Stats().QueueWait
+	if assert.NotNil(t, queueWait) {
+		assert.EqualValues(t, 5, queueWait.Count)
+		// the items that were queued behind slowly processed items waited more than 1ms
+		assert.Greater(t, queueWait.Counts[1], uint64(0))
+		assert.Zero(t, queueWait.Counts[2])
+	}
+	assert.Nil(t, fast.Stats().QueueWait)
+}
+
+func TestMeasureQueueWait_InvalidOptions(t *testing.T) {
+	assert.Panics(t, func() {
+		AsTerminal(func(in <-chan []byte) {},
+			MeasureQueueWait(), WithByteBuffer(10, func(b []byte) int { return len(b) }))
+	})
+}
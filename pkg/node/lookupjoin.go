@@ -0,0 +1,210 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LookupJoiner is a node that joins each received item with the entry of a reference table
+// (e.g. a configuration or dimension table), and sends the result to its receivers. The items
+// whose key is not in the table are sent to the receivers of the Misses endpoint.
+type LookupJoiner[IN, OUT any, K comparable, R any] struct {
+	nodeMeta
+	receiverBase[IN]
+	key     func(IN) K
+	table   func() map[K]R
+	refresh time.Duration
+	merge   func(IN, R) OUT
+	outType reflect.Type
+	outs    []Receiver[OUT]
+	misses  []Receiver[IN]
+	// current table, of type map[K]R, which is replaced by the background reloads
+	refs atomic.Value
+	done chan struct{}
+}
+
+// LookupJoin returns a LookupJoiner node that joins each received item with the entry of a
+// reference table whose key is provided by the key function, and sends the result of merging
+// both with the merge function.
+// The table is loaded with the table function when the node starts, and reloaded in background
+// every refresh interval while the node is running. The table function must return a new map on
+// each invocation, as the node keeps joining the items with the previous map until the new one
+// is returned, and then it atomically swaps them.
+// The items whose key is not in the table are sent to the receivers of the Misses endpoint (e.g.
+// a branch of the graph that handles them), or discarded if it has no receivers.
+// If the context of the node is cancelled, it stops joining the items and discards the rest of
+// its input, so it does not block on slow receivers.
+// The node.WithClock option allows overriding the source of time.
+func LookupJoin[IN, OUT any, K comparable, R any](
+	key func(IN) K,
+	table func() map[K]R,
+	refresh time.Duration,
+	merge func(IN, R) OUT,
+	opts ...Option,
+) *LookupJoiner[IN, OUT, K, R] {
+	var out OUT
+	options := getOptions(opts...)
+	receiver := newReceiverBase[IN](&options)
+	outType := reflect.TypeOf(out)
+	j := &LookupJoiner[IN, OUT, K, R]{
+		nodeMeta:     newNodeMeta(&options, KindMiddle, Schema{In: receiver.inType, Out: outType}),
+		receiverBase: receiver,
+		key:          key,
+		table:        table,
+		refresh:      refresh,
+		merge:        merge,
+		outType:      outType,
+		done:         make(chan struct{}),
+	}
+	j.inputDone = j.receiverBase.inputClosed
+	return j
+}
+
+// SendsTo connects the output of the joined items with a group of receivers
+func (j *LookupJoiner[IN, OUT, K, R]) SendsTo(outputs ...Receiver[OUT]) {
+	j.outs = connectTo(&j.nodeMeta, j.outs, outputs)
+}
+
+// Misses returns the endpoint that sends the items whose key is not in the table
+func (j *LookupJoiner[IN, OUT, K, R]) Misses() Sender[IN] {
+	return lookupMisses[IN, OUT, K, R]{j: j}
+}
+
+// OutType returns the type of the joined items
+func (j *LookupJoiner[IN, OUT, K, R]) OutType() reflect.Type {
+	return j.outType
+}
+
+// Done returns a channel that is closed when the LookupJoiner node has ended its processing.
+// This is, when its input has been closed and all its outputs have been closed.
+func (j *LookupJoiner[IN, OUT, K, R]) Done() <-chan struct{} {
+	return j.done
+}
+
+// Kind returns KindMiddle
+func (j *LookupJoiner[IN, OUT, K, R]) Kind() NodeKind {
+	return KindMiddle
+}
+
+// Schema returns the input type of the LookupJoiner, and the type of the joined items
+func (j *LookupJoiner[IN, OUT, K, R]) Schema() Schema {
+	return Schema{In: j.inType, Out: j.outType}
+}
+
+// Stats returns runtime information about the LookupJoiner node
+func (j *LookupJoiner[IN, OUT, K, R]) Stats() Stats {
+	stats := j.receiverBase.Stats()
+	j.senderStats(&stats)
+	return stats
+}
+
+func (j *LookupJoiner[IN, OUT, K, R]) outputs() []graphNode {
+	return append(receiversAsNodes(j.outs), receiversAsNodes(j.misses)...)
+}
+
+func (j *LookupJoiner[IN, OUT, K, R]) start(ctx context.Context) {
+	if len(j.outs) == 0 && len(j.misses) == 0 {
+		panicNoOutputs(&j.nodeMeta)
+	}
+	if !j.markStarted() {
+		return
+	}
+	var joined chan<- OUT
+	var missed chan<- IN
+	var releasers []func()
+	if len(j.outs) > 0 {
+		forker := forkTo(ctx, &j.nodeMeta, j.outs)
+		joined = forker.Sender()
+		releasers = append(releasers, forker.Close)
+	}
+	if len(j.misses) > 0 {
+		forker := forkTo(ctx, &j.nodeMeta, j.misses)
+		missed = forker.Sender()
+		releasers = append(releasers, forker.Close)
+	}
+	go func() {
+		j.notifyStart()
+		if !j.invoke(j, func() { j.join(ctx, joined, missed) }) {
+			drain[IN](j.inputs.Receiver())
+		}
+		for _, release := range releasers {
+			release()
+		}
+		j.notifyFinish()
+		close(j.done)
+	}()
+}
+
+// join loads the table and joins the received items until the input is closed or the context
+// is cancelled. Meanwhile, the table is reloaded in background.
+func (j *LookupJoiner[IN, OUT, K, R]) join(ctx context.Context, joined chan<- OUT, missed chan<- IN) {
+	j.refs.Store(j.table())
+	stop := make(chan struct{})
+	reloader := sync.WaitGroup{}
+	reloader.Add(1)
+	go j.reload(ctx, stop, &reloader)
+	defer func() {
+		close(stop)
+		reloader.Wait()
+	}()
+	for item := range j.inputs.Receiver() {
+		refs := j.refs.Load().(map[K]R)
+		if ref, ok := refs[j.key(item)]; ok {
+			if joined == nil {
+				continue
+			}
+			select {
+			case joined <- j.merge(item, ref):
+			case <-ctx.Done():
+				drain[IN](j.inputs.Receiver())
+				return
+			}
+		} else if missed != nil {
+			select {
+			case missed <- item:
+			case <-ctx.Done():
+				drain[IN](j.inputs.Receiver())
+				return
+			}
+		}
+	}
+}
+
+// reload replaces the table every refresh interval, until stop is closed or the context is
+// cancelled. If the table function panics and the node has a panic handler, the panic is passed
+// to the handler without failing the node, which keeps joining the items with the last table
+// until the next reload.
+func (j *LookupJoiner[IN, OUT, K, R]) reload(
+	ctx context.Context, stop <-chan struct{}, wg *sync.WaitGroup,
+) {
+	defer wg.Done()
+	timer := j.clock.NewTimer(j.refresh)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C():
+			j.invokeBackground(j, func() { j.refs.Store(j.table()) })
+			timer.Reset(j.refresh)
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// lookupMisses is the Sender endpoint of the items of a LookupJoiner that miss the table
+type lookupMisses[IN, OUT any, K comparable, R any] struct {
+	j *LookupJoiner[IN, OUT, K, R]
+}
+
+func (m lookupMisses[IN, OUT, K, R]) SendsTo(receivers ...Receiver[IN]) {
+	m.j.misses = connectTo(&m.j.nodeMeta, m.j.misses, receivers)
+}
+
+func (m lookupMisses[IN, OUT, K, R]) OutType() reflect.Type {
+	return m.j.inType
+}
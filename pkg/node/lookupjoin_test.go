@@ -0,0 +1,165 @@
+package node
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupJoin(t *testing.T) {
+	loads := int32(0)
+	table := func() map[string]string {
+		if atomic.AddInt32(&loads, 1) == 1 {
+			return map[string]string{"a": "v1"}
+		}
+		return map[string]string{"a": "v2", "b": "v2"}
+	}
+	input := make(chan string)
+	start := AsStart(func(out chan<- string) {
+		for s := range input {
+			out <- s
+		}
+	})
+	clock := nodetest.NewManualClock()
+	join := LookupJoin(func(s string) string { return s }, table, time.Minute,
+		func(s, ref string) string { return s + ":" + ref }, WithClock(clock))
+	joined, missed := make(chan string, 10), make(chan string, 10)
+	joinedTerm := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			joined <- s
+		}
+	})
+	missedTerm := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			missed <- s
+		}
+	})
+	start.SendsTo(join)
+	join.SendsTo(joinedTerm)
+	join.Misses().SendsTo(missedTerm)
+	graph := NewGraph(start, join, joinedTerm, missedTerm)
+	require.NoError(t, graph.Validate())
+	require.NoError(t, graph.Start())
+
+	input <- "a"
+	nodetest.ExpectReceives(t, joined, "a:v1", timeout)
+	input <- "b"
+	nodetest.ExpectReceives(t, missed, "b", timeout)
+
+	// the table is reloaded in background
+	require.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&loads) == 2 }, timeout, time.Millisecond)
+	input <- "a"
+	nodetest.ExpectReceives(t, joined, "a:v2", timeout)
+	input <- "b"
+	nodetest.ExpectReceives(t, joined, "b:v2", timeout)
+	input <- "c"
+	nodetest.ExpectReceives(t, missed, "c", timeout)
+
+	close(input)
+	waitDone(t, graph.Done())
+	// the background reload is stopped
+	assert.Zero(t, clock.Timers())
+}
+
+func TestLookupJoin_Cancel(t *testing.T) {
+	start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		for n := 1; ; n++ {
+			select {
+			case out <- n:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	join := LookupJoin(func(n int) int { return n % 2 }, func() map[int]string {
+		return map[int]string{0: "even", 1: "odd"}
+	}, time.Hour, func(n int, ref string) string { return ref })
+	release := make(chan struct{})
+	defer close(release)
+	received := make(chan string, 1)
+	term := AsTerminal(func(in <-chan string) {
+		received <- <-in
+		// the receiver is blocked
+		<-release
+		for range in {
+		}
+	})
+	start.SendsTo(join)
+	join.SendsTo(term)
+	ctx, cancel := context.WithCancel(context.Background())
+	start.StartCtx(ctx)
+	nodetest.ExpectReceives(t, received, "odd", timeout)
+
+	// the node is not blocked by its receiver after the context is cancelled
+	cancel()
+	waitDone(t, join.Done())
+}
+
+func TestLookupJoin_ReloadPanic(t *testing.T) {
+	loads := int32(0)
+	table := func() map[string]string {
+		switch atomic.AddInt32(&loads, 1) {
+		case 1:
+			return map[string]string{"a": "v1"}
+		case 2:
+			panic("store unavailable")
+		default:
+			return map[string]string{"a": "v3"}
+		}
+	}
+	input := make(chan string)
+	start := AsStart(func(out chan<- string) {
+		for s := range input {
+			out <- s
+		}
+	})
+	clock := nodetest.NewManualClock()
+	panics := make(chan NodePanic, 10)
+	join := LookupJoin(func(s string) string { return s }, table, time.Minute,
+		func(s, ref string) string { return s + ":" + ref },
+		WithClock(clock), WithPanicHandler(func(p NodePanic) { panics <- p }))
+	joined := make(chan string, 10)
+	joinedTerm := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			joined <- s
+		}
+	})
+	start.SendsTo(join)
+	join.SendsTo(joinedTerm)
+	graph := NewGraph(start, join, joinedTerm)
+	require.NoError(t, graph.Start())
+
+	input <- "a"
+	nodetest.ExpectReceives(t, joined, "a:v1", timeout)
+
+	// the panic of the reload is reported, and the node keeps joining with the last table
+	require.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+	clock.Advance(time.Minute)
+	select {
+	case p := <-panics:
+		assert.Equal(t, "store unavailable", p.Value)
+	case <-time.After(timeout):
+		require.Fail(t, "timeout while waiting for the panic")
+	}
+	assert.Equal(t, StateRunning, join.State())
+	input <- "a"
+	nodetest.ExpectReceives(t, joined, "a:v1", timeout)
+
+	// the next reloads are still scheduled
+	require.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&loads) == 3 }, timeout, time.Millisecond)
+	input <- "a"
+	nodetest.ExpectReceives(t, joined, "a:v3", timeout)
+
+	close(input)
+	waitDone(t, graph.Done())
+	assert.Equal(t, StateFinished, join.State())
+}
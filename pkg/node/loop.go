@@ -0,0 +1,120 @@
+package node
+
+import "sync/atomic"
+
+// Hop wraps an item that flows through a feedback loop, together with the number of times that
+// it has traversed the loop
+type Hop[T any] struct {
+	Item T
+	// Hops is the number of node.LoopGuard nodes that the item has passed through
+	Hops int
+}
+
+// Loop is a Middle node that guards the entry of a feedback loop, as returned by node.LoopGuard
+type Loop[T any] struct {
+	*Middle[Hop[T], Hop[T]]
+	// receives the items that are sent back to the guard through the FeedsBack method
+	feedback *Terminal[Hop[T]]
+	// closed once the guard stops accepting the items that are sent back
+	closed chan struct{}
+	// allocated separately to guarantee the 64-bit alignment of atomic operations
+	dropped *int64
+}
+
+// LoopGuard returns a Loop node that breaks the runaway feedback loops: it increments the hop
+// count of each received item, and forwards it only if the count does not exceed maxHops.
+// Otherwise, the item is dropped, so an item can't circulate forever through the loop.
+// The hop count is attached when an item enters the loop, by wrapping it into a Hop with a zero
+// count (e.g. with a node.Map), and it is reset by unwrapping it when it leaves the loop.
+// The last nodes of the loop must be connected back to the guard through its FeedsBack method,
+// instead of SendsTo, so the graph is not reported as cyclic. The guard accepts the items that
+// are sent back while it waits to forward others, so the loop does not deadlock when the
+// channels of its nodes are full.
+// When the input of the guard is closed, it forwards its pending items and closes its output, so
+// the loop nodes can finish. The items that are sent back afterwards are dropped.
+// It panics if maxHops is lower than 1.
+func LoopGuard[T any](maxHops int, opts ...Option) *Loop[T] {
+	if maxHops < 1 {
+		panic("LoopGuard requires at least one hop")
+	}
+	l := &Loop[T]{closed: make(chan struct{}), dropped: new(int64)}
+	sentBack := make(chan Hop[T])
+	l.Middle = AsMiddle(func(in <-chan Hop[T], out chan<- Hop[T]) {
+		defer close(l.closed)
+		var feedback <-chan Hop[T]
+		if l.feedback.joiner().Wired() {
+			feedback = sentBack
+		}
+		var pending []Hop[T]
+		accept := func(item Hop[T]) {
+			item.Hops++
+			if item.Hops > maxHops {
+				atomic.AddInt64(l.dropped, 1)
+				return
+			}
+			pending = append(pending, item)
+		}
+		for {
+			// the input is only read when there are no pending items, so the items that are sent
+			// back take precedence, and the number of items in the loop is bounded
+			var input <-chan Hop[T]
+			var send chan<- Hop[T]
+			var next Hop[T]
+			if len(pending) > 0 {
+				send, next = out, pending[0]
+			} else if in != nil {
+				input = in
+			} else {
+				return
+			}
+			select {
+			case item, ok := <-input:
+				if !ok {
+					in = nil
+					continue
+				}
+				accept(item)
+			case item := <-feedback:
+				accept(item)
+			case send <- next:
+				pending = pending[1:]
+			}
+		}
+	}, opts...)
+	l.feedback = AsTerminal(func(in <-chan Hop[T]) {
+		for item := range in {
+			select {
+			case sentBack <- item:
+			case <-l.closed:
+				atomic.AddInt64(l.dropped, 1)
+			}
+		}
+	}, WithName(l.Name()+"/feedback"))
+	return l
+}
+
+// FeedsBack connects the provided senders, which are the last nodes of the loop, back to the
+// guard. The connection is made through an internal Terminal node, so the graph has no cycle:
+// node.Graph validates and sorts the loop nodes as if the senders were the end of the graph.
+// As SendsTo, it must be invoked before the guard is started.
+func (l *Loop[T]) FeedsBack(senders ...Sender[Hop[T]]) {
+	for _, s := range senders {
+		s.SendsTo(l.feedback)
+	}
+}
+
+// Done returns a channel that is closed when the guard has finished and, if the loop is connected
+// back to it, when the loop nodes have finished sending back their items.
+func (l *Loop[T]) Done() <-chan struct{} {
+	if !l.feedback.joiner().Wired() {
+		return l.Middle.Done()
+	}
+	return AllDone(l.Middle, l.feedback)
+}
+
+// Dropped returns the number of items that have been dropped, either because they exceeded the
+// maximum number of hops or because they were sent back after the guard input was closed. It
+// can be invoked concurrently with the node execution.
+func (l *Loop[T]) Dropped() int64 {
+	return atomic.LoadInt64(l.dropped)
+}
@@ -0,0 +1,110 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoopGuard(t *testing.T) {
+	nodetest.AssertNoLeaks(t, func() {
+		input := make(chan string)
+		start := AsStart(func(out chan<- Hop[string]) {
+			for s := range input {
+				out <- Hop[string]{Item: s}
+			}
+		})
+		guard := LoopGuard[string](3)
+		// sends back to the guard every item, forever
+		body := AsMiddle(func(in <-chan Hop[string], out chan<- Hop[string]) {
+			for item := range in {
+				out <- item
+			}
+		})
+		passes := make(chan Hop[string], 10)
+		term := AsTerminal(func(in <-chan Hop[string]) {
+			for item := range in {
+				passes <- item
+			}
+		})
+		start.SendsTo(guard)
+		guard.SendsTo(body)
+		body.SendsTo(term)
+		guard.FeedsBack(body)
+		graph := NewGraph(start, guard, body, term)
+		// the connection back to the guard is not reported as a cycle
+		require.NoError(t, graph.Start())
+
+		input <- "a"
+		input <- "b"
+		require.Eventually(t, func() bool {
+			return guard.Dropped() == 2
+		}, timeout, time.Millisecond)
+		hops := map[string][]int{}
+		for i := 0; i < 6; i++ {
+			select {
+			case p := <-passes:
+				hops[p.Item] = append(hops[p.Item], p.Hops)
+			case <-time.After(timeout):
+				require.Fail(t, "timeout while waiting for the items")
+			}
+		}
+		assert.Equal(t, map[string][]int{"a": {1, 2, 3}, "b": {1, 2, 3}}, hops)
+		// the dropped items don't circulate anymore
+		nodetest.ExpectBlocked(t, passes)
+
+		// closing the input of the guard closes the loop
+		close(input)
+		waitDone(t, graph.Done())
+		waitDone(t, guard.Done())
+	})
+}
+
+func TestLoopGuard_FullChannels(t *testing.T) {
+	nodetest.AssertNoLeaks(t, func() {
+		start := AsStart(func(out chan<- Hop[int]) {
+			for n := 0; n < 100; n++ {
+				out <- Hop[int]{Item: n}
+			}
+		})
+		guard := LoopGuard[int](5)
+		// each item is sent back multiple times, so the unbuffered channels of the loop are
+		// always full
+		body := AsMiddle(func(in <-chan Hop[int], out chan<- Hop[int]) {
+			for item := range in {
+				out <- item
+				out <- item
+			}
+		})
+		start.SendsTo(guard)
+		guard.SendsTo(body)
+		guard.FeedsBack(body)
+		graph := NewGraph(start, guard, body)
+		require.NoError(t, graph.Start())
+
+		// the guard input is closed once all the items have entered the loop, and the items
+		// that are still circulating are dropped
+		waitDone(t, guard.Done())
+		assert.Positive(t, guard.Dropped())
+	})
+}
+
+func TestLoopGuard_Cycle(t *testing.T) {
+	start := AsStart(func(out chan<- Hop[int]) {})
+	guard := LoopGuard[int](3)
+	body := AsMiddle(func(in <-chan Hop[int], out chan<- Hop[int]) {})
+	start.SendsTo(guard)
+	guard.SendsTo(body)
+	// connecting the loop directly is reported as a cycle
+	body.SendsTo(guard)
+	err := NewGraph(start, guard, body).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cycle")
+}
+
+func TestLoopGuard_NoHops(t *testing.T) {
+	assert.Panics(t, func() { LoopGuard[int](0) })
+}
@@ -0,0 +1,112 @@
+package node
+
+import (
+	"context"
+	"time"
+)
+
+// Map returns a Middle node that converts each received item with the provided function, and
+// forwards the result. The node can be paused.
+func Map[IN, OUT any](fun func(IN) OUT, opts ...Option) *Middle[IN, OUT] {
+	return asStepMiddle(func(item IN, emit func(OUT)) {
+		emit(fun(item))
+	}, opts...)
+}
+
+// Filter returns a Middle node that only forwards the received items that satisfy the provided
+// predicate. The node can be paused.
+func Filter[T any](pred func(T) bool, opts ...Option) *Middle[T, T] {
+	return asStepMiddle(func(item T, emit func(T)) {
+		if pred(item) {
+			emit(item)
+		}
+	}, opts...)
+}
+
+// Scan returns a Middle node that accumulates the received items with the step function,
+// starting from the initial accumulator, and forwards the accumulator after each received item
+// (e.g. a running total). The node can be paused.
+func Scan[IN, ACC any](initial ACC, step func(ACC, IN) ACC, opts ...Option) *Middle[IN, ACC] {
+	acc := initial
+	return asStepMiddle(func(item IN, emit func(ACC)) {
+		acc = step(acc, item)
+		emit(acc)
+	}, opts...)
+}
+
+// Pairwise returns a Middle node that forwards the result of the combine function for each pair
+// of consecutive received items (e.g. to turn the samples of a cumulative counter into deltas).
+// It sends nothing for the first item, so it forwards N-1 results for N received items. Only the
+// previous item is retained. The node can be paused.
+func Pairwise[IN, OUT any](combine func(prev, cur IN) OUT, opts ...Option) *Middle[IN, OUT] {
+	var prev IN
+	first := true
+	return asStepMiddle(func(item IN, emit func(OUT)) {
+		if !first {
+			emit(combine(prev, item))
+		}
+		prev, first = item, false
+	}, opts...)
+}
+
+// TrySend returns a Middle node that forwards each received item without blocking: if the
+// input buffer of the next node is full, the item is passed to the onDrop function instead (e.g.
+// to count the dropped items or to write them into a secondary destination). It allows
+// best-effort, low-latency stages that never backpressure the previous nodes.
+// The input buffer of the next nodes can be specified with their node.ChannelBufferLen option.
+// With unbuffered inputs, the items are only forwarded if the next node is already waiting for
+// them. The node can be paused.
+func TrySend[T any](onDrop func(T), opts ...Option) *Middle[T, T] {
+	return asPausableMiddle(func(item T, out chan<- T) {
+		select {
+		case out <- item:
+		default:
+			onDrop(item)
+		}
+	}, opts...)
+}
+
+// ForEach returns a Terminal node that invokes the provided function for each received item.
+// The function is invoked from a single goroutine. The node can run in serial mode (see
+// Graph.RunSerial).
+func ForEach[T any](fun func(T), opts ...Option) *Terminal[T] {
+	t := AsTerminal(func(in <-chan T) {
+		for item := range in {
+			fun(item)
+		}
+	}, opts...)
+	t.serial = fun
+	return t
+}
+
+// MapWithBudget returns a Middle node that converts each received item with the provided
+// function, and forwards the result. The function is invoked with a context whose deadline is
+// the time budget of the item, as returned by the budget function (e.g. derived from a field of
+// the item), so the calls to external services can respect it. The context is derived from the
+// context of the node.
+// If the function returns an error, or the budget is exceeded before the function returns, the
+// item is not forwarded, and it is passed to the onError function together with the error
+// (context.DeadlineExceeded if the budget has been exceeded). If onError is nil, the item is
+// dropped.
+func MapWithBudget[IN, OUT any](
+	budget func(IN) time.Duration,
+	fun func(context.Context, IN) (OUT, error),
+	onError func(IN, error),
+	opts ...Option,
+) *Middle[IN, OUT] {
+	return AsMiddleCtx(func(ctx context.Context, in <-chan IN, out chan<- OUT) {
+		for item := range in {
+			itemCtx, cancel := context.WithTimeout(ctx, budget(item))
+			result, err := fun(itemCtx, item)
+			if err == nil {
+				err = itemCtx.Err()
+			}
+			cancel()
+			if err == nil {
+				out <- result
+			} else if onError != nil {
+				onError(item, err)
+			}
+		}
+	}, opts...)
+}
@@ -0,0 +1,154 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapFilter(t *testing.T) {
+	start := AsStart(Counter(1, 6))
+	evens := Filter(func(n int) bool { return n%2 == 0 })
+	strs := Map(strconv.Itoa)
+	var received []string
+	term := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			received = append(received, s)
+		}
+	})
+	start.SendsTo(evens)
+	evens.SendsTo(strs)
+	strs.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []string{"2", "4", "6"}, received)
+}
+
+func TestScan(t *testing.T) {
+	start := AsStart(Counter(1, 5))
+	total := Scan(100, func(acc, n int) int { return acc + n })
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(total)
+	total.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{101, 103, 106, 110, 115}, received)
+}
+
+func TestPairwise(t *testing.T) {
+	start := AsStart(func(out chan<- int) {
+		// samples of a cumulative counter
+		for _, n := range []int{10, 15, 15, 30} {
+			out <- n
+		}
+	})
+	delta := Pairwise(func(prev, cur int) int { return cur - prev })
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(delta)
+	delta.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{5, 0, 15}, received)
+}
+
+func TestPairwise_SingleItem(t *testing.T) {
+	start := AsStart(Counter(1, 1))
+	pairs := Pairwise(func(prev, cur int) [2]int { return [2]int{prev, cur} })
+	var received [][2]int
+	term := AsTerminal(func(in <-chan [2]int) {
+		for p := range in {
+			received = append(received, p)
+		}
+	})
+	start.SendsTo(pairs)
+	pairs.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Empty(t, received)
+}
+
+func TestTrySend(t *testing.T) {
+	release := make(chan struct{})
+	start := AsStart(Counter(1, 5))
+	var dropped []int
+	trySend := TrySend(func(n int) {
+		dropped = append(dropped, n)
+	})
+	var received []int
+	term := AsTerminal(func(in <-chan int) {
+		<-release
+		for n := range in {
+			received = append(received, n)
+		}
+	}, ChannelBufferLen(2))
+	start.SendsTo(trySend)
+	trySend.SendsTo(term)
+	start.Start()
+
+	// the previous node is not blocked by the slow terminal
+	waitDone(t, trySend.Done())
+	close(release)
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{1, 2}, received)
+	assert.Equal(t, []int{3, 4, 5}, dropped)
+}
+
+func TestMapWithBudget(t *testing.T) {
+	type job struct {
+		name   string
+		budget time.Duration
+		work   time.Duration
+	}
+	start := AsStart(func(out chan<- job) {
+		out <- job{name: "fast", budget: time.Second, work: time.Millisecond}
+		out <- job{name: "slow", budget: 5 * time.Millisecond, work: time.Second}
+		out <- job{name: "stubborn", budget: time.Millisecond, work: 10 * time.Millisecond}
+		out <- job{name: "failing", budget: time.Second}
+	})
+	failed := map[string]error{}
+	process := MapWithBudget(func(j job) time.Duration { return j.budget },
+		func(ctx context.Context, j job) (string, error) {
+			switch j.name {
+			case "failing":
+				return "", errors.New("failed")
+			case "stubborn":
+				// ignores the context
+				time.Sleep(j.work)
+				return j.name, nil
+			}
+			select {
+			case <-time.After(j.work):
+				return j.name, nil
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		},
+		func(j job, err error) { failed[j.name] = err })
+	var received []string
+	term := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			received = append(received, s)
+		}
+	})
+	start.SendsTo(process)
+	process.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []string{"fast"}, received)
+	require.Len(t, failed, 3)
+	assert.ErrorIs(t, failed["slow"], context.DeadlineExceeded)
+	assert.ErrorIs(t, failed["stubborn"], context.DeadlineExceeded)
+	assert.EqualError(t, failed["failing"], "failed")
+}
@@ -0,0 +1,116 @@
+package node
+
+import "sync"
+
+// MergeAll returns a Middle node that receives a stream of channels, and forwards the items of
+// all of them as they arrive, draining each received channel from its own goroutine. The output
+// is closed after the input has been closed and all the received channels have been closed and
+// drained.
+// The node.WithMaxConcurrency option bounds the number of channels that are drained concurrently.
+// When the bound is reached, the received channels are not drained, and no more channels are
+// received, until one of the active channels is closed. By default, it is unbounded.
+func MergeAll[T any](opts ...Option) *Middle[<-chan T, T] {
+	maxConcurrency := getOptions(opts...).maxConcurrency
+	return AsMiddle(func(in <-chan (<-chan T), out chan<- T) {
+		var slots chan struct{}
+		if maxConcurrency > 0 {
+			slots = make(chan struct{}, maxConcurrency)
+		}
+		wg := sync.WaitGroup{}
+		for ch := range in {
+			if ch == nil {
+				continue
+			}
+			if slots != nil {
+				slots <- struct{}{}
+			}
+			wg.Add(1)
+			go func(ch <-chan T) {
+				defer wg.Done()
+				for item := range ch {
+					out <- item
+				}
+				if slots != nil {
+					<-slots
+				}
+			}(ch)
+		}
+		wg.Wait()
+	}, opts...)
+}
+
+// fairMergeBufferLen is the number of items that FairMerge buffers for each source. A single
+// item allows servicing the sources in strict rotation with minimal memory.
+const fairMergeBufferLen = 1
+
+// FairMerge joins the outputs of the provided source nodes into a Middle node that forwards their
+// items fairly, so a fast source can't dominate the output under load: the items of each source
+// are queued in their own buffer of fairMergeBufferLen items, and the node services the sources
+// in rotation, forwarding one item of each source with pending items and skipping the empty
+// ones. While the buffer of a source is full, the source is blocked.
+// A source that closes its output is removed from the rotation after its buffered items have
+// been forwarded, and the rest of sources keep being serviced. The returned node must be
+// connected to its outputs through its SendsTo method, and finishes when all the sources have
+// closed their output.
+func FairMerge[T any](sources ...Sender[T]) *Middle[T, T] {
+	if len(sources) == 0 {
+		panic("FairMerge requires at least one source")
+	}
+	queues := make([]chan T, len(sources))
+	// notifies that an item has been queued, or that a queue has been closed
+	ready := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case ready <- struct{}{}:
+		default:
+			// there is already a pending notification
+		}
+	}
+	merged := AsMiddle(func(in <-chan T, out chan<- T) {
+		queues := append([]chan T(nil), queues...)
+		open, next := len(queues), 0
+		for open > 0 {
+			// services the sources in rotation, from the one after the last serviced source
+			serviced := false
+			for i := 0; i < len(queues) && !serviced; i++ {
+				idx := (next + i) % len(queues)
+				if queues[idx] == nil {
+					continue
+				}
+				select {
+				case item, ok := <-queues[idx]:
+					if !ok {
+						queues[idx] = nil
+						open--
+						continue
+					}
+					out <- item
+					next, serviced = idx+1, true
+				default:
+				}
+			}
+			if !serviced && open > 0 {
+				<-ready
+			}
+		}
+		// the lanes don't send anything through the input, which is closed when all of them end
+		drain(in)
+	})
+	for i, src := range sources {
+		queue := make(chan T, fairMergeBufferLen)
+		queues[i] = queue
+		// the lane is connected to the merged node, so it is started with the sources, but it
+		// sends its items through its own queue
+		lane := AsMiddle(func(in <-chan T, _ chan<- T) {
+			for item := range in {
+				queue <- item
+				notify()
+			}
+			close(queue)
+			notify()
+		})
+		lane.SendsTo(merged)
+		src.SendsTo(lane)
+	}
+	return merged
+}
@@ -0,0 +1,155 @@
+package node
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeAll(t *testing.T) {
+	subs := []chan int{make(chan int), make(chan int), make(chan int)}
+	start := AsStart(func(out chan<- (<-chan int)) {
+		for _, sub := range subs {
+			out <- sub
+		}
+	})
+	merge := MergeAll[int]()
+	probe := merge.Probe()
+	start.SendsTo(merge)
+	start.Start()
+
+	// items are forwarded as they arrive from any sub-stream
+	subs[2] <- 3
+	nodetest.ExpectReceives(t, probe.inputs.Receiver(), 3, timeout)
+	subs[0] <- 1
+	nodetest.ExpectReceives(t, probe.inputs.Receiver(), 1, timeout)
+	close(subs[0])
+	close(subs[2])
+	// the output is not closed until all the sub-streams are closed
+	nodetest.ExpectBlocked(t, probe.inputs.Receiver())
+	subs[1] <- 2
+	nodetest.ExpectReceives(t, probe.inputs.Receiver(), 2, timeout)
+	close(subs[1])
+	nodetest.ExpectClosed(t, probe.inputs.Receiver(), timeout)
+}
+
+func TestMergeAll_MaxConcurrency(t *testing.T) {
+	subs := []chan int{make(chan int, 10), make(chan int, 10), make(chan int, 10)}
+	for i, sub := range subs {
+		sub <- i * 10
+		sub <- i*10 + 1
+	}
+	start := AsStart(func(out chan<- (<-chan int)) {
+		for _, sub := range subs {
+			out <- sub
+		}
+	})
+	merge := MergeAll[int](WithMaxConcurrency(2))
+	probe := merge.Probe()
+	start.SendsTo(merge)
+	start.Start()
+
+	// the third channel is not drained until any of the first two is closed
+	items, err := probe.Expect(4, timeout)
+	assert.NoError(t, err)
+	sort.Ints(items)
+	assert.Equal(t, []int{0, 1, 10, 11}, items)
+	nodetest.ExpectBlocked(t, probe.inputs.Receiver())
+
+	close(subs[1])
+	items, err = probe.Expect(2, timeout)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{20, 21}, items)
+	close(subs[0])
+	close(subs[2])
+	_, ok := probe.Next(timeout)
+	assert.False(t, ok)
+}
+
+func TestFairMerge(t *testing.T) {
+	// the sources notify when they have sent their second item: the merge node has then queued
+	// the first one, so they have pending items when the consumer starts reading
+	filling := func(from, to int, filled chan<- struct{}) StartFunc[int] {
+		return func(out chan<- int) {
+			for i := from; i <= to; i++ {
+				out <- i
+				if i == from+1 {
+					close(filled)
+				}
+			}
+		}
+	}
+	fastFilled, slowFilled := make(chan struct{}), make(chan struct{})
+	fast := AsStart(filling(1, 1000, fastFilled))
+	slow := AsStart(filling(10001, 10010, slowFilled))
+	merge := FairMerge[int](fast, slow)
+	release := make(chan struct{})
+	out := make(chan int, 1010)
+	term := AsTerminal(func(in <-chan int) {
+		<-release
+		for n := range in {
+			out <- n
+		}
+	})
+	merge.SendsTo(term)
+	fast.Start()
+	slow.Start()
+
+	waitDone(t, fastFilled)
+	waitDone(t, slowFilled)
+	close(release)
+	waitDone(t, term.Done())
+	close(out)
+
+	var fastItems, slowItems []int
+	firstSlow := -1
+	i := 0
+	for n := range out {
+		if n > 10000 {
+			if firstSlow < 0 {
+				firstSlow = i
+			}
+			slowItems = append(slowItems, n)
+		} else {
+			fastItems = append(fastItems, n)
+		}
+		i++
+	}
+	// all the items are forwarded, in the order of their source
+	assert.Equal(t, []int{10001, 10002, 10003, 10004, 10005, 10006, 10007, 10008, 10009, 10010}, slowItems)
+	assert.Len(t, fastItems, 1000)
+	assert.True(t, sort.IntsAreSorted(fastItems))
+	// the slow source is not starved by the fast source
+	assert.GreaterOrEqual(t, firstSlow, 0)
+	assert.Less(t, firstSlow, 5)
+}
+
+func TestFairMerge_SourcesEndAtDifferentTimes(t *testing.T) {
+	release := make(chan struct{})
+	short := AsStart(Counter(1, 3))
+	long := AsStart(func(out chan<- int) {
+		out <- 10
+		<-release
+		out <- 11
+		out <- 12
+	})
+	merge := FairMerge[int](short, long)
+	var received []int
+	term := collectInts(&received)
+	merge.SendsTo(term)
+	short.Start()
+	long.Start()
+
+	waitDone(t, short.Done())
+	nodetest.ExpectBlocked(t, term.Done())
+	close(release)
+	waitDone(t, term.Done())
+	sort.Ints(received)
+	assert.Equal(t, []int{1, 2, 3, 10, 11, 12}, received)
+}
+
+func TestFairMerge_NoSources(t *testing.T) {
+	assert.Panics(t, func() { FairMerge[int]() })
+}
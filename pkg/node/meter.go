@@ -0,0 +1,207 @@
+package node
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// Throughput is the measurement of the items that a Meter node has forwarded during an interval
+type Throughput struct {
+	// Start and End delimit the measured interval
+	Start, End time.Time
+	// Items is the number of items that have been forwarded during the interval
+	Items int64
+	// Bytes is the accumulated size of the items that have been forwarded during the interval.
+	// It is 0 if the Meter has not been created with a sizeOf function.
+	Bytes       int64
+	ItemsPerSec float64
+	BytesPerSec float64
+}
+
+// Meter is a node that forwards the received items unchanged, while it periodically sends
+// measurements of its throughput to a separate output (e.g. a monitoring dashboard).
+// The receivers of the items are connected through the Out endpoint, and the receivers of the
+// measurements through the Rates endpoint.
+type Meter[T any] struct {
+	nodeMeta
+	receiverBase[T]
+	interval time.Duration
+	sizeOf   func(T) int
+	clock    Clock
+	outs     []Receiver[T]
+	rates    []Receiver[Throughput]
+	done     chan struct{}
+}
+
+// NewMeter creates a Meter node that sends a Throughput measurement every interval, computed
+// over the items that have been forwarded since the previous measurement. The sizeOf function
+// provides the size of each item to measure the bytes per second. It can be nil if only the items
+// per second are required.
+// The measurements are sent even if no items have been forwarded during the interval. When the
+// input is closed, a last measurement of the incomplete interval is sent, if any item has been
+// forwarded during it.
+// The node.WithClock option allows overriding the source of time.
+func NewMeter[T any](interval time.Duration, sizeOf func(T) int, opts ...Option) *Meter[T] {
+	if interval <= 0 {
+		panic("Meter interval must be greater than zero")
+	}
+	options := getOptions(opts...)
+	receiver := newReceiverBase[T](&options)
+	m := &Meter[T]{
+		nodeMeta:     newNodeMeta(&options, KindMiddle, Schema{In: receiver.inType, Out: receiver.inType}),
+		receiverBase: receiver,
+		interval:     interval,
+		sizeOf:       sizeOf,
+		clock:        options.clock,
+		done:         make(chan struct{}),
+	}
+	m.inputDone = m.receiverBase.inputClosed
+	return m
+}
+
+// Out returns the endpoint that sends the received items
+func (m *Meter[T]) Out() Sender[T] {
+	return meterOut[T]{m: m}
+}
+
+// Rates returns the endpoint that sends the Throughput measurements
+func (m *Meter[T]) Rates() Sender[Throughput] {
+	return meterRates[T]{m: m}
+}
+
+// Done returns a channel that is closed when the Meter node has ended its processing. This is,
+// when its input has been closed and all its outputs have been closed.
+func (m *Meter[T]) Done() <-chan struct{} {
+	return m.done
+}
+
+// Kind returns KindMiddle
+func (m *Meter[T]) Kind() NodeKind {
+	return KindMiddle
+}
+
+// Schema returns the input and output types of the Meter, which are the same. The type of the
+// measurements is not part of the schema.
+func (m *Meter[T]) Schema() Schema {
+	return Schema{In: m.inType, Out: m.inType}
+}
+
+// Stats returns runtime information about the Meter node
+// Stats returns runtime information about the node. Its Emitted and Delivered items only count
+// the metered items, not the measurements.
+func (m *Meter[T]) Stats() Stats {
+	stats := m.receiverBase.Stats()
+	m.senderStats(&stats)
+	if m.edgeCounts != nil {
+		for _, r := range m.rates {
+			stats.Delivered -= atomic.LoadInt64(m.edgeCounts[r])
+		}
+	}
+	return stats
+}
+
+func (m *Meter[T]) outputs() []graphNode {
+	return append(receiversAsNodes(m.outs), receiversAsNodes(m.rates)...)
+}
+
+func (m *Meter[T]) start(ctx context.Context) {
+	if len(m.outs) == 0 && len(m.rates) == 0 {
+		panicNoOutputs(&m.nodeMeta)
+	}
+	if !m.markStarted() {
+		return
+	}
+	var out chan<- T
+	var rates chan<- Throughput
+	var releasers []func()
+	if len(m.outs) > 0 {
+		forker := forkTo(ctx, &m.nodeMeta, m.outs)
+		out = forker.Sender()
+		releasers = append(releasers, forker.Close)
+	}
+	if len(m.rates) > 0 {
+		// the measurements are not items of the metered stream, so they are not counted as
+		// emitted
+		forker := forkCounting(ctx, &m.nodeMeta, m.rates, nil)
+		rates = forker.Sender()
+		releasers = append(releasers, forker.Close)
+	}
+	go func() {
+		m.notifyStart()
+		m.measure(out, rates)
+		for _, release := range releasers {
+			release()
+		}
+		m.notifyFinish()
+		close(m.done)
+	}()
+}
+
+// measure forwards the input items to the out channel, and the measurements to the rates
+// channel. Any of the channels can be nil if it has no receivers.
+func (m *Meter[T]) measure(out chan<- T, rates chan<- Throughput) {
+	timer := m.clock.NewTimer(m.interval)
+	defer timer.Stop()
+	current := Throughput{Start: m.clock.Now()}
+	send := func() {
+		current.End = m.clock.Now()
+		if secs := current.End.Sub(current.Start).Seconds(); secs > 0 {
+			current.ItemsPerSec = float64(current.Items) / secs
+			current.BytesPerSec = float64(current.Bytes) / secs
+		}
+		if rates != nil {
+			rates <- current
+		}
+		current = Throughput{Start: current.End}
+	}
+	in := m.inputs.Receiver()
+	for {
+		select {
+		case item, ok := <-in:
+			if !ok {
+				if current.Items > 0 {
+					send()
+				}
+				return
+			}
+			current.Items++
+			if m.sizeOf != nil {
+				current.Bytes += int64(m.sizeOf(item))
+			}
+			if out != nil {
+				out <- item
+			}
+		case <-timer.C():
+			send()
+			timer.Reset(m.interval)
+		}
+	}
+}
+
+// meterOut is the Sender endpoint of the items of a Meter node
+type meterOut[T any] struct {
+	m *Meter[T]
+}
+
+func (o meterOut[T]) SendsTo(receivers ...Receiver[T]) {
+	o.m.outs = connectTo(&o.m.nodeMeta, o.m.outs, receivers)
+}
+
+func (o meterOut[T]) OutType() reflect.Type {
+	return o.m.inType
+}
+
+// meterRates is the Sender endpoint of the measurements of a Meter node
+type meterRates[T any] struct {
+	m *Meter[T]
+}
+
+func (r meterRates[T]) SendsTo(receivers ...Receiver[Throughput]) {
+	r.m.rates = connectTo(&r.m.nodeMeta, r.m.rates, receivers)
+}
+
+func (r meterRates[T]) OutType() reflect.Type {
+	return reflect.TypeOf(Throughput{})
+}
@@ -0,0 +1,112 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeter(t *testing.T) {
+	clock := nodetest.NewManualClock()
+	input := make(chan string)
+	start := AsStart(func(out chan<- string) {
+		for s := range input {
+			out <- s
+		}
+	})
+	meter := NewMeter(2*time.Second, func(s string) int { return len(s) }, WithClock(clock))
+	received := make(chan string, 10)
+	term := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			received <- s
+		}
+	})
+	measured := make(chan Throughput, 10)
+	rates := AsTerminal(func(in <-chan Throughput) {
+		for r := range in {
+			measured <- r
+		}
+	})
+	start.SendsTo(meter)
+	meter.Out().SendsTo(term)
+	meter.Rates().SendsTo(rates)
+	start.Start()
+
+	// the data is forwarded unchanged, once it has been measured
+	for _, s := range []string{"a", "bb", "ccc"} {
+		input <- s
+		nodetest.ExpectReceives(t, received, s, timeout)
+	}
+	require.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+	clock.Advance(2 * time.Second)
+	nodetest.ExpectReceives(t, measured, Throughput{
+		Start: time.Unix(0, 0).UTC(), End: time.Unix(2, 0).UTC(),
+		Items: 3, Bytes: 6, ItemsPerSec: 1.5, BytesPerSec: 3,
+	}, timeout)
+
+	require.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+	for _, s := range []string{"dddd", "e"} {
+		input <- s
+		nodetest.ExpectReceives(t, received, s, timeout)
+	}
+	clock.Advance(time.Second)
+	close(input)
+	waitDone(t, rates.Done())
+	// last measurement of the incomplete interval
+	nodetest.ExpectReceives(t, measured, Throughput{
+		Start: time.Unix(2, 0).UTC(), End: time.Unix(3, 0).UTC(),
+		Items: 2, Bytes: 5, ItemsPerSec: 2, BytesPerSec: 5,
+	}, timeout)
+	waitDone(t, term.Done())
+	assert.Empty(t, measured)
+	assert.Zero(t, clock.Timers())
+}
+
+func TestMeter_OnlyRates(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	meter := NewMeter[int](time.Hour, nil)
+	var measurements []Throughput
+	rates := AsTerminal(func(in <-chan Throughput) {
+		for r := range in {
+			measurements = append(measurements, r)
+		}
+	})
+	start.SendsTo(meter)
+	meter.Rates().SendsTo(rates)
+	start.Start()
+
+	// the items are discarded if the Out endpoint has no receivers
+	waitDone(t, rates.Done())
+	require.Len(t, measurements, 1)
+	assert.EqualValues(t, 3, measurements[0].Items)
+	assert.Zero(t, measurements[0].Bytes)
+}
+
+func TestMeter_CountEdges(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	meter := NewMeter[int](time.Hour, nil, CountEdges())
+	var received []int
+	term := collectInts(&received)
+	var measurements []Throughput
+	rates := AsTerminal(func(in <-chan Throughput) {
+		for r := range in {
+			measurements = append(measurements, r)
+		}
+	})
+	start.SendsTo(meter)
+	meter.Out().SendsTo(term)
+	meter.Rates().SendsTo(rates)
+	start.Start()
+
+	waitDone(t, term.Done())
+	waitDone(t, rates.Done())
+	require.Len(t, measurements, 1)
+	// the measurements are not counted as emitted items
+	stats := meter.Stats()
+	assert.EqualValues(t, 3, stats.Emitted)
+	assert.EqualValues(t, 3, stats.Delivered)
+	assert.Equal(t, 1.0, stats.Amplification())
+}
@@ -0,0 +1,249 @@
+package node
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"net"
+)
+
+// the frames of the network nodes are prefixed by their length as a 4-byte big endian integer.
+// This length marks the end of the stream, so the receiver can tell a closed sender from a
+// broken connection.
+const endOfStream = ^uint32(0)
+
+// maxFrameLen is the maximum length of the payload of a frame. A NetworkSource considers the
+// connections sending longer frames as broken.
+const maxFrameLen = 64 << 20
+
+// networkDialAttempts is the number of times that a NetworkSink tries to connect or to send a
+// frame before giving up
+const networkDialAttempts = 5
+
+// NetworkSink returns a Terminal node that sends the received items to a NetworkSource in the
+// provided TCP address, allowing the rest of the graph to run in another process. Each item is
+// encoded with the encode function (e.g. node.GobEncode) and sent as a length-prefixed frame.
+// When the input is closed, the node sends an end-of-stream mark and closes the connection, so
+// the NetworkSource finishes.
+// If the connection breaks, the node reconnects and sends again the item that failed, preceded
+// by the last item that was written to the broken connection, as a write may succeed before the
+// connection is detected as broken. So that item may be received twice, and the items that were
+// sent before it may still be lost.
+// If the node can't connect after a few attempts with increasing delay, an item can't be encoded,
+// or the context of the node is cancelled while reconnecting, it stops sending and discards the
+// rest of its input. The node.WithBackoff option allows overriding the delay between attempts,
+// and the node.WithClock option the source of time of the delays.
+// It also returns a function that provides the error that stopped the node, if any, once the
+// node has finished. The function blocks until the Done channel of the Terminal is closed.
+func NetworkSink[T any](addr string, encode func(T) ([]byte, error), opts ...Option) (*Terminal[T], func() error) {
+	var sinkErr error
+	options := getOptions(opts...)
+	backoff, clock := options.backoff, options.clock
+	if encode == nil {
+		encode = codecOf[T](&options).Marshal
+	}
+	term := AsTerminalCtx(func(ctx context.Context, in <-chan T) {
+		conn := &sinkConn{addr: addr, backoff: backoff, clock: clock}
+		defer conn.close()
+		for item := range in {
+			payload, err := encode(item)
+			if err != nil {
+				sinkErr = fmt.Errorf("encoding item: %w", err)
+				break
+			}
+			if len(payload) > maxFrameLen {
+				sinkErr = fmt.Errorf("encoded item is too large: %d bytes", len(payload))
+				break
+			}
+			if err := conn.send(ctx, uint32(len(payload)), payload); err != nil {
+				sinkErr = err
+				break
+			}
+		}
+		if sinkErr != nil {
+			drain(in)
+			return
+		}
+		sinkErr = conn.send(ctx, endOfStream, nil)
+	}, opts...)
+	return term, func() error {
+		<-term.Done()
+		return sinkErr
+	}
+}
+
+// sinkConn is the connection of a NetworkSink, which is established lazily and re-established
+// when it breaks
+type sinkConn struct {
+	addr    string
+	backoff BackoffPolicy
+	clock   Clock
+	conn    net.Conn
+	w       *bufio.Writer
+	// last frame written to a connection, which is sent again if the connection breaks, as it
+	// may not have been delivered
+	last *frame
+	// whether the last frame must be sent before the next one
+	resend bool
+}
+
+type frame struct {
+	length  uint32
+	payload []byte
+}
+
+// send writes a frame, flushing it immediately, and retrying it over a new connection if it
+// fails. It stops retrying if the context is cancelled.
+func (c *sinkConn) send(ctx context.Context, length uint32, payload []byte) error {
+	var err error
+	for attempt := 0; attempt < networkDialAttempts; attempt++ {
+		if attempt > 0 && !sleepCtx(ctx, c.clock, c.backoff.Delay(attempt)) {
+			err = ctx.Err()
+			break
+		}
+		if c.conn == nil {
+			if c.conn, err = net.Dial("tcp", c.addr); err != nil {
+				c.conn = nil
+				continue
+			}
+			c.w = bufio.NewWriter(c.conn)
+		}
+		if c.resend {
+			if err = writeFrame(c.w, c.last.length, c.last.payload); err != nil {
+				c.close()
+				continue
+			}
+			c.resend = false
+		}
+		if err = writeFrame(c.w, length, payload); err == nil {
+			c.last = &frame{length: length, payload: payload}
+			return nil
+		}
+		c.close()
+	}
+	return fmt.Errorf("sending to %s: %w", c.addr, err)
+}
+
+// close closes the connection. The last frame that was written to it is sent again over the
+// next connection.
+func (c *sinkConn) close() {
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+		c.resend = c.last != nil
+	}
+}
+
+func writeFrame(w *bufio.Writer, length uint32, payload []byte) error {
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], length)
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// NetworkSource returns a Start node that receives items from a NetworkSink through the
+// connections accepted by the provided listener (e.g. created with net.Listen("tcp", addr)),
+// and forwards them into the graph. Each frame is decoded with the decode function. If decode is
+// nil, the frames are decoded with the Codec of the node.WithCodec option, or with node.GobCodec
+// by default. The frames that can't be decoded are discarded.
+// The connections are accepted one at a time: if a connection breaks, the node waits for the
+// NetworkSink to reconnect, discarding any partially received frame. The node finishes, closing
+// the listener, when the NetworkSink closes its connection after sending all its items, or when
+// the context passed to the node is cancelled.
+func NetworkSource[T any](listener net.Listener, decode func([]byte) (T, error), opts ...Option) *Start[T] {
+	if decode == nil {
+		options := getOptions(opts...)
+		decode = codecOf[T](&options).Unmarshal
+	}
+	return AsStartCtx(func(ctx context.Context, out chan<- T) {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-ctx.Done():
+			case <-stop:
+			}
+			_ = listener.Close()
+		}()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if receiveFrames(ctx, conn, decode, out) {
+				return
+			}
+		}
+	}, opts...)
+}
+
+// receiveFrames forwards the items of a connection until it is closed. It returns true if the
+// sender marked the end of the stream, or the context was cancelled.
+func receiveFrames[T any](ctx context.Context, conn net.Conn, decode func([]byte) (T, error), out chan<- T) bool {
+	closed := make(chan struct{})
+	defer close(closed)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// unblocks the pending reads
+			_ = conn.Close()
+		case <-closed:
+			_ = conn.Close()
+		}
+	}()
+	r := bufio.NewReader(conn)
+	var header [4]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return ctx.Err() != nil
+		}
+		length := binary.BigEndian.Uint32(header[:])
+		if length == endOfStream {
+			return true
+		}
+		if length > maxFrameLen {
+			return false
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return ctx.Err() != nil
+		}
+		item, err := decode(payload)
+		if err != nil {
+			continue
+		}
+		select {
+		case out <- item:
+		case <-ctx.Done():
+			return true
+		}
+	}
+}
+
+// GobEncode encodes an item with the encoding/gob package. It can be passed to NetworkSink.
+// Each item is encoded independently, so it includes the description of its type.
+// See also node.GobCodec.
+func GobEncode[T any](item T) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := gob.NewEncoder(&buf).Encode(item); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode decodes an item that has been encoded with GobEncode. It can be passed to
+// NetworkSource.
+func GobDecode[T any](b []byte) (T, error) {
+	var item T
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&item)
+	return item, err
+}
@@ -0,0 +1,165 @@
+package node
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type netEvent struct {
+	Name  string
+	Bytes int
+}
+
+func TestNetworkSinkSource(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// downstream process
+	source := NetworkSource(listener, GobDecode[netEvent])
+	var received []netEvent
+	collect := ForEach(func(e netEvent) { received = append(received, e) })
+	source.SendsTo(collect)
+	source.Start()
+
+	// upstream process
+	start := AsStart(func(out chan<- netEvent) {
+		out <- netEvent{Name: "a", Bytes: 1}
+		out <- netEvent{Name: "b", Bytes: 2}
+		out <- netEvent{Name: "c", Bytes: 3}
+	})
+	sink, sinkErr := NetworkSink(listener.Addr().String(), GobEncode[netEvent])
+	start.SendsTo(sink)
+	start.Start()
+
+	require.NoError(t, sinkErr())
+	// closing the sink finishes the source
+	waitDone(t, collect.Done())
+	assert.Equal(t, []netEvent{{"a", 1}, {"b", 2}, {"c", 3}}, received)
+}
+
+func TestNetworkSource_BrokenConnection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	source := NetworkSource(listener, GobDecode[int])
+	var received []int
+	collect := collectInts(&received)
+	source.SendsTo(collect)
+	source.Start()
+
+	// a connection that breaks after sending a complete frame and a partial frame
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	payload, err := GobEncode(1)
+	require.NoError(t, err)
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, uint32(len(payload)))
+	_, err = conn.Write(append(header, payload...))
+	require.NoError(t, err)
+	binary.BigEndian.PutUint32(header, 100)
+	_, err = conn.Write(append(header, 1, 2, 3))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	// the source waits for a new connection
+	start := AsStart(Counter(2, 3))
+	sink, sinkErr := NetworkSink(listener.Addr().String(), GobEncode[int])
+	start.SendsTo(sink)
+	start.Start()
+
+	require.NoError(t, sinkErr())
+	waitDone(t, collect.Done())
+	assert.Equal(t, []int{1, 2, 3}, received)
+}
+
+func TestNetworkSink_Unreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	start := AsStart(Counter(1, 3))
+	attempts := 0
+	sink, sinkErr := NetworkSink(addr, GobEncode[int], WithBackoff(backoffFunc(func(int) time.Duration {
+		attempts++
+		return time.Millisecond
+	})))
+	start.SendsTo(sink)
+	start.Start()
+
+	// the sink gives up and discards its input, so the upstream nodes are not blocked
+	assert.Error(t, sinkErr())
+	waitDone(t, start.Done())
+	assert.Equal(t, networkDialAttempts-1, attempts)
+}
+
+func TestSinkConn_ResendAfterReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	addr := listener.Addr().String()
+	conn := &sinkConn{addr: addr, backoff: defaultOptions.backoff, clock: systemClock{}}
+	defer conn.close()
+
+	require.NoError(t, conn.send(context.Background(), 1, []byte("a")))
+	first, err := listener.Accept()
+	require.NoError(t, err)
+	defer first.Close()
+	assert.Equal(t, []string{"a"}, readFrames(t, first, 1))
+
+	// the connection breaks after the frame was written, so it might not have been delivered
+	conn.close()
+	require.NoError(t, conn.send(context.Background(), 1, []byte("b")))
+	second, err := listener.Accept()
+	require.NoError(t, err)
+	defer second.Close()
+	assert.Equal(t, []string{"a", "b"}, readFrames(t, second, 2))
+}
+
+func TestSinkConn_CancelReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	clock := nodetest.NewManualClock()
+	conn := &sinkConn{addr: addr, backoff: defaultOptions.backoff, clock: clock}
+	ctx, cancel := context.WithCancel(context.Background())
+	sent := make(chan error, 1)
+	go func() { sent <- conn.send(ctx, 1, []byte("a")) }()
+	// the sink waits for the backoff delay of the clock, until it is cancelled
+	require.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+	cancel()
+	select {
+	case err := <-sent:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(timeout):
+		require.Fail(t, "the sink did not stop reconnecting")
+	}
+}
+
+// readFrames reads the payloads of n frames from the connection
+func readFrames(t *testing.T, conn net.Conn, n int) []string {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(timeout)))
+	r := bufio.NewReader(conn)
+	var payloads []string
+	for i := 0; i < n; i++ {
+		var header [4]byte
+		_, err := io.ReadFull(r, header[:])
+		require.NoError(t, err)
+		payload := make([]byte, binary.BigEndian.Uint32(header[:]))
+		_, err = io.ReadFull(r, payload)
+		require.NoError(t, err)
+		payloads = append(payloads, string(payload))
+	}
+	return payloads
+}
@@ -6,6 +6,7 @@ package node
 import (
 	"context"
 	"reflect"
+	"sync"
 
 	"github.com/netobserv/gopipes/pkg/node/internal/connect"
 )
@@ -21,18 +22,37 @@ type StartFuncCtx[OUT any] func(ctx context.Context, out chan<- OUT)
 
 // MiddleFunc is a function that receives a readable channel as first argument,
 // and a writable channel as second argument.
-// It must process the inputs from the input channel until it's closed.
+// It must process the inputs from the input channel until it's closed. If it returns earlier,
+// its output is closed, and the rest of its input is discarded before the node is done, so the
+// previous nodes are not blocked.
+// As for the TerminalFunc, the function runs in a single goroutine.
 type MiddleFunc[IN, OUT any] func(in <-chan IN, out chan<- OUT)
 
+// MiddleFuncCtx is a MiddleFunc that also receives a context as a first argument. The context is
+// the one that has been passed to the StartCtx method of the Start node that first started
+// this node. If the context is cancelled, the implementer function may stop its processing, but
+// it should keep draining the input channel until it is closed, so the previous nodes are
+// not blocked.
+type MiddleFuncCtx[IN, OUT any] func(ctx context.Context, in <-chan IN, out chan<- OUT)
+
 // TerminalFunc is a function that receives a readable channel as unique argument.
 // It must process the inputs from the input channel until it's closed.
+// The function runs in a single goroutine, even if the Terminal node receives data from multiple
+// nodes, so it can safely mutate non-synchronized state (e.g. a map) from its loop.
 type TerminalFunc[IN any] func(out <-chan IN)
 
+// TerminalFuncCtx is a TerminalFunc that also receives a context as a first argument. As for the
+// MiddleFuncCtx, the context is the one of the Start node that first started this node, and the
+// function should keep draining the input channel after the context is cancelled.
+type TerminalFuncCtx[IN any] func(ctx context.Context, in <-chan IN)
+
 // TODO: OutType and InType methods are candidates for deprecation
 
 // Sender is any node that can send data to another node: node.Start and node.Middle
 type Sender[OUT any] interface {
-	// SendsTo connect a sender with a group of receivers
+	// SendsTo connect a sender with a group of receivers. It can be invoked multiple times, and
+	// the receivers of all the invocations are started when the sender is started. It panics if
+	// the sender has already been started.
 	SendsTo(...Receiver[OUT])
 	// OutType returns the inner type of the Sender's output channel
 	OutType() reflect.Type
@@ -40,8 +60,11 @@ type Sender[OUT any] interface {
 
 // Receiver is any node that can receive data from another node: node.Middle and node.Terminal
 type Receiver[IN any] interface {
+	graphNode
 	isStarted() bool
-	start()
+	// start the node with the context that has been passed to the Start node that first
+	// sends data to it
+	start(ctx context.Context)
 	joiner() *connect.Joiner[IN]
 	// InType returns the inner type of the Receiver's input channel
 	InType() reflect.Type
@@ -53,13 +76,64 @@ type Receiver[IN any] interface {
 // A graph must have at least one Start node.
 // A Start node must have at least one output node.
 type Start[OUT any] struct {
+	nodeMeta
 	outs    []Receiver[OUT]
 	fun     StartFuncCtx[OUT]
+	onEnd   func() OUT
+	gate    <-chan struct{}
+	done    chan struct{}
 	outType reflect.Type
+
+	stopMt sync.Mutex
+	// cancels the context of the Start function, if the node has been started
+	stop    context.CancelFunc
+	stopped bool
+	// nil if the node does not accept canary items
+	canaries chan OUT
+	// if not nil, closed when the node sends its first item
+	ready chan struct{}
 }
 
 func (s *Start[OUT]) SendsTo(outputs ...Receiver[OUT]) {
-	s.outs = append(s.outs, outputs...)
+	s.outs = connectTo(&s.nodeMeta, s.outs, outputs)
+}
+
+// OnEnd registers a function that provides a final item (e.g. an end-of-stream marker), which is
+// sent to the output after the Start function has returned and before the output is closed.
+// It must be invoked before the node is started.
+func (s *Start[OUT]) OnEnd(trailer func() OUT) {
+	s.onEnd = trailer
+}
+
+// Gate holds the execution of the Start function until the provided channel is closed (or
+// receives a value), even if the node has already been started. The rest of the nodes of the
+// graph are started normally, so they are ready to process data as soon as the gate is released.
+// This decouples the graph assembly from the beginning of the data flow, e.g. to synchronize
+// multiple graphs to start together. If the context passed to StartCtx is cancelled before the
+// gate is released, the Start function is never invoked and the output is closed.
+// It must be invoked before the node is started.
+func (s *Start[OUT]) Gate(gate <-chan struct{}) {
+	s.gate = gate
+}
+
+// Stop cancels the context that is passed to the Start function, so it can finish without
+// cancelling the context of the rest of the graph (e.g. to stop only one of the sources of a
+// multi-source graph). The Start functions that are not context-aware (e.g. created with AsStart)
+// are not stopped. If Stop is invoked before the node is started, the context is cancelled as
+// soon as the node starts.
+func (s *Start[OUT]) Stop() {
+	s.stopMt.Lock()
+	defer s.stopMt.Unlock()
+	s.stopped = true
+	if s.stop != nil {
+		s.stop()
+	}
+}
+
+// Done returns a channel that is closed when the Start node has ended its processing. This is,
+// when its function has returned and its output has been closed.
+func (s *Start[OUT]) Done() <-chan struct{} {
+	return s.done
 }
 
 // OutType is deprecated. It will be removed in future versions.
@@ -67,54 +141,98 @@ func (s *Start[OUT]) OutType() reflect.Type {
 	return s.outType
 }
 
+// Kind returns KindStart
+func (s *Start[OUT]) Kind() NodeKind {
+	return KindStart
+}
+
+// Schema returns the output type of the Start node
+func (s *Start[OUT]) Schema() Schema {
+	return Schema{Out: s.outType}
+}
+
+// Stats returns runtime information about the Start node
+func (s *Start[OUT]) Stats() Stats {
+	stats := Stats{}
+	s.senderStats(&stats)
+	return stats
+}
+
+func (s *Start[OUT]) outputs() []graphNode {
+	return receiversAsNodes(s.outs)
+}
+
 // Middle is any intermediate node that receives data from another node, processes/filters it,
 // and forwards the data to another node.
 // An Middle node must have at least one output node.
 type Middle[IN, OUT any] struct {
+	nodeMeta
+	receiverBase[IN]
 	outs    []Receiver[OUT]
-	inputs  connect.Joiner[IN]
-	started bool
-	fun     MiddleFunc[IN, OUT]
+	fun     MiddleFuncCtx[IN, OUT]
+	onEnd   func() OUT
+	done    chan struct{}
 	outType reflect.Type
-	inType  reflect.Type
+	// nil if the node can't be paused
+	pause *pauseGate
+	// nil if the node can't run in serial mode
+	serial func(item IN, emit func(OUT))
 }
 
-func (i *Middle[IN, OUT]) joiner() *connect.Joiner[IN] {
-	return &i.inputs
+func (s *Middle[IN, OUT]) SendsTo(outputs ...Receiver[OUT]) {
+	s.outs = connectTo(&s.nodeMeta, s.outs, outputs)
 }
 
-func (i *Middle[IN, OUT]) isStarted() bool {
-	return i.started
+// OnEnd registers a function that provides a final item (e.g. an end-of-stream marker), which is
+// sent to the output after the Middle function has returned, this is, after the input channel
+// has been closed and processed. It must be invoked before the node is started.
+func (m *Middle[IN, OUT]) OnEnd(trailer func() OUT) {
+	m.onEnd = trailer
 }
 
-func (s *Middle[IN, OUT]) SendsTo(outputs ...Receiver[OUT]) {
-	s.outs = append(s.outs, outputs...)
+// Done returns a channel that is closed when the Middle node has ended its processing. This is,
+// when its input has been closed and processed, and its output has been closed.
+func (m *Middle[IN, OUT]) Done() <-chan struct{} {
+	return m.done
 }
 
 func (m *Middle[IN, OUT]) OutType() reflect.Type {
 	return m.outType
 }
 
-func (m *Middle[IN, OUT]) InType() reflect.Type {
-	return m.inType
+// Kind returns KindMiddle
+func (m *Middle[IN, OUT]) Kind() NodeKind {
+	return KindMiddle
 }
 
-// Terminal is any node that receives data from another node and does not forward it to another node,
-// but can process it and send the results to outside the graph (e.g. memory, storage, web...)
-type Terminal[IN any] struct {
-	inputs  connect.Joiner[IN]
-	started bool
-	fun     TerminalFunc[IN]
-	done    chan struct{}
-	inType  reflect.Type
+// Schema returns the input and output types of the Middle node
+func (m *Middle[IN, OUT]) Schema() Schema {
+	return Schema{In: m.inType, Out: m.outType}
+}
+
+// Stats returns runtime information about the Middle node
+func (m *Middle[IN, OUT]) Stats() Stats {
+	stats := m.receiverBase.Stats()
+	m.senderStats(&stats)
+	return stats
 }
 
-func (i *Terminal[IN]) joiner() *connect.Joiner[IN] {
-	return &i.inputs
+func (m *Middle[IN, OUT]) outputs() []graphNode {
+	return receiversAsNodes(m.outs)
 }
 
-func (t *Terminal[IN]) isStarted() bool {
-	return t.started
+// Terminal is any node that receives data from another node and does not forward it to another node,
+// but can process it and send the results to outside the graph (e.g. memory, storage, web...)
+type Terminal[IN any] struct {
+	nodeMeta
+	receiverBase[IN]
+	fun   TerminalFuncCtx[IN]
+	onEnd func()
+	done  chan struct{}
+	// nil if the node can't run in serial mode
+	serial func(item IN)
+	// nil if the node does not detect canary items
+	canaries *canaryTracker
 }
 
 // Done returns a channel that is closed when the Terminal node has ended its processing. This
@@ -125,8 +243,26 @@ func (t *Terminal[IN]) Done() <-chan struct{} {
 	return t.done
 }
 
-func (m *Terminal[IN]) InType() reflect.Type {
-	return m.inType
+// OnEnd registers a function that is invoked after the Terminal function has returned, and before
+// the Done channel is closed. It allows performing final operations once all the input data has
+// been processed (e.g. writing a trailer into a network protocol). It must be invoked before the
+// node is started.
+func (t *Terminal[IN]) OnEnd(fun func()) {
+	t.onEnd = fun
+}
+
+// Kind returns KindTerminal
+func (t *Terminal[IN]) Kind() NodeKind {
+	return KindTerminal
+}
+
+// Schema returns the input type of the Terminal node
+func (t *Terminal[IN]) Schema() Schema {
+	return Schema{In: t.inType}
+}
+
+func (t *Terminal[IN]) outputs() []graphNode {
+	return nil
 }
 
 // AsStart wraps a StartFunc into a Start node.
@@ -136,44 +272,95 @@ func AsInit[OUT any](fun StartFunc[OUT]) *Start[OUT] {
 }
 
 // AsStart wraps a StartFunc into a Start node.
-func AsStart[OUT any](fun StartFunc[OUT]) *Start[OUT] {
+func AsStart[OUT any](fun StartFunc[OUT], opts ...Option) *Start[OUT] {
 	return AsStartCtx(func(_ context.Context, out chan<- OUT) {
 		fun(out)
-	})
+	}, opts...)
 }
 
 // AsStartCtx wraps a StartFuncCtx into a Start node.
-func AsStartCtx[OUT any](fun StartFuncCtx[OUT]) *Start[OUT] {
+func AsStartCtx[OUT any](fun StartFuncCtx[OUT], opts ...Option) *Start[OUT] {
 	var out OUT
-	return &Start[OUT]{
-		fun:     fun,
-		outType: reflect.TypeOf(out),
+	options := getOptions(opts...)
+	outType := reflect.TypeOf(out)
+	s := &Start[OUT]{
+		nodeMeta: newNodeMeta(&options, KindStart, Schema{Out: outType}),
+		fun:      fun,
+		done:     make(chan struct{}),
+		outType:  outType,
 	}
+	if options.canaries {
+		s.canaries = make(chan OUT)
+	}
+	return s
 }
 
 // AsMiddle wraps an MiddleFunc into an Middle node.
 func AsMiddle[IN, OUT any](fun MiddleFunc[IN, OUT], opts ...Option) *Middle[IN, OUT] {
-	var in IN
+	return AsMiddleCtx(func(_ context.Context, in <-chan IN, out chan<- OUT) {
+		fun(in, out)
+	}, opts...)
+}
+
+// AsMiddleCtx wraps a MiddleFuncCtx into a Middle node.
+func AsMiddleCtx[IN, OUT any](fun MiddleFuncCtx[IN, OUT], opts ...Option) *Middle[IN, OUT] {
 	var out OUT
 	options := getOptions(opts...)
-	return &Middle[IN, OUT]{
-		inputs:  connect.NewJoiner[IN](options.channelBufferLen),
-		fun:     fun,
-		inType:  reflect.TypeOf(in),
-		outType: reflect.TypeOf(out),
+	receiver := newReceiverBase[IN](&options)
+	outType := reflect.TypeOf(out)
+	m := &Middle[IN, OUT]{
+		nodeMeta:     newNodeMeta(&options, KindMiddle, Schema{In: receiver.inType, Out: outType}),
+		receiverBase: receiver,
+		fun:          fun,
+		done:         make(chan struct{}),
+		outType:      outType,
 	}
+	m.inputDone = m.receiverBase.inputClosed
+	return m
 }
 
 // AsTerminal wraps a TerminalFunc into a Terminal node.
+// The function is invoked only once, from a single goroutine, regardless of the number of nodes
+// that send data to the Terminal, and of whether they are started concurrently.
 func AsTerminal[IN any](fun TerminalFunc[IN], opts ...Option) *Terminal[IN] {
-	var i IN
+	return AsTerminalCtx(func(_ context.Context, in <-chan IN) {
+		fun(in)
+	}, opts...)
+}
+
+// AsTerminalCtx wraps a TerminalFuncCtx into a Terminal node.
+func AsTerminalCtx[IN any](fun TerminalFuncCtx[IN], opts ...Option) *Terminal[IN] {
 	options := getOptions(opts...)
-	return &Terminal[IN]{
-		inputs: connect.NewJoiner[IN](options.channelBufferLen),
-		fun:    fun,
-		done:   make(chan struct{}),
-		inType: reflect.TypeOf(i),
+	receiver := newReceiverBase[IN](&options)
+	t := &Terminal[IN]{
+		nodeMeta:     newNodeMeta(&options, KindTerminal, Schema{In: receiver.inType}),
+		receiverBase: receiver,
+		fun:          fun,
+		done:         make(chan struct{}),
+	}
+	t.inputDone = t.receiverBase.inputClosed
+	if options.canaries {
+		t.canaries = newCanaryTracker()
 	}
+	return t
+}
+
+// AsSink wraps a MiddleFunc into a Terminal node, for the Middle functions that are used only
+// for their side effects. The items that the function sends to its output are dropped.
+// This avoids having to connect a Middle node to a Terminal node that discards its output.
+func AsSink[IN, OUT any](fun MiddleFunc[IN, OUT], opts ...Option) *Terminal[IN] {
+	return AsTerminal(func(in <-chan IN) {
+		out := make(chan OUT)
+		drained := make(chan struct{})
+		go func() {
+			for range out {
+			}
+			close(drained)
+		}()
+		fun(in, out)
+		close(out)
+		<-drained
+	}, opts...)
 }
 
 // Start the function wrapped in the Start node. Either this method or StartCtx should be invoked
@@ -187,45 +374,91 @@ func (i *Start[OUT]) Start() {
 // for all the start nodes of the same graph, so the graph can properly start and finish.
 func (i *Start[OUT]) StartCtx(ctx context.Context) {
 	if len(i.outs) == 0 {
-		panic("Start node should have outputs")
+		panicNoOutputs(&i.nodeMeta)
 	}
-	joiners := make([]*connect.Joiner[OUT], 0, len(i.outs))
-	for _, out := range i.outs {
-		joiners = append(joiners, out.joiner())
-		if !out.isStarted() {
-			out.start()
-		}
+	// the Stop method only cancels the context of this node, not the context of the receivers
+	nodeCtx, cancel := context.WithCancel(ctx)
+	i.stopMt.Lock()
+	i.stop = cancel
+	if i.stopped {
+		cancel()
 	}
-	forker := connect.Fork(joiners...)
+	i.stopMt.Unlock()
+	forker := forkTo(ctx, &i.nodeMeta, i.outs)
+	stopCanaries := forwardCanaries(i.canaries, forker.Sender())
 	go func() {
-		i.fun(ctx, forker.Sender())
+		defer cancel()
+		i.notifyStart()
+		out, stopSignal := forker.Sender(), func() {}
+		if i.ready != nil {
+			out, stopSignal = signalFirstItem(forker.Sender(), i.ready)
+		}
+		if i.waitGate(nodeCtx) {
+			i.invoke(i, func() { i.fun(nodeCtx, out) })
+			if i.onEnd != nil {
+				out <- i.onEnd()
+			}
+		}
+		stopSignal()
+		stopCanaries()
 		forker.Close()
+		i.notifyFinish()
+		close(i.done)
 	}()
 }
 
-func (i *Middle[IN, OUT]) start() {
+// waitGate blocks until the gate is released, returning true, or the context is cancelled,
+// returning false
+func (i *Start[OUT]) waitGate(ctx context.Context) bool {
+	if i.gate == nil {
+		return true
+	}
+	select {
+	case <-i.gate:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (i *Middle[IN, OUT]) start(ctx context.Context) {
 	if len(i.outs) == 0 {
-		panic("Middle node should have outputs")
+		panicNoOutputs(&i.nodeMeta)
 	}
-	i.started = true
-	joiners := make([]*connect.Joiner[OUT], 0, len(i.outs))
-	for _, out := range i.outs {
-		joiners = append(joiners, out.joiner())
-		if !out.isStarted() {
-			out.start()
-		}
+	if !i.markStarted() {
+		return
 	}
-	forker := connect.Fork(joiners...)
+	forker := forkTo(ctx, &i.nodeMeta, i.outs)
 	go func() {
-		i.fun(i.inputs.Receiver(), forker.Sender())
+		i.notifyStart()
+		if !i.invoke(i, func() { i.fun(ctx, i.inputs.Receiver(), forker.Sender()) }) {
+			drain[IN](i.inputs.Receiver())
+		}
+		if i.onEnd != nil {
+			forker.Sender() <- i.onEnd()
+		}
 		forker.Close()
+		// the function may return before its input is closed (e.g. node.Take)
+		drain[IN](i.inputs.Receiver())
+		i.notifyFinish()
+		close(i.done)
 	}()
 }
 
-func (t *Terminal[IN]) start() {
-	t.started = true
+func (t *Terminal[IN]) start(ctx context.Context) {
+	if !t.markStarted() {
+		return
+	}
+	in := t.inputs.Receiver()
 	go func() {
-		t.fun(t.inputs.Receiver())
+		t.notifyStart()
+		if !t.invoke(t, func() { t.fun(ctx, in) }) {
+			drain[IN](in)
+		}
+		if t.onEnd != nil {
+			t.onEnd()
+		}
+		t.notifyFinish()
 		close(t.done)
 	}()
 }
@@ -235,5 +468,6 @@ func getOptions(opts ...Option) creationOptions {
 	for _, opt := range opts {
 		opt(&options)
 	}
+	options.checkBufferOptions()
 	return options
 }
@@ -247,6 +247,238 @@ func TestContexts(t *testing.T) {
 	}
 }
 
+func TestFanIn_SendersFinishingAtDifferentTimes(t *testing.T) {
+	start1 := AsStart(Counter(1, 3))
+	start2 := AsStart(Counter(4, 6))
+	double := AsMiddle(func(in <-chan int, out chan<- int) {
+		for n := range in {
+			out <- n * 2
+		}
+	})
+	var received []int
+	term := collectInts(&received)
+	start1.SendsTo(double)
+	start2.SendsTo(double)
+	double.SendsTo(term)
+
+	start1.Start()
+	waitDone(t, start1.Done())
+	// the input of the middle node is not closed until the second start node finishes
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, isClosed(double.Done()))
+
+	start2.Start()
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{2, 4, 6, 8, 10, 12}, received)
+}
+
+func TestFanIn_SingleGoroutineTerminal(t *testing.T) {
+	const senders = 20
+	var starts []*Start[int]
+	// the collector mutates a map without synchronization, which is safe as the terminal
+	// function runs in a single goroutine. Run with -race to verify it.
+	counts := map[int]int{}
+	collector := AsTerminal(func(in <-chan int) {
+		for n := range in {
+			counts[n]++
+		}
+	})
+	for s := 0; s < senders; s++ {
+		start := AsStart(Counter(1, 100))
+		passThrough := AsMiddle(func(in <-chan int, out chan<- int) {
+			for n := range in {
+				out <- n
+			}
+		})
+		start.SendsTo(passThrough)
+		passThrough.SendsTo(collector)
+		starts = append(starts, start)
+	}
+	ready := make(chan struct{})
+	for _, start := range starts {
+		go func(s *Start[int]) {
+			<-ready
+			s.Start()
+		}(start)
+	}
+	close(ready)
+
+	waitDone(t, collector.Done())
+	require.Len(t, counts, 100)
+	for n, c := range counts {
+		assert.Equal(t, senders, c, "count of %d", n)
+	}
+}
+
+func TestStart_Stop(t *testing.T) {
+	endless := func(from int) StartFuncCtx[int] {
+		return func(ctx context.Context, out chan<- int) {
+			for n := from; ; n++ {
+				select {
+				case <-ctx.Done():
+					return
+				case out <- n:
+				}
+			}
+		}
+	}
+	start1 := AsStartCtx(endless(0))
+	start2 := AsStartCtx(endless(1000))
+	stoppedBefore := AsStartCtx(endless(2000))
+	stoppedBefore.Stop()
+	merge := AsMiddle(func(in <-chan int, out chan<- int) {
+		for n := range in {
+			out <- n
+		}
+	})
+	probe := merge.Probe()
+	start1.SendsTo(merge)
+	start2.SendsTo(merge)
+	stoppedBefore.SendsTo(merge)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start1.StartCtx(ctx)
+	start2.StartCtx(ctx)
+	stoppedBefore.StartCtx(ctx)
+	waitDone(t, stoppedBefore.Done())
+
+	start1.Stop()
+	waitDone(t, start1.Done())
+	// the other source keeps sending, and the graph context is not cancelled
+	assert.NoError(t, ctx.Err())
+	for {
+		n, ok := probe.Next(timeout)
+		require.True(t, ok)
+		if n >= 1000 {
+			break
+		}
+	}
+	_, err := probe.Expect(10, timeout)
+	require.NoError(t, err)
+
+	start2.Stop()
+	for _, ok := probe.Next(timeout); ok; _, ok = probe.Next(timeout) {
+	}
+	waitDone(t, merge.Done())
+}
+
+func TestSendsTo_DuplicateReceivers(t *testing.T) {
+	term := AsTerminal(func(in <-chan int) {}, WithName("term"))
+	start := AsStart(Counter(1, 3), WithName("start"))
+	assert.PanicsWithValue(t, "node start is already connected to node term. Use the DedupeReceivers"+
+		" option to ignore duplicate connections", func() {
+		start.SendsTo(term, term)
+	})
+	middle := AsMiddle(OddFilter)
+	middle.SendsTo(term)
+	assert.Panics(t, func() {
+		middle.SendsTo(term)
+	})
+}
+
+func TestSendsTo_NoReceivers(t *testing.T) {
+	var receivers []Receiver[int]
+	start := AsStart(Counter(1, 3), WithName("start"))
+	// invoking SendsTo without receivers is allowed, but the node can't start without outputs
+	start.SendsTo(receivers...)
+	assert.PanicsWithValue(t, "node start should have outputs. Check that its SendsTo method is"+
+		" invoked with at least one receiver before starting it", start.Start)
+
+	start = AsStart(Counter(1, 3))
+	odds := AsMiddle(OddFilter, WithName("odds"))
+	start.SendsTo(odds)
+	odds.SendsTo(receivers...)
+	assert.PanicsWithValue(t, "node odds should have outputs. Check that its SendsTo method is"+
+		" invoked with at least one receiver before starting it", start.Start)
+}
+
+func TestSendsTo_DedupeReceivers(t *testing.T) {
+	start := AsStart(Counter(1, 3), DedupeReceivers())
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(term, term)
+	start.SendsTo(term)
+	assert.Len(t, start.outputs(), 1)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{1, 2, 3}, received)
+}
+
+func TestSendsTo_MultipleInvocations(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	odds := AsMiddle(OddFilter)
+	evens := AsMiddle(EvenFilter)
+	var all, oddNums, evenNums []int
+	allTerm := collectInts(&all)
+	oddsTerm := collectInts(&oddNums)
+	evensTerm := collectInts(&evenNums)
+	// the receivers are discovered and connected incrementally
+	start.SendsTo(odds)
+	odds.SendsTo(oddsTerm)
+	start.SendsTo(evens, allTerm)
+	evens.SendsTo(evensTerm)
+	start.Start()
+
+	waitDone(t, allTerm.Done())
+	waitDone(t, oddsTerm.Done())
+	waitDone(t, evensTerm.Done())
+	assert.Equal(t, []int{1, 2, 3}, all)
+	assert.Equal(t, []int{1, 3}, oddNums)
+	assert.Equal(t, []int{2}, evenNums)
+}
+
+func TestSendsTo_AfterStart(t *testing.T) {
+	release := make(chan struct{})
+	start := AsStart(func(out chan<- int) {
+		<-release
+	}, WithName("start"))
+	middle := AsMiddle(OddFilter, WithName("middle"))
+	term := AsTerminal(func(in <-chan int) {})
+	start.SendsTo(middle)
+	middle.SendsTo(term)
+	start.Start()
+
+	assert.PanicsWithValue(t, "node start is already started. Its receivers must be connected"+
+		" before starting it", func() {
+		start.SendsTo(AsTerminal(func(in <-chan int) {}))
+	})
+	// the receivers of the started node can't be modified either
+	assert.Panics(t, func() {
+		middle.SendsTo(AsTerminal(func(in <-chan int) {}))
+	})
+	close(release)
+	waitDone(t, term.Done())
+}
+
+func TestAsSink(t *testing.T) {
+	var seen []int
+	start := AsStart(Counter(1, 5))
+	sink := AsSink(func(in <-chan int, out chan<- string) {
+		for n := range in {
+			seen = append(seen, n)
+			out <- fmt.Sprint(n)
+		}
+	})
+	start.SendsTo(sink)
+	start.Start()
+
+	waitDone(t, sink.Done())
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, seen)
+	assert.Equal(t, KindTerminal, sink.Kind())
+}
+
+// waitDone waits for the provided channel to be closed, failing the test after a timeout
+func waitDone(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	// ok!
+	case <-time.After(timeout):
+		require.Fail(t, "timeout while waiting for pipeline to complete")
+	}
+}
+
 func Counter(from, to int) func(out chan<- int) {
 	return func(out chan<- int) {
 		for i := from; i <= to; i++ {
@@ -0,0 +1,137 @@
+package nodetest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/internal/timing"
+)
+
+// ManualClock is a node.Clock whose time only changes when it is advanced by the test, so the
+// nodes that depend on time (e.g. node.SampleLatest or node.RateLimit) behave deterministically
+// when they are created with the node.WithClock option. It is safe for concurrent use.
+type ManualClock struct {
+	mt     sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+// NewManualClock returns a ManualClock whose current time is the Unix epoch, in UTC
+func NewManualClock() *ManualClock {
+	return &ManualClock{now: time.Unix(0, 0).UTC()}
+}
+
+// Now returns the current time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.mt.Lock()
+	defer c.mt.Unlock()
+	return c.now
+}
+
+// NewTimer creates a Timer that expires when the clock is advanced by the given duration
+func (c *ManualClock) NewTimer(d time.Duration) timing.Timer {
+	c.mt.Lock()
+	defer c.mt.Unlock()
+	t := &manualTimer{clock: c, ch: make(chan time.Time, 1)}
+	c.schedule(t, d)
+	return t
+}
+
+// Advance moves the time of the clock forward by the given duration. The timers that expire
+// meanwhile are fired synchronously, in the order of their expiration, with the clock set to
+// their expiration time. When Advance returns, their expiration time has been sent to their
+// channel, but the nodes may not have processed it yet.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mt.Lock()
+	defer c.mt.Unlock()
+	until := c.now.Add(d)
+	for {
+		next := c.nextExpired(until)
+		if next < 0 {
+			break
+		}
+		t := c.timers[next]
+		c.timers = append(c.timers[:next], c.timers[next+1:]...)
+		c.now = t.when
+		t.fire()
+	}
+	c.now = until
+}
+
+// Timers returns the number of active timers: this is, the timers that have been created or
+// reset, and have not expired nor been stopped yet. It allows waiting for a node to schedule
+// its timers before advancing the clock.
+func (c *ManualClock) Timers() int {
+	c.mt.Lock()
+	defer c.mt.Unlock()
+	return len(c.timers)
+}
+
+// nextExpired returns the index of the active timer that expires first, if it expires before or
+// at the given time. Otherwise, it returns -1. The timers that expire at the same time are
+// returned in the order in which they were scheduled.
+func (c *ManualClock) nextExpired(until time.Time) int {
+	next := -1
+	for i, t := range c.timers {
+		if !t.when.After(until) && (next < 0 || t.when.Before(c.timers[next].when)) {
+			next = i
+		}
+	}
+	return next
+}
+
+// schedule activates the timer to expire after the given duration, or fires it immediately if
+// the duration is not positive. It must be invoked with the clock locked.
+func (c *ManualClock) schedule(t *manualTimer, d time.Duration) {
+	t.when = c.now.Add(d)
+	if d <= 0 {
+		t.fire()
+		return
+	}
+	c.timers = append(c.timers, t)
+}
+
+// unschedule deactivates the timer, returning whether it was active. It must be invoked with
+// the clock locked.
+func (c *ManualClock) unschedule(t *manualTimer) bool {
+	for i, active := range c.timers {
+		if active == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type manualTimer struct {
+	clock *ManualClock
+	ch    chan time.Time
+	when  time.Time
+}
+
+func (t *manualTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *manualTimer) Stop() bool {
+	t.clock.mt.Lock()
+	defer t.clock.mt.Unlock()
+	return t.clock.unschedule(t)
+}
+
+func (t *manualTimer) Reset(d time.Duration) bool {
+	t.clock.mt.Lock()
+	defer t.clock.mt.Unlock()
+	active := t.clock.unschedule(t)
+	t.clock.schedule(t, d)
+	return active
+}
+
+// fire sends the expiration time to the timer channel. As with time.Timer, the value is
+// discarded if the channel has not been drained since the previous expiration.
+func (t *manualTimer) fire() {
+	select {
+	case t.ch <- t.when:
+	default:
+	}
+}
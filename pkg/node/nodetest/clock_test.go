@@ -0,0 +1,84 @@
+package nodetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ node.Clock = (*ManualClock)(nil)
+
+func TestManualClock(t *testing.T) {
+	clock := NewManualClock()
+	begin := clock.Now()
+	first := clock.NewTimer(2 * time.Second)
+	second := clock.NewTimer(time.Second)
+	stopped := clock.NewTimer(time.Second)
+	assert.Equal(t, 3, clock.Timers())
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, begin.Add(500*time.Millisecond), clock.Now())
+	ExpectBlocked(t, first.C())
+	ExpectBlocked(t, second.C())
+
+	// the timers receive their expiration time
+	clock.Advance(2 * time.Second)
+	assert.Equal(t, begin.Add(time.Second), <-second.C())
+	assert.Equal(t, begin.Add(2*time.Second), <-first.C())
+	ExpectBlocked(t, stopped.C())
+	assert.Equal(t, begin.Add(2500*time.Millisecond), clock.Now())
+	assert.Zero(t, clock.Timers())
+
+	assert.False(t, first.Reset(time.Second))
+	assert.True(t, first.Reset(2*time.Second))
+	clock.Advance(time.Second)
+	ExpectBlocked(t, first.C())
+	clock.Advance(time.Second)
+	assert.Equal(t, begin.Add(4500*time.Millisecond), <-first.C())
+
+	// non-positive durations expire immediately
+	assert.Equal(t, clock.Now(), <-clock.NewTimer(0).C())
+}
+
+func TestManualClock_SampleLatest(t *testing.T) {
+	clock := NewManualClock()
+	updates := make(chan int)
+	// the input of the node is unbuffered, so each item has been received by the node when it
+	// is acknowledged
+	acks := make(chan struct{})
+	start := node.AsStart(func(out chan<- int) {
+		for n := range updates {
+			out <- n
+			acks <- struct{}{}
+		}
+	})
+	sample := node.SampleLatest[int](time.Second, node.WithClock(clock))
+	probe := sample.Probe()
+	start.SendsTo(sample)
+	start.Start()
+	require.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+
+	for n := 1; n <= 3; n++ {
+		updates <- n
+		<-acks
+	}
+	_, ok := probe.Next(10 * time.Millisecond)
+	assert.False(t, ok)
+	clock.Advance(time.Second)
+	items, err := probe.Expect(1, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, items)
+
+	// the ticks without updates send nothing
+	require.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+	clock.Advance(time.Second)
+	require.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+	close(updates)
+	_, ok = probe.Next(timeout)
+	assert.False(t, ok)
+}
@@ -0,0 +1,65 @@
+// Package nodetest provides utilities for testing the pipelines built with the node package.
+package nodetest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// blockedWait is the time that ExpectBlocked waits before considering that a channel is blocked
+const blockedWait = 10 * time.Millisecond
+
+// ExpectBlocked fails the test if the provided channel is not blocked: this is, if it is
+// closed or it has any value that can be received. This allows, for example, verifying that a
+// node is still waiting for being able to forward its data.
+// It waits a few milliseconds before considering that the channel is blocked. If the channel
+// is not blocked, the value that has been received is lost.
+func ExpectBlocked[T any](t testing.TB, ch <-chan T) {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if ok {
+			t.Fatalf("expected channel to be blocked. Received: %v", v)
+		} else {
+			t.Fatalf("expected channel to be blocked, but it is closed")
+		}
+	case <-time.After(blockedWait):
+		// ok!
+	}
+}
+
+// ExpectReceives fails the test if the provided channel does not receive the expected value
+// before the given timeout, or if the channel is closed.
+func ExpectReceives[T any](t testing.TB, ch <-chan T, want T, timeout time.Duration) {
+	t.Helper()
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatalf("expected to receive %v, but the channel is closed", want)
+		} else if !reflect.DeepEqual(want, v) {
+			t.Fatalf("expected to receive %v. Received: %v", want, v)
+		}
+	case <-time.After(timeout):
+		t.Fatalf("timeout while waiting to receive %v", want)
+	}
+}
+
+// ExpectClosed fails the test if the provided channel is not closed before the given timeout.
+// Any value received before the channel is closed is discarded. It is useful to wait for
+// signaling channels like the one returned by the node.Terminal Done method.
+func ExpectClosed[T any](t testing.TB, ch <-chan T, timeout time.Duration) {
+	t.Helper()
+	deadline := time.After(timeout)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("timeout while waiting for the channel to be closed")
+			return
+		}
+	}
+}
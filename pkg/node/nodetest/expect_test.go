@@ -0,0 +1,96 @@
+package nodetest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node"
+	"github.com/stretchr/testify/assert"
+)
+
+const timeout = 2 * time.Second
+
+// recorder is a testing.TB that records the failures instead of failing the test
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestExpectBlocked(t *testing.T) {
+	r := &recorder{}
+	ExpectBlocked(r, make(chan int))
+	assert.Empty(t, r.failures)
+
+	full := make(chan int, 1)
+	full <- 1
+	ExpectBlocked(r, full)
+	assert.Equal(t, []string{"expected channel to be blocked. Received: 1"}, r.failures)
+
+	closed := make(chan int)
+	close(closed)
+	r.failures = nil
+	ExpectBlocked(r, closed)
+	assert.Equal(t, []string{"expected channel to be blocked, but it is closed"}, r.failures)
+}
+
+func TestExpectReceives(t *testing.T) {
+	r := &recorder{}
+	ch := make(chan []string, 2)
+	ch <- []string{"a", "b"}
+	ch <- []string{"c"}
+	ExpectReceives(r, ch, []string{"a", "b"}, timeout)
+	assert.Empty(t, r.failures)
+	ExpectReceives(r, ch, []string{"d"}, timeout)
+	assert.Equal(t, []string{"expected to receive [d]. Received: [c]"}, r.failures)
+
+	r.failures = nil
+	ExpectReceives(r, ch, []string{"e"}, 10*time.Millisecond)
+	assert.Equal(t, []string{"timeout while waiting to receive [e]"}, r.failures)
+
+	r.failures = nil
+	close(ch)
+	ExpectReceives(r, ch, []string{"f"}, timeout)
+	assert.Equal(t, []string{"expected to receive [f], but the channel is closed"}, r.failures)
+}
+
+func TestExpectClosed(t *testing.T) {
+	r := &recorder{}
+	ExpectClosed(r, make(chan struct{}), 10*time.Millisecond)
+	assert.Equal(t, []string{"timeout while waiting for the channel to be closed"}, r.failures)
+
+	r.failures = nil
+	ch := make(chan int, 1)
+	ch <- 1
+	close(ch)
+	ExpectClosed(r, ch, timeout)
+	assert.Empty(t, r.failures)
+}
+
+func TestPipelineBlocking(t *testing.T) {
+	graphIn, graphOut := make(chan int), make(chan int)
+	endStart := make(chan struct{})
+	start := node.AsStart(func(out chan<- int) {
+		out <- <-graphIn
+		close(endStart)
+	})
+	term := node.AsTerminal(func(in <-chan int) {
+		graphOut <- <-in
+	}, node.ChannelBufferLen(1))
+	start.SendsTo(term)
+	start.Start()
+
+	graphIn <- 123
+	// the terminal input is buffered, so the start node can finish before the terminal
+	// forwards the data
+	ExpectClosed(t, endStart, timeout)
+	ExpectBlocked(t, term.Done())
+	ExpectReceives(t, graphOut, 123, timeout)
+	ExpectClosed(t, term.Done(), timeout)
+}
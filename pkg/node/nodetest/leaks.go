@@ -0,0 +1,90 @@
+package nodetest
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakTimeout is the time that AssertNoLeaks waits for the goroutines to finish
+const leakTimeout = time.Second
+
+// nodeFrame matches the stack frames of the functions of the node package and its internal
+// packages, whose goroutines are checked by AssertNoLeaks
+var nodeFrame = regexp.MustCompile(
+	`(?m)^(created by )?github\.com/netobserv/gopipes/pkg/node(/internal/[^.]+)?\.`)
+
+// AssertNoLeaks fails the test if the provided function leaves running goroutines after it
+// returns. This allows, for example, detecting the nodes that never close their output, leaving
+// the goroutines of the nodes that receive data from them blocked.
+// The function should wait for the graph to finish (e.g. by waiting for the Done channel of its
+// Terminal nodes). Afterwards, AssertNoLeaks waits a short time for the rest of goroutines to
+// return, before reporting the stack traces of the leaked goroutines.
+// Only the goroutines that have been created during the function execution, and that run code
+// of the node package, are considered leaked, so the goroutines of the runtime, the testing
+// framework or other libraries are ignored. The goroutines of the nodes of other tests that run
+// in parallel may still be reported.
+func AssertNoLeaks(t testing.TB, fun func()) {
+	t.Helper()
+	before := map[string]struct{}{}
+	for id := range goroutineStacks() {
+		before[id] = struct{}{}
+	}
+	fun()
+	deadline := time.Now().Add(leakTimeout)
+	for {
+		leaked := leakedStacks(before)
+		if len(leaked) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines leaked:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// leakedStacks returns, sorted, the stacks of the goroutines that are not in the before set and
+// run code of the node package
+func leakedStacks(before map[string]struct{}) []string {
+	var leaked []string
+	for id, stack := range goroutineStacks() {
+		if _, ok := before[id]; ok {
+			continue
+		}
+		if nodeFrame.MatchString(stack) {
+			leaked = append(leaked, stack)
+		}
+	}
+	sort.Strings(leaked)
+	return leaked
+}
+
+// goroutineStacks returns the stack traces of all the running goroutines, indexed by their ID
+func goroutineStacks() map[string]string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	stacks := map[string]string{}
+	for _, stack := range bytes.Split(buf, []byte("\n\n")) {
+		// the header of each stack is "goroutine <id> [<state>]:"
+		var id string
+		if _, err := fmt.Sscanf(string(stack), "goroutine %s", &id); err != nil {
+			continue
+		}
+		stacks[id] = string(stack)
+	}
+	return stacks
+}
@@ -0,0 +1,55 @@
+package nodetest
+
+import (
+	"testing"
+
+	"github.com/netobserv/gopipes/pkg/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertNoLeaks(t *testing.T) {
+	r := &recorder{}
+	AssertNoLeaks(r, func() {
+		start := node.AsStart(func(out chan<- int) {
+			out <- 1
+		})
+		term := node.AsTerminal(func(in <-chan int) {
+			for range in {
+			}
+		})
+		start.SendsTo(term)
+		start.Start()
+		ExpectClosed(r, term.Done(), timeout)
+	})
+	assert.Empty(t, r.failures)
+
+	release := make(chan struct{})
+	AssertNoLeaks(r, func() {
+		start := node.AsStart(func(out chan<- int) {
+			// never returns, so the output is never closed
+			<-release
+		})
+		term := node.AsTerminal(func(in <-chan int) {
+			for range in {
+			}
+		})
+		start.SendsTo(term)
+		start.Start()
+	})
+	close(release)
+	require.Len(t, r.failures, 1)
+	assert.Contains(t, r.failures[0], "goroutines leaked")
+	assert.Contains(t, r.failures[0], "node.(*Start[...]).StartCtx")
+}
+
+func TestAssertNoLeaks_IgnoresOtherGoroutines(t *testing.T) {
+	r := &recorder{}
+	release := make(chan struct{})
+	defer close(release)
+	AssertNoLeaks(r, func() {
+		// a goroutine that does not belong to any node
+		go func() { <-release }()
+	})
+	assert.Empty(t, r.failures)
+}
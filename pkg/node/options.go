@@ -1,12 +1,79 @@
 package node
 
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/internal/connect"
+)
+
 type creationOptions struct {
+	// name of the node. If empty, it is derived from the node kind and types
+	name string
 	// if 0, channel is unbuffered
 	channelBufferLen int
+	// whether channelBufferLen has been explicitly set
+	channelBufferLenSet bool
+	// if true, the length of the input buffer can be changed while the node runs
+	resizableBuffer bool
+	// if not nil, the input buffer is bounded by the size of the items instead of by their number
+	byteBuffer *byteBuffer
+	// if not nil, the input buffer is provided by a custom Channel implementation
+	channel *customChannel
+	// if not nil, the input buffer is bounded by a memory budget shared with other nodes
+	memoryBudget *MemoryBudget
+	// seed for the nodes that make use of random numbers. If nil, a time-based seed is used
+	randSeed *int64
+	// if not nil, source of random numbers, which takes precedence over randSeed
+	randSource rand.Source
+	// source of time for the nodes that depend on it
+	clock Clock
+	// whether the windowing nodes send the last incomplete window when their input is closed
+	flushPartialWindow bool
+	// whether the replaying nodes reproduce the recorded time between items
+	preserveTiming bool
+	// if not nil, the panics of the node function are recovered and passed to this function
+	panicHandler func(NodePanic)
+	// if not nil, invoked when the node starts and finishes its processing
+	onStart  func()
+	onFinish func()
+	// if not nil, it is invoked by the nodes that complete the graph before its input is closed
+	cancel context.CancelFunc
+	// if > 0, maximum number of concurrent tasks of the nodes that spawn them
+	maxConcurrency int
+	// if true, connecting a node to an already connected receiver is ignored instead of panicking
+	dedupeReceivers bool
+	// key/value metadata of the node
+	labels map[string]string
+	// if true, the node counts the items that it sends to each receiver
+	countEdges bool
+	// if > 0, maximum time that a node forwards its pending items after its function returns
+	closeTimeout time.Duration
+	// if not nil, bounds the buffer of the Reorder nodes
+	reorderBuffer *ReorderBuffer
+	// if true, the Start nodes accept canary items, and the Terminal nodes detect them
+	canaries bool
+	// delays between the retries of the nodes that retry failed operations
+	backoff BackoffPolicy
+	// if not nil, the node measures the time that the items wait in its input buffer
+	queueWait *LatencyHistogram
+	// if not nil, invoked for each item that the node forwards to multiple receivers, or that a
+	// routing node discards without forwarding it (with 0 receivers)
+	onFork func(item any, receivers int)
+	// if not nil, the finite Start nodes report their progress to it
+	progress *SourceProgress
+	// if not nil, serializes the items of the nodes that send them outside the process
+	codec *nodeCodec
 }
 
 var defaultOptions = creationOptions{
 	channelBufferLen: 0,
+	clock:            systemClock{},
+	backoff:          &exponentialBackoff{initial: 50 * time.Millisecond, max: time.Second, factor: 2},
 }
 
 // Option allows overriding the default values of node instantiation
@@ -15,8 +82,393 @@ type Option func(options *creationOptions)
 // ChannelBufferLen is a node.Option that allows specifying the length of the input
 // channels for a given node. The default value is 0, which means that the channels
 // are unbuffered.
+// It can't be used together with the node.WithChannel, node.WithByteBuffer or
+// node.WithMemoryBudget options. Otherwise, the node creation panics.
 func ChannelBufferLen(length int) Option {
 	return func(options *creationOptions) {
 		options.channelBufferLen = length
+		options.channelBufferLenSet = true
+	}
+}
+
+// byteBuffer configures an input buffer that is bounded by the size of the queued items
+type byteBuffer struct {
+	maxBytes int
+	itemType reflect.Type
+	// sizeOf is a func(T) int, where T is itemType
+	sizeOf any
+}
+
+// ResizableBuffer is a node.Option that allows changing the length of the input buffer of a node
+// while it is running, through its SetBufferLen method (e.g. from an autotuner that responds to
+// the measured backpressure). The initial length is provided by the node.ChannelBufferLen option.
+// As the Go channels can't be resized, the items are queued between two unbuffered channels,
+// which adds some per-item overhead.
+// It can't be used together with the node.WithChannel, node.WithByteBuffer,
+// node.WithMemoryBudget or node.MeasureQueueWait options. Otherwise, the node creation panics.
+func ResizableBuffer() Option {
+	return func(options *creationOptions) {
+		options.resizableBuffer = true
+	}
+}
+
+// WithByteBuffer is a node.Option that bounds the input buffer of a node by the accumulated size of
+// the queued items, as estimated by the sizeOf function, instead of by the number of items. This
+// provides a predictable memory usage regardless of the variance in the size of the items. When
+// the buffer is full, the senders are blocked until the node processes some of the queued items.
+// The queued items can exceed maxBytes by at most one item.
+// The type T must be the input type of the node, and it can't be used together with other
+// options that define the input buffer. Otherwise, the node creation panics.
+func WithByteBuffer[T any](maxBytes int, sizeOf func(T) int) Option {
+	return func(options *creationOptions) {
+		options.byteBuffer = &byteBuffer{
+			maxBytes: maxBytes,
+			itemType: reflect.TypeOf((*T)(nil)).Elem(),
+			sizeOf:   sizeOf,
+		}
+	}
+}
+
+// WithCodec is a node.Option that provides the Codec that serializes the items of the nodes that
+// send them outside the process (e.g. node.NetworkSink and node.NetworkSource), when they are not
+// provided with explicit encoding functions. The default is node.GobCodec.
+// The type T must be the item type of the node. Otherwise, the node creation panics.
+func WithCodec[T any](codec Codec[T]) Option {
+	return func(options *creationOptions) {
+		options.codec = &nodeCodec{
+			itemType: reflect.TypeOf((*T)(nil)).Elem(),
+			codec:    codec,
+		}
+	}
+}
+
+// WithMemoryBudget is a node.Option that bounds the input buffer of a node by a MemoryBudget,
+// which can be shared by multiple nodes (e.g. all the nodes of a graph), so the total memory of
+// their buffered items can't exceed the budget regardless of which node is backed up. When the
+// budget is exhausted, the senders of the nodes are blocked until some buffered items are
+// processed. See node.NewMemoryBudget.
+// It can't be used together with the node.WithChannel, node.WithByteBuffer or
+// node.MeasureQueueWait options. Otherwise, the node creation panics.
+func WithMemoryBudget(budget *MemoryBudget) Option {
+	return func(options *creationOptions) {
+		options.memoryBudget = budget
+	}
+}
+
+// customChannel configures an input buffer that is provided by a custom Channel
+type customChannel struct {
+	itemType reflect.Type
+	// newChannel is a func() Channel[T], where T is itemType
+	newChannel any
+}
+
+// WithChannel is a node.Option that replaces the input buffer of a node by the Channel that is
+// returned by the provided function. This allows plugging custom buffering strategies.
+// The type T must be the input type of the node, and it can't be used together with other
+// options that define the input buffer. Otherwise, the node creation panics.
+func WithChannel[T any](newChannel func() Channel[T]) Option {
+	return func(options *creationOptions) {
+		options.channel = &customChannel{
+			itemType:   reflect.TypeOf((*T)(nil)).Elem(),
+			newChannel: newChannel,
+		}
+	}
+}
+
+// WithName is a node.Option that allows specifying the name of a node, which is used to
+// identify it in the graph validation errors and inspection tools.
+func WithName(name string) Option {
+	return func(options *creationOptions) {
+		options.name = name
+	}
+}
+
+// WithLabels is a node.Option that attaches key/value labels to a node (e.g. team="net"), which
+// allow identifying and filtering the nodes of large graphs in inspection and metrics tools.
+// Multiple WithLabels options are merged.
+func WithLabels(labels map[string]string) Option {
+	return func(options *creationOptions) {
+		if options.labels == nil {
+			options.labels = make(map[string]string, len(labels))
+		}
+		for k, v := range labels {
+			options.labels[k] = v
+		}
+	}
+}
+
+// RandSeed is a node.Option that allows specifying the seed of the random number generator
+// used by some nodes (e.g. node.Reservoir), so their behavior is deterministic. By default,
+// the seed is based on the node creation time.
+func RandSeed(seed int64) Option {
+	return func(options *creationOptions) {
+		options.randSeed = &seed
+	}
+}
+
+// WithRandSource is a node.Option that allows providing the source of random numbers used by some
+// nodes (e.g. node.Reservoir) and by the jittered node.NewExponentialBackoff, so their behavior is
+// reproducible. It takes precedence over the node.RandSeed option. As the sources of the math/rand
+// package are not safe for concurrent use, each node should be provided with its own source.
+// By default, each node uses its own source seeded with its creation time.
+func WithRandSource(source rand.Source) Option {
+	return func(options *creationOptions) {
+		options.randSource = source
+	}
+}
+
+// WithClock is a node.Option that allows overriding the source of time for the nodes whose
+// behavior depends on it (e.g. node.ExpireBy). By default, the system clock is used.
+func WithClock(clock Clock) Option {
+	return func(options *creationOptions) {
+		options.clock = clock
+	}
+}
+
+// MeasureQueueWait is a node.Option that makes a node measure the time that each item waits in
+// its input buffer, from it is sent until the node picks it up, which is a direct indicator of
+// backpressure. The measures are accumulated in a histogram with the provided bucket bounds (see
+// node.NewLatencyHistogram), which is reported in the QueueWait field of the node Stats.
+// It applies to the buffers created with node.ChannelBufferLen, and panics if the node is
+// created with the node.WithChannel, node.WithByteBuffer or node.WithMemoryBudget options.
+// The node.WithClock option allows overriding the source of time.
+func MeasureQueueWait(bounds ...time.Duration) Option {
+	return func(options *creationOptions) {
+		options.queueWait = NewLatencyHistogram(bounds...)
+	}
+}
+
+// WithItemPool is a node.Option that makes a node add a reference to the items of the provided
+// pool that it forwards to multiple receivers, so each receiver owns a reference and can release
+// it independently. The routing nodes (e.g. node.TopicExchange) also release the pooled items
+// that they do not forward to any receiver. See node.Pool for the ownership contract of the
+// pooled items.
+// The node.Broadcaster panics if it is created with this option, as its subscribers are outside
+// the graph and its history retains the items.
+func WithItemPool[T any](pool *Pool[T]) Option {
+	return func(options *creationOptions) {
+		options.onFork = func(item any, receivers int) {
+			p, ok := item.(*Pooled[T])
+			if !ok || p.pool != pool {
+				return
+			}
+			if receivers == 0 {
+				p.Release()
+			} else {
+				p.Retain(receivers - 1)
+			}
+		}
+	}
+}
+
+// ReportProgress is a node.Option that makes the finite Start nodes (node.Range, node.Generate
+// and node.FromSlice) report the number of sent items, and their total, to the provided
+// SourceProgress. Other nodes ignore it, so the total of their progress stays unknown.
+func ReportProgress(progress *SourceProgress) Option {
+	return func(options *creationOptions) {
+		options.progress = progress
+	}
+}
+
+// PreserveTiming is a node.Option that makes node.ReplayFrom reproduce the time between the
+// recorded items, instead of sending them as fast as possible.
+func PreserveTiming() Option {
+	return func(options *creationOptions) {
+		options.preserveTiming = true
+	}
+}
+
+// FlushPartialWindow is a node.Option that makes the windowing nodes (e.g. node.CountWindow)
+// send a last window with the items that have not been part of any window when their input
+// is closed. By default, those items are discarded.
+func FlushPartialWindow() Option {
+	return func(options *creationOptions) {
+		options.flushPartialWindow = true
+	}
+}
+
+// WithPanicHandler is a node.Option that recovers the panics of the node function, passing them to
+// the provided handler together with information about the node. After a panic, the node behaves
+// as if its function had returned: its remaining input is discarded, so the previous nodes are not
+// blocked, and its output is closed.
+// By default, the panics are not recovered, so they crash the program.
+func WithPanicHandler(handler func(NodePanic)) Option {
+	return func(options *creationOptions) {
+		options.panicHandler = handler
+	}
+}
+
+// WithOnStart is a node.Option that provides a function that is invoked once, from the goroutine
+// of the node, when the node starts its processing (e.g. to set up the resources of the node).
+func WithOnStart(hook func()) Option {
+	return func(options *creationOptions) {
+		options.onStart = hook
+	}
+}
+
+// WithOnFinish is a node.Option that provides a function that is invoked once, from the goroutine
+// of the node, when the node finishes its processing: after its input has been drained and its
+// outputs have been closed, just before its Done channel is closed (e.g. to release the
+// resources of the node). It is also invoked if the node function panics and the panic is
+// recovered by the node.WithPanicHandler option.
+func WithOnFinish(hook func()) Option {
+	return func(options *creationOptions) {
+		options.onFinish = hook
+	}
+}
+
+// WithCancel is a node.Option that provides the cancel function of the context passed to the
+// graph, so the nodes that complete the graph before their input is closed (e.g. node.Take)
+// can stop the Start nodes.
+func WithCancel(cancel context.CancelFunc) Option {
+	return func(options *creationOptions) {
+		options.cancel = cancel
+	}
+}
+
+// WithMaxConcurrency is a node.Option that bounds the number of concurrent tasks of the nodes
+// that spawn them (e.g. the number of channels that node.MergeAll drains concurrently).
+// By default, or if max is 0 or lower, the number of concurrent tasks is unbounded.
+func WithMaxConcurrency(max int) Option {
+	return func(options *creationOptions) {
+		options.maxConcurrency = max
+	}
+}
+
+// DedupeReceivers is a node.Option that makes the SendsTo method of a node ignore the receivers
+// that are already connected to it. By default, connecting a node twice to the same receiver
+// panics, as it would send duplicate items to the receiver.
+func DedupeReceivers() Option {
+	return func(options *creationOptions) {
+		options.dedupeReceivers = true
+	}
+}
+
+// CountEdges is a node.Option that makes a node count the items that it sends to each of its
+// receivers. The counts are accessible through the Graph.Edges method, e.g. to verify how a
+// routing node distributes the items across its branches.
+// Counting the items of a node that sends data to a single receiver requires forwarding them
+// through an extra goroutine, so it is disabled by default.
+func CountEdges() Option {
+	return func(options *creationOptions) {
+		options.countEdges = true
+	}
+}
+
+// WithCloseTimeout is a node.Option that bounds the time that a node keeps forwarding its pending
+// items to its receivers after its function has returned. If any receiver is blocked when the
+// timeout expires (e.g. a misbehaving consumer), the pending items are discarded and the inputs
+// of all the receivers are closed, so the rest of the graph can finish.
+// The discarded items are counted in the Abandoned field of the node Stats. The timeout is
+// measured with the clock of the node.WithClock option.
+// By default, the node waits indefinitely until all the pending items are forwarded.
+func WithCloseTimeout(timeout time.Duration) Option {
+	return func(options *creationOptions) {
+		options.closeTimeout = timeout
+	}
+}
+
+// WithReorderBuffer is a node.Option that bounds the buffer of a node.Reorder node, and allows
+// observing its depth, through the provided ReorderBuffer. With the ReorderBlock policy, the
+// node.Sequence node that creates the sequence numbers must be created with the same option.
+// Other nodes ignore it.
+func WithReorderBuffer(buffer *ReorderBuffer) Option {
+	return func(options *creationOptions) {
+		options.reorderBuffer = buffer
+	}
+}
+
+// WithBackoff is a node.Option that overrides the delays between the retries of the nodes that
+// retry failed operations (e.g. the reconnections of a node.NetworkSink). By default, the delay
+// starts at 50 milliseconds and doubles after each retry, up to one second.
+func WithBackoff(policy BackoffPolicy) Option {
+	return func(options *creationOptions) {
+		options.backoff = policy
+	}
+}
+
+// WithCanaries is a node.Option that enables the canary items of Graph.Canary: a Start node
+// created with this option accepts the injection of canary items, and a Terminal node created
+// with this option detects the canary items and removes them from its input, so they do not
+// pollute the real output. Other nodes ignore it.
+func WithCanaries() Option {
+	return func(options *creationOptions) {
+		options.canaries = true
+	}
+}
+
+// random returns the random number generator for the nodes that make use of random numbers
+func (o *creationOptions) random() *rand.Rand {
+	if o.randSource != nil {
+		return rand.New(o.randSource)
+	}
+	if o.randSeed != nil {
+		return rand.New(rand.NewSource(*o.randSeed))
+	}
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
+
+// checkBufferOptions panics if the options that define the input buffer of a node are
+// contradictory. Only one of ChannelBufferLen, WithChannel, WithByteBuffer and WithMemoryBudget
+// can be used, and MeasureQueueWait and ResizableBuffer can only be combined with the default
+// buffer or the buffer of ChannelBufferLen. Overriding the same option multiple times is
+// allowed: the last one applies.
+func (o *creationOptions) checkBufferOptions() {
+	var buffers []string
+	if o.channelBufferLenSet {
+		buffers = append(buffers, "ChannelBufferLen")
+	}
+	if o.channel != nil {
+		buffers = append(buffers, "WithChannel")
+	}
+	if o.byteBuffer != nil {
+		buffers = append(buffers, "WithByteBuffer")
+	}
+	if o.memoryBudget != nil {
+		buffers = append(buffers, "WithMemoryBudget")
+	}
+	if len(buffers) > 1 {
+		panic(fmt.Sprintf("the input buffer options %s can't be used together",
+			strings.Join(buffers, ", ")))
+	}
+	if o.queueWait != nil && len(buffers) == 1 && !o.channelBufferLenSet {
+		panic(fmt.Sprintf("MeasureQueueWait can't be used together with %s", buffers[0]))
+	}
+	if o.resizableBuffer {
+		if len(buffers) == 1 && !o.channelBufferLenSet {
+			panic(fmt.Sprintf("ResizableBuffer can't be used together with %s", buffers[0]))
+		}
+		if o.queueWait != nil {
+			panic("ResizableBuffer can't be used together with MeasureQueueWait")
+		}
+	}
+}
+
+func newJoiner[IN any](options *creationOptions) connect.Joiner[IN] {
+	if mb := options.memoryBudget; mb != nil {
+		return connect.NewBudgetJoiner(mb.budget, func(item IN) int { return mb.sizeOf(item) })
+	}
+	if options.resizableBuffer {
+		return connect.NewResizableJoiner[IN](options.channelBufferLen)
+	}
+	if h := options.queueWait; h != nil {
+		return connect.NewTimedJoiner[IN](options.channelBufferLen, options.clock.Now, h.Observe)
+	}
+	if cc := options.channel; cc != nil {
+		newChannel, ok := cc.newChannel.(func() Channel[IN])
+		if !ok {
+			panic(fmt.Sprintf("WithChannel item type %v does not match the node input type %v",
+				cc.itemType, reflect.TypeOf((*IN)(nil)).Elem()))
+		}
+		return connect.NewChannelJoiner[IN](newChannel())
+	}
+	if bb := options.byteBuffer; bb != nil {
+		sizeOf, ok := bb.sizeOf.(func(IN) int)
+		if !ok {
+			panic(fmt.Sprintf("WithByteBuffer item type %v does not match the node input type %v",
+				bb.itemType, reflect.TypeOf((*IN)(nil)).Elem()))
+		}
+		return connect.NewByteJoiner(bb.maxBytes, sizeOf)
 	}
+	return connect.NewJoiner[IN](options.channelBufferLen)
 }
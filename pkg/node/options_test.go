@@ -0,0 +1,335 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+)
+
+func TestWithByteBuffer(t *testing.T) {
+	release := make(chan struct{})
+	start := AsStart(func(out chan<- []byte) {
+		for _, size := range []int{2, 5, 3, 4, 1} {
+			out <- make([]byte, size)
+		}
+	})
+	var sizes []int
+	term := AsTerminal(func(in <-chan []byte) {
+		<-release
+		for b := range in {
+			sizes = append(sizes, len(b))
+		}
+	}, WithByteBuffer(10, func(b []byte) int { return len(b) }))
+	start.SendsTo(term)
+	start.Start()
+
+	// the first three items fill the buffer
+	assert.Eventually(t, func() bool {
+		return term.Stats().BufferLen == 3
+	}, timeout, 10*time.Millisecond)
+	assert.Never(t, func() bool {
+		return term.Stats().BufferLen > 3
+	}, 50*time.Millisecond, 10*time.Millisecond)
+
+	close(release)
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{2, 5, 3, 4, 1}, sizes)
+}
+
+func TestWithByteBuffer_TypeMismatch(t *testing.T) {
+	assert.Panics(t, func() {
+		AsTerminal(func(in <-chan []byte) {}, WithByteBuffer(10, func(s string) int { return len(s) }))
+	})
+}
+
+// closeTracker is a Channel that records whether it has been closed
+type closeTracker struct {
+	ch     chan int
+	closed bool
+}
+
+func (c *closeTracker) Send() chan<- int    { return c.ch }
+func (c *closeTracker) Receive() <-chan int { return c.ch }
+func (c *closeTracker) Len() int            { return len(c.ch) }
+func (c *closeTracker) Cap() int            { return cap(c.ch) }
+func (c *closeTracker) Close() {
+	c.closed = true
+	close(c.ch)
+}
+
+func TestWithChannel(t *testing.T) {
+	channel := &closeTracker{ch: make(chan int, 5)}
+	start := AsStart(Counter(1, 3))
+	var received []int
+	term := collectInts(&received)
+	double := AsMiddle(func(in <-chan int, out chan<- int) {
+		for n := range in {
+			out <- n * 2
+		}
+	}, WithChannel(func() Channel[int] { return channel }))
+	start.SendsTo(double)
+	double.SendsTo(term)
+	assert.Equal(t, Stats{BufferCap: 5}, double.Stats())
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{2, 4, 6}, received)
+	assert.True(t, channel.closed)
+}
+
+func TestWithChannel_TypeMismatch(t *testing.T) {
+	assert.Panics(t, func() {
+		AsTerminal(func(in <-chan string) {}, WithChannel(func() Channel[int] {
+			return &closeTracker{ch: make(chan int)}
+		}))
+	})
+}
+
+func TestWithCloseTimeout(t *testing.T) {
+	clock := nodetest.NewManualClock()
+	start := AsStart(Counter(1, 3), WithCloseTimeout(10*time.Millisecond), WithClock(clock))
+	var received []int
+	good := AsTerminal(func(in <-chan int) {
+		for n := range in {
+			received = append(received, n)
+		}
+	}, ChannelBufferLen(10))
+	release := make(chan struct{})
+	defer close(release)
+	// misbehaving consumer that does not read its input
+	stuck := AsTerminal(func(in <-chan int) {
+		<-release
+	})
+	start.SendsTo(good, stuck)
+	start.Start()
+
+	// the timeout starts once the function has returned
+	assert.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+	nodetest.ExpectBlocked(t, good.Done())
+	clock.Advance(10 * time.Millisecond)
+	waitDone(t, good.Done())
+	waitDone(t, start.Done())
+	// the first item was only forwarded to the first receiver
+	assert.Equal(t, []int{1}, received)
+	assert.Equal(t, Stats{Abandoned: 3}, start.Stats())
+	assert.Zero(t, clock.Timers())
+}
+
+func TestWithCloseTimeout_SingleReceiver(t *testing.T) {
+	clock := nodetest.NewManualClock()
+	start := AsStart(Counter(1, 1), WithCloseTimeout(10*time.Millisecond), WithClock(clock))
+	release := make(chan struct{})
+	defer close(release)
+	// misbehaving consumer that does not read its input
+	stuck := AsTerminal(func(in <-chan int) {
+		<-release
+	})
+	start.SendsTo(stuck)
+	start.Start()
+
+	assert.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+	clock.Advance(10 * time.Millisecond)
+	waitDone(t, start.Done())
+	assert.Eventually(t, func() bool { return start.Stats().Abandoned == 1 }, timeout, time.Millisecond)
+}
+
+func TestWithCloseTimeout_StopsTimer(t *testing.T) {
+	clock := nodetest.NewManualClock()
+	release := make(chan struct{})
+	start := AsStart(Counter(1, 3), WithCloseTimeout(time.Hour), WithClock(clock))
+	var received []int
+	slow := AsTerminal(func(in <-chan int) {
+		<-release
+		for n := range in {
+			received = append(received, n)
+		}
+	}, ChannelBufferLen(1))
+	start.SendsTo(slow)
+	start.Start()
+
+	assert.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+	close(release)
+	waitDone(t, slow.Done())
+	assert.Equal(t, []int{1, 2, 3}, received)
+	// the timer is stopped once all the items have been forwarded
+	assert.Eventually(t, func() bool { return clock.Timers() == 0 }, timeout, time.Millisecond)
+}
+
+func TestBufferOptions_Conflicts(t *testing.T) {
+	sizeOf := func(n int) int { return n }
+	newChannel := func() Channel[int] { return &closeTracker{ch: make(chan int)} }
+	budget := NewMemoryBudget(10, func(any) int { return 1 })
+	for name, tc := range map[string]struct {
+		opts []Option
+		msg  string
+	}{
+		"ChannelBufferLen and WithChannel": {
+			opts: []Option{ChannelBufferLen(10), WithChannel(newChannel)},
+			msg:  "the input buffer options ChannelBufferLen, WithChannel can't be used together",
+		},
+		"ChannelBufferLen and WithByteBuffer": {
+			opts: []Option{ChannelBufferLen(10), WithByteBuffer(10, sizeOf)},
+			msg:  "the input buffer options ChannelBufferLen, WithByteBuffer can't be used together",
+		},
+		"ChannelBufferLen and WithMemoryBudget": {
+			opts: []Option{WithMemoryBudget(budget), ChannelBufferLen(0)},
+			msg:  "the input buffer options ChannelBufferLen, WithMemoryBudget can't be used together",
+		},
+		"WithChannel and WithByteBuffer": {
+			opts: []Option{WithChannel(newChannel), WithByteBuffer(10, sizeOf)},
+			msg:  "the input buffer options WithChannel, WithByteBuffer can't be used together",
+		},
+		"WithChannel and WithMemoryBudget": {
+			opts: []Option{WithChannel(newChannel), WithMemoryBudget(budget)},
+			msg:  "the input buffer options WithChannel, WithMemoryBudget can't be used together",
+		},
+		"WithByteBuffer and WithMemoryBudget": {
+			opts: []Option{WithByteBuffer(10, sizeOf), WithMemoryBudget(budget)},
+			msg:  "the input buffer options WithByteBuffer, WithMemoryBudget can't be used together",
+		},
+		"MeasureQueueWait and WithChannel": {
+			opts: []Option{MeasureQueueWait(), WithChannel(newChannel)},
+			msg:  "MeasureQueueWait can't be used together with WithChannel",
+		},
+		"MeasureQueueWait and WithByteBuffer": {
+			opts: []Option{MeasureQueueWait(), WithByteBuffer(10, sizeOf)},
+			msg:  "MeasureQueueWait can't be used together with WithByteBuffer",
+		},
+		"MeasureQueueWait and WithMemoryBudget": {
+			opts: []Option{MeasureQueueWait(), WithMemoryBudget(budget)},
+			msg:  "MeasureQueueWait can't be used together with WithMemoryBudget",
+		},
+		"ResizableBuffer and WithChannel": {
+			opts: []Option{ResizableBuffer(), WithChannel(newChannel)},
+			msg:  "ResizableBuffer can't be used together with WithChannel",
+		},
+		"ResizableBuffer and MeasureQueueWait": {
+			opts: []Option{ResizableBuffer(), ChannelBufferLen(10), MeasureQueueWait()},
+			msg:  "ResizableBuffer can't be used together with MeasureQueueWait",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.PanicsWithValue(t, tc.msg, func() {
+				AsTerminal(func(in <-chan int) {}, tc.opts...)
+			})
+		})
+	}
+}
+
+func TestBufferOptions_Compatible(t *testing.T) {
+	assert.NotPanics(t, func() {
+		// the last value applies
+		AsTerminal(func(in <-chan int) {}, ChannelBufferLen(10), ChannelBufferLen(20))
+		AsTerminal(func(in <-chan int) {}, ChannelBufferLen(10), MeasureQueueWait())
+		AsTerminal(func(in <-chan int) {}, MeasureQueueWait())
+		AsTerminal(func(in <-chan int) {}, ChannelBufferLen(10), ResizableBuffer())
+	})
+}
+
+func TestResizableBuffer(t *testing.T) {
+	release := make(chan struct{})
+	start := AsStart(Counter(1, 10))
+	var received []int
+	term := AsTerminal(func(in <-chan int) {
+		<-release
+		for n := range in {
+			received = append(received, n)
+		}
+	}, ChannelBufferLen(2), ResizableBuffer())
+	start.SendsTo(term)
+	start.Start()
+
+	assert.Eventually(t, func() bool {
+		return term.Stats().BufferLen == 2
+	}, timeout, time.Millisecond)
+	term.SetBufferLen(5)
+	assert.Equal(t, 5, term.Stats().BufferCap)
+	// the senders are unblocked until the new length is reached
+	assert.Eventually(t, func() bool {
+		return term.Stats().BufferLen == 5
+	}, timeout, time.Millisecond)
+	assert.Never(t, func() bool {
+		return term.Stats().BufferLen > 5
+	}, 20*time.Millisecond, time.Millisecond)
+
+	close(release)
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, received)
+}
+
+func TestSetBufferLen_NotResizable(t *testing.T) {
+	term := AsTerminal(func(in <-chan int) {}, ChannelBufferLen(2))
+	assert.Panics(t, func() { term.SetBufferLen(5) })
+}
+
+func TestWithOnStartFinish(t *testing.T) {
+	var mt sync.Mutex
+	var events []string
+	hooks := func(name string) []Option {
+		record := func(event string) func() {
+			return func() {
+				mt.Lock()
+				events = append(events, name+" "+event)
+				mt.Unlock()
+			}
+		}
+		return []Option{WithOnStart(record("start")), WithOnFinish(record("finish"))}
+	}
+	start := AsStart(Counter(1, 3), hooks("source")...)
+	panicky := AsMiddle(func(in <-chan int, out chan<- int) {
+		for n := range in {
+			if n == 2 {
+				panic("boom")
+			}
+			out <- n
+		}
+	}, append(hooks("middle"), WithPanicHandler(func(NodePanic) {}))...)
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	}, hooks("sink")...)
+	start.SendsTo(panicky)
+	panicky.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	waitDone(t, start.Done())
+	mt.Lock()
+	defer mt.Unlock()
+	assert.ElementsMatch(t, []string{
+		"source start", "source finish", "middle start", "middle finish", "sink start", "sink finish",
+	}, events)
+	// the receivers finish after their senders
+	position := map[string]int{}
+	for i, e := range events {
+		position[e] = i
+	}
+	assert.Less(t, position["middle finish"], position["sink finish"])
+	assert.Less(t, position["sink start"], position["sink finish"])
+}
+
+func TestWithOnStartFinish_RunSerial(t *testing.T) {
+	var events []string
+	hooks := func(name string) []Option {
+		record := func(event string) func() {
+			return func() { events = append(events, name+" "+event) }
+		}
+		return []Option{WithOnStart(record("start")), WithOnFinish(record("finish"))}
+	}
+	start := AsStart(Counter(1, 3), hooks("source")...)
+	double := Map(func(n int) int { return n * 2 }, hooks("middle")...)
+	term := ForEach(func(int) {}, hooks("sink")...)
+	start.SendsTo(double)
+	double.SendsTo(term)
+
+	require.NoError(t, NewGraph(start, double, term).RunSerial(context.Background()))
+	assert.Equal(t, []string{
+		"middle start", "sink start", "source start", "source finish", "middle finish", "sink finish",
+	}, events)
+}
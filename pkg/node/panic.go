@@ -0,0 +1,132 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// NodeInfo identifies a node of a graph
+type NodeInfo struct {
+	Name   string
+	Kind   NodeKind
+	Schema Schema
+	// Labels of the node, or nil if the node has no labels
+	Labels map[string]string
+}
+
+// NodePanic is passed to the handler installed with the node.WithPanicHandler option when the
+// function of a node panics.
+type NodePanic struct {
+	// Node that panicked
+	Node NodeInfo
+	// Value is the recovered value, as returned by the recover() builtin
+	Value any
+	// Stack trace of the goroutine that panicked
+	Stack []byte
+}
+
+func (p NodePanic) String() string {
+	return fmt.Sprintf("panic in %s node %s: %v", p.Node.Kind, p.Node.Name, p.Value)
+}
+
+// Error returns the same description as String, so a NodePanic can be reported as an error
+func (p NodePanic) Error() string {
+	return p.String()
+}
+
+func infoOf(n Node) NodeInfo {
+	return NodeInfo{Name: n.Name(), Kind: n.Kind(), Schema: n.Schema(), Labels: n.Labels()}
+}
+
+// invoke runs the function of the node n. If a panic handler is installed, it recovers the panics
+// of the function and returns false if it panicked.
+func (m *nodeMeta) invoke(n Node, fun func()) (completed bool) {
+	if m.panicHandler == nil {
+		fun()
+		return true
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.StoreInt32(&m.state, int32(StateFailed))
+			m.handlePanic(n, r)
+			completed = false
+		}
+	}()
+	fun()
+	return true
+}
+
+// invokeBackground runs a background task of the node n (e.g. a periodic reload) that does not
+// stop the node if it panics. If a panic handler is installed, it recovers the panics of the
+// task and passes them to the handler, without marking the node as failed, and returns false if
+// it panicked.
+func (m *nodeMeta) invokeBackground(n Node, fun func()) (completed bool) {
+	if m.panicHandler == nil {
+		fun()
+		return true
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			m.handlePanic(n, r)
+			completed = false
+		}
+	}()
+	fun()
+	return true
+}
+
+// handlePanic passes a recovered panic of the node n to its handler and observer
+func (m *nodeMeta) handlePanic(n Node, r any) {
+	p := NodePanic{Node: infoOf(n), Value: r, Stack: debug.Stack()}
+	m.panicHandler(p)
+	if m.panicObserver != nil {
+		m.panicObserver(p)
+	}
+}
+
+// drain discards the remaining items of a channel until it is closed
+func drain[T any](ch <-chan T) {
+	for range ch {
+	}
+}
+
+// receiveCtx returns a function that returns the next item of a channel, or false if the channel
+// has been closed. Once the context is cancelled, the function only returns the items that were
+// already queued in the channel, and then false, so the items that the senders had already sent
+// are not lost.
+func receiveCtx[T any](ctx context.Context, ch <-chan T) func() (T, bool) {
+	var queued []T
+	cancelled := false
+	return func() (T, bool) {
+		if !cancelled {
+			select {
+			case item, ok := <-ch:
+				return item, ok
+			case <-ctx.Done():
+				cancelled, queued = true, takeQueued(ch)
+			}
+		}
+		if len(queued) == 0 {
+			var zero T
+			return zero, false
+		}
+		item := queued[0]
+		queued = queued[1:]
+		return item, true
+	}
+}
+
+// takeQueued receives, without blocking, the items that are queued in a channel
+func takeQueued[T any](ch <-chan T) []T {
+	queued := make([]T, 0, len(ch))
+	for n := len(ch); n > 0; n-- {
+		item, ok := <-ch
+		if !ok {
+			break
+		}
+		queued = append(queued, item)
+	}
+	return queued
+}
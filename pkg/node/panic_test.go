@@ -0,0 +1,64 @@
+package node
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPanicHandler(t *testing.T) {
+	var mt sync.Mutex
+	var panics []NodePanic
+	handler := WithPanicHandler(func(p NodePanic) {
+		mt.Lock()
+		defer mt.Unlock()
+		panics = append(panics, p)
+	})
+	start := AsStart(func(out chan<- int) {
+		for i := 1; i <= 5; i++ {
+			out <- i
+		}
+		panic("start failed")
+	}, WithName("start"), handler)
+	middle := AsMiddle(func(in <-chan int, out chan<- string) {
+		for n := range in {
+			if n == 2 {
+				panic(n)
+			}
+			out <- "ok"
+		}
+	}, WithName("middle"), handler)
+	var received []string
+	term := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			received = append(received, s)
+		}
+	})
+	start.SendsTo(middle)
+	middle.SendsTo(term)
+	start.Start()
+
+	// the graph finishes even if the nodes panicked
+	waitDone(t, term.Done())
+	waitDone(t, start.Done())
+	assert.Equal(t, []string{"ok"}, received)
+
+	mt.Lock()
+	defer mt.Unlock()
+	require.Len(t, panics, 2)
+	byName := map[string]NodePanic{}
+	for _, p := range panics {
+		byName[p.Node.Name] = p
+		assert.Contains(t, string(p.Stack), "panic")
+	}
+	assert.Equal(t, NodeInfo{
+		Name: "middle", Kind: KindMiddle,
+		Schema: Schema{In: reflect.TypeOf(0), Out: reflect.TypeOf("")},
+	}, byName["middle"].Node)
+	assert.Equal(t, 2, byName["middle"].Value)
+	assert.Equal(t, "start failed", byName["start"].Value)
+	assert.Equal(t, "panic in Start node start: start failed", byName["start"].String())
+}
@@ -0,0 +1,227 @@
+package node
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// autoScaleInterval is the period at which AutoParallel nodes check their load
+const autoScaleInterval = 100 * time.Millisecond
+
+// idleTicksToRetire is the number of consecutive load checks where an AutoParallel node needs to be
+// underloaded before retiring a worker
+const idleTicksToRetire = 3
+
+// ioBoundWorkersPerCPU is the number of workers per available CPU of the Parallel nodes whose
+// workload is IOBound
+const ioBoundWorkersPerCPU = 4
+
+// WorkloadKind hints the nature of the work of a parallel node, to choose its default
+// number of workers.
+type WorkloadKind int
+
+const (
+	// CPUBound workloads mostly use the CPU, so they can't benefit from having more workers than
+	// available CPUs: runtime.GOMAXPROCS(0)
+	CPUBound WorkloadKind = iota
+	// IOBound workloads spend most of the time waiting (e.g. for network or disk), so they
+	// default to a multiple of the available CPUs.
+	IOBound
+)
+
+func (k WorkloadKind) String() string {
+	switch k {
+	case CPUBound:
+		return "CPUBound"
+	case IOBound:
+		return "IOBound"
+	default:
+		return fmt.Sprintf("WorkloadKind(%d)", int(k))
+	}
+}
+
+// workers returns the default number of workers for the workload kind
+func (k WorkloadKind) workers() int {
+	if k == IOBound {
+		return ioBoundWorkersPerCPU * runtime.GOMAXPROCS(0)
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// Parallel returns a Middle node that applies the provided function to each received item, from a
+// given number of concurrent workers, and forwards the results. The order of the forwarded items
+// is not guaranteed to be the same as the order of the input items.
+// If workers is 0 or lower, the node runs runtime.GOMAXPROCS(0) workers.
+// If the node has a panic handler, the panics of the function are recovered from any worker:
+// the node fails, and all its workers stop processing the items.
+func Parallel[IN, OUT any](workers int, fun func(IN) OUT, opts ...Option) *Middle[IN, OUT] {
+	if workers <= 0 {
+		workers = CPUBound.workers()
+	}
+	var middle *Middle[IN, OUT]
+	middle = AsMiddle(func(in <-chan IN, out chan<- OUT) {
+		wg := sync.WaitGroup{}
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func() {
+				defer wg.Done()
+				for item := range in {
+					if middle.failed() || !middle.invoke(middle, func() { out <- fun(item) }) {
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}, opts...)
+	middle.parallelism = workers
+	return middle
+}
+
+// ParallelAuto returns a Middle node that works like the node returned by Parallel, whose number
+// of workers is chosen according to the kind of workload: runtime.GOMAXPROCS(0) workers for
+// CPUBound workloads, and a multiple of it for IOBound workloads.
+// Unlike AutoParallel, the number of workers does not change with the load.
+func ParallelAuto[IN, OUT any](fun func(IN) OUT, kind WorkloadKind, opts ...Option) *Middle[IN, OUT] {
+	return Parallel(kind.workers(), fun, opts...)
+}
+
+// AutoParallel returns a Middle node that works like the node returned by Parallel, but the
+// number of workers scales between min and max according to the load: a new worker is
+// spawned when the input buffer is almost full (or when all the workers are busy, for unbuffered
+// inputs), and an idle worker is retired when the input buffer has been empty for a while.
+// It adapts the CPU usage to the load of bursty workloads. The input buffer can be specified
+// with the node.ChannelBufferLen option, or any other option that defines the input buffer.
+// The load is checked periodically, and the node.WithClock option allows overriding the source
+// of time.
+// As for Parallel, the panics of the function are recovered from any worker if the node has a
+// panic handler.
+func AutoParallel[IN, OUT any](min, max int, fun func(IN) OUT, opts ...Option) *Middle[IN, OUT] {
+	middle, _ := autoParallel(min, max, fun, opts...)
+	return middle
+}
+
+func autoParallel[IN, OUT any](min, max int, fun func(IN) OUT, opts ...Option) (*Middle[IN, OUT], *autoScaler[IN, OUT]) {
+	if min <= 0 || max < min {
+		panic("AutoParallel requires 0 < min <= max")
+	}
+	s := &autoScaler[IN, OUT]{
+		fun:    fun,
+		clock:  getOptions(opts...).clock,
+		retire: make(chan struct{}),
+		exited: make(chan bool, max),
+	}
+	middle := AsMiddle(func(in <-chan IN, out chan<- OUT) {
+		s.in, s.out = in, out
+		s.supervise(min, max)
+	}, opts...)
+	middle.parallelism = max
+	s.node = middle
+	return middle, s
+}
+
+type autoScaler[IN, OUT any] struct {
+	// the node whose function the workers run, to recover their panics
+	node *Middle[IN, OUT]
+	in   <-chan IN
+	out  chan<- OUT
+	fun  func(IN) OUT
+	// schedules the load checks
+	clock Clock
+	// a worker that receives from this channel is retired
+	retire chan struct{}
+	// each worker sends here whether it exited because the input channel was closed
+	exited  chan bool
+	workers int
+	busy    int32
+	// copy of the workers field that can be concurrently read
+	alive int32
+}
+
+func (s *autoScaler[IN, OUT]) supervise(min, max int) {
+	for i := 0; i < min; i++ {
+		s.spawn()
+	}
+	timer := s.clock.NewTimer(autoScaleInterval)
+	defer timer.Stop()
+	inputClosed := false
+	idleTicks := 0
+	for s.workers > 0 {
+		select {
+		case closed := <-s.exited:
+			s.workers--
+			atomic.StoreInt32(&s.alive, int32(s.workers))
+			inputClosed = inputClosed || closed
+		case <-timer.C():
+			if inputClosed {
+				continue
+			}
+			idleTicks = s.scale(min, max, idleTicks)
+			timer.Reset(autoScaleInterval)
+		}
+	}
+}
+
+// scale spawns or retires a worker according to the load of the node, and returns the updated
+// number of consecutive load checks where the node was underloaded
+func (s *autoScaler[IN, OUT]) scale(min, max, idleTicks int) int {
+	busy := int(atomic.LoadInt32(&s.busy))
+	if s.overloaded(busy) {
+		if s.workers < max {
+			s.spawn()
+		}
+		return 0
+	}
+	if s.node.inputs.Len() == 0 && busy < s.workers {
+		idleTicks++
+	} else {
+		idleTicks = 0
+	}
+	if idleTicks >= idleTicksToRetire && s.workers > min {
+		// only workers waiting for input can be retired
+		select {
+		case s.retire <- struct{}{}:
+			idleTicks = 0
+		default:
+		}
+	}
+	return idleTicks
+}
+
+func (s *autoScaler[IN, OUT]) overloaded(busy int) bool {
+	capacity := s.node.inputs.Cap()
+	if capacity == 0 {
+		return busy >= s.workers
+	}
+	return s.node.inputs.Len()*5 >= capacity*4
+}
+
+func (s *autoScaler[IN, OUT]) spawn() {
+	s.workers++
+	atomic.StoreInt32(&s.alive, int32(s.workers))
+	go func() {
+		for {
+			select {
+			case item, ok := <-s.in:
+				// once a worker has panicked, the rest of workers exit as if the input was closed
+				if !ok || s.node.failed() {
+					s.exited <- true
+					return
+				}
+				atomic.AddInt32(&s.busy, 1)
+				completed := s.node.invoke(s.node, func() { s.out <- s.fun(item) })
+				atomic.AddInt32(&s.busy, -1)
+				if !completed {
+					s.exited <- true
+					return
+				}
+			case <-s.retire:
+				s.exited <- false
+				return
+			}
+		}
+	}()
+}
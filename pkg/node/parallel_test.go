@@ -0,0 +1,237 @@
+package node
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyTracker wraps a function to measure how many instances of it run concurrently
+type concurrencyTracker struct {
+	mt      sync.Mutex
+	current int
+	max     int
+}
+
+func (c *concurrencyTracker) wrap(sleep time.Duration, fun func(int) int) func(int) int {
+	return func(n int) int {
+		c.mt.Lock()
+		c.current++
+		if c.current > c.max {
+			c.max = c.current
+		}
+		c.mt.Unlock()
+		time.Sleep(sleep)
+		c.mt.Lock()
+		c.current--
+		c.mt.Unlock()
+		return fun(n)
+	}
+}
+
+func (c *concurrencyTracker) maxConcurrent() int {
+	c.mt.Lock()
+	defer c.mt.Unlock()
+	return c.max
+}
+
+func collectInts(into *[]int) *Terminal[int] {
+	return AsTerminal(func(in <-chan int) {
+		for n := range in {
+			*into = append(*into, n)
+		}
+	})
+}
+
+func TestParallel(t *testing.T) {
+	tracker := concurrencyTracker{}
+	start := AsStart(Counter(1, 20))
+	double := Parallel(4, tracker.wrap(20*time.Millisecond, func(n int) int {
+		return n * 2
+	}))
+	var results []int
+	term := collectInts(&results)
+	start.SendsTo(double)
+	double.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	sort.Ints(results)
+	var expected []int
+	for i := 1; i <= 20; i++ {
+		expected = append(expected, i*2)
+	}
+	assert.Equal(t, expected, results)
+	assert.Equal(t, 4, tracker.maxConcurrent())
+}
+
+func TestParallel_DefaultWorkers(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(3))
+	for _, tc := range []struct {
+		name    string
+		node    func(fun func(int) int) *Middle[int, int]
+		workers int
+	}{
+		{name: "unspecified", workers: 3, node: func(fun func(int) int) *Middle[int, int] {
+			return Parallel(0, fun)
+		}},
+		{name: "CPU bound", workers: 3, node: func(fun func(int) int) *Middle[int, int] {
+			return ParallelAuto(fun, CPUBound)
+		}},
+		{name: "IO bound", workers: 3 * ioBoundWorkersPerCPU, node: func(fun func(int) int) *Middle[int, int] {
+			return ParallelAuto(fun, IOBound)
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := concurrencyTracker{}
+			start := AsStart(Counter(1, 40))
+			par := tc.node(tracker.wrap(20*time.Millisecond, func(n int) int { return n }))
+			var results []int
+			term := collectInts(&results)
+			start.SendsTo(par)
+			par.SendsTo(term)
+			start.Start()
+
+			waitDone(t, term.Done())
+			assert.Len(t, results, 40)
+			assert.Equal(t, tc.workers, tracker.maxConcurrent())
+		})
+	}
+}
+
+func TestParallel_PanicHandler(t *testing.T) {
+	for name, parallel := range map[string]func(func(int) int, ...Option) *Middle[int, int]{
+		"Parallel": func(fun func(int) int, opts ...Option) *Middle[int, int] {
+			return Parallel(2, fun, opts...)
+		},
+		"AutoParallel": func(fun func(int) int, opts ...Option) *Middle[int, int] {
+			return AutoParallel(1, 2, fun, opts...)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			panics := make(chan NodePanic, 10)
+			start := AsStart(Counter(1, 10))
+			failing := parallel(func(n int) int {
+				if n == 3 {
+					panic("boom")
+				}
+				return n
+			}, WithName("failing"), WithPanicHandler(func(p NodePanic) { panics <- p }))
+			var results []int
+			term := collectInts(&results)
+			start.SendsTo(failing)
+			failing.SendsTo(term)
+			start.Start()
+
+			// the panic of the worker is recovered, and the rest of the input is discarded
+			waitDone(t, term.Done())
+			assert.Equal(t, StateFailed, failing.State())
+			require.Len(t, panics, 1)
+			p := <-panics
+			assert.Equal(t, "failing", p.Node.Name)
+			assert.Equal(t, "boom", p.Value)
+			assert.NotContains(t, results, 3)
+		})
+	}
+}
+
+func TestAutoParallel(t *testing.T) {
+	tracker := concurrencyTracker{}
+	start := AsStart(Counter(1, 60))
+	double := AutoParallel(1, 4, tracker.wrap(50*time.Millisecond, func(n int) int {
+		return n * 2
+	}), ChannelBufferLen(10))
+	var results []int
+	term := collectInts(&results)
+	start.SendsTo(double)
+	double.SendsTo(term)
+	start.Start()
+
+	select {
+	case <-term.Done():
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "timeout while waiting for pipeline to complete")
+	}
+	assert.Len(t, results, 60)
+	// the number of workers scaled up, without surpassing the maximum
+	assert.Greater(t, tracker.maxConcurrent(), 1)
+	assert.LessOrEqual(t, tracker.maxConcurrent(), 4)
+}
+
+func TestAutoParallel_Retire(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "channel", opts: []Option{ChannelBufferLen(10)}},
+		// the queue-backed inputs are not measured through the capacity of the input channel
+		{name: "queue", opts: []Option{ChannelBufferLen(10), ResizableBuffer()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			release := make(chan struct{})
+			start := AsStart(func(out chan<- int) {
+				for i := 0; i < 40; i++ {
+					out <- i
+				}
+				<-release
+			})
+			clock := nodetest.NewManualClock()
+			unblock := make(chan struct{})
+			concurrent, scaler := autoParallel(2, 4, func(n int) int {
+				<-unblock
+				return n
+			}, append(tc.opts, WithClock(clock))...)
+			term := AsTerminal(func(in <-chan int) {
+				for range in {
+				}
+			})
+			start.SendsTo(concurrent)
+			concurrent.SendsTo(term)
+			start.Start()
+
+			workers := func() int32 {
+				return atomic.LoadInt32(&scaler.alive)
+			}
+			tick := func() {
+				require.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+				clock.Advance(autoScaleInterval)
+			}
+			// workers scale up, one per load check, while the input buffer is full
+			require.Eventually(t, func() bool {
+				return concurrent.Stats().BufferLen == 10
+			}, timeout, time.Millisecond)
+			assert.EqualValues(t, 2, workers())
+			tick()
+			tick()
+			tick()
+			require.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+			assert.EqualValues(t, 4, workers())
+
+			// after some load checks without input, workers are retired down to the minimum
+			close(unblock)
+			require.Eventually(t, func() bool {
+				return concurrent.Stats().BufferLen == 0 && atomic.LoadInt32(&scaler.busy) == 0
+			}, timeout, time.Millisecond)
+			for i := 0; i < 2*idleTicksToRetire; i++ {
+				tick()
+			}
+			require.Eventually(t, func() bool { return workers() == 2 }, timeout, time.Millisecond)
+			for i := 0; i < 2*idleTicksToRetire; i++ {
+				tick()
+			}
+			require.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+			assert.EqualValues(t, 2, workers())
+
+			close(release)
+			waitDone(t, term.Done())
+			assert.Zero(t, workers())
+		})
+	}
+}
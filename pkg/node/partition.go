@@ -0,0 +1,128 @@
+package node
+
+import (
+	"context"
+	"reflect"
+)
+
+// Partition is a node that routes each received item to one of two branches, depending on
+// whether it matches a predicate. It expresses if/else routing without scanning the stream
+// twice, as two Filter nodes would do.
+// The receivers of each branch are connected through the True and False endpoints.
+type Partition[T any] struct {
+	nodeMeta
+	receiverBase[T]
+	pred      func(T) bool
+	trueOuts  []Receiver[T]
+	falseOuts []Receiver[T]
+	done      chan struct{}
+}
+
+// Partition2 creates a Partition node that sends each item to the receivers of the True endpoint
+// if it matches the predicate, or to the receivers of the False endpoint otherwise. Each item is
+// sent to exactly one branch. The items of a branch without receivers are discarded.
+func Partition2[T any](pred func(T) bool, opts ...Option) *Partition[T] {
+	options := getOptions(opts...)
+	receiver := newReceiverBase[T](&options)
+	p := &Partition[T]{
+		nodeMeta:     newNodeMeta(&options, KindMiddle, Schema{In: receiver.inType, Out: receiver.inType}),
+		receiverBase: receiver,
+		pred:         pred,
+		done:         make(chan struct{}),
+	}
+	p.inputDone = p.receiverBase.inputClosed
+	return p
+}
+
+// True returns the endpoint that sends the items that match the predicate
+func (p *Partition[T]) True() Sender[T] {
+	return partitionBranch[T]{p: p, matched: true}
+}
+
+// False returns the endpoint that sends the items that do not match the predicate
+func (p *Partition[T]) False() Sender[T] {
+	return partitionBranch[T]{p: p, matched: false}
+}
+
+// Done returns a channel that is closed when the Partition node has ended its processing. This
+// is, when its input has been closed and all its outputs have been closed.
+func (p *Partition[T]) Done() <-chan struct{} {
+	return p.done
+}
+
+// Kind returns KindMiddle
+func (p *Partition[T]) Kind() NodeKind {
+	return KindMiddle
+}
+
+// Schema returns the input and output types of the Partition, which are the same
+func (p *Partition[T]) Schema() Schema {
+	return Schema{In: p.inType, Out: p.inType}
+}
+
+// Stats returns runtime information about the Partition node
+func (p *Partition[T]) Stats() Stats {
+	stats := p.receiverBase.Stats()
+	p.senderStats(&stats)
+	return stats
+}
+
+func (p *Partition[T]) outputs() []graphNode {
+	return append(receiversAsNodes(p.trueOuts), receiversAsNodes(p.falseOuts)...)
+}
+
+func (p *Partition[T]) start(ctx context.Context) {
+	if len(p.trueOuts) == 0 && len(p.falseOuts) == 0 {
+		panicNoOutputs(&p.nodeMeta)
+	}
+	if !p.markStarted() {
+		return
+	}
+	var matched, unmatched chan<- T
+	var releasers []func()
+	if len(p.trueOuts) > 0 {
+		forker := forkTo(ctx, &p.nodeMeta, p.trueOuts)
+		matched = forker.Sender()
+		releasers = append(releasers, forker.Close)
+	}
+	if len(p.falseOuts) > 0 {
+		forker := forkTo(ctx, &p.nodeMeta, p.falseOuts)
+		unmatched = forker.Sender()
+		releasers = append(releasers, forker.Close)
+	}
+	go func() {
+		p.notifyStart()
+		for item := range p.inputs.Receiver() {
+			if p.pred(item) {
+				if matched != nil {
+					matched <- item
+				}
+			} else if unmatched != nil {
+				unmatched <- item
+			}
+		}
+		for _, release := range releasers {
+			release()
+		}
+		p.notifyFinish()
+		close(p.done)
+	}()
+}
+
+// partitionBranch is the Sender endpoint of a branch of a Partition node
+type partitionBranch[T any] struct {
+	p       *Partition[T]
+	matched bool
+}
+
+func (b partitionBranch[T]) SendsTo(receivers ...Receiver[T]) {
+	if b.matched {
+		b.p.trueOuts = connectTo(&b.p.nodeMeta, b.p.trueOuts, receivers)
+	} else {
+		b.p.falseOuts = connectTo(&b.p.nodeMeta, b.p.falseOuts, receivers)
+	}
+}
+
+func (b partitionBranch[T]) OutType() reflect.Type {
+	return b.p.inType
+}
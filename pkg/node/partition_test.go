@@ -0,0 +1,39 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartition2(t *testing.T) {
+	start := AsStart(Counter(1, 7))
+	evens := Partition2(func(n int) bool { return n%2 == 0 })
+	var even, odd []int
+	evenTerm := collectInts(&even)
+	oddTerm := collectInts(&odd)
+	start.SendsTo(evens)
+	evens.True().SendsTo(evenTerm)
+	evens.False().SendsTo(oddTerm)
+
+	graph := NewGraph(start, evens, evenTerm, oddTerm)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	assert.Equal(t, []int{2, 4, 6}, even)
+	assert.Equal(t, []int{1, 3, 5, 7}, odd)
+}
+
+func TestPartition2_SingleBranch(t *testing.T) {
+	start := AsStart(Counter(1, 7))
+	small := Partition2(func(n int) bool { return n < 4 })
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(small)
+	// the unmatched items are discarded
+	small.True().SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{1, 2, 3}, received)
+}
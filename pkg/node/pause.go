@@ -0,0 +1,100 @@
+package node
+
+import (
+	"context"
+	"sync"
+)
+
+// pauseGate holds the processing loop of a node while it is paused
+type pauseGate struct {
+	mt sync.Mutex
+	// if not nil, the node is paused until this channel is closed
+	resumed chan struct{}
+}
+
+func (g *pauseGate) pause() {
+	g.mt.Lock()
+	defer g.mt.Unlock()
+	if g.resumed == nil {
+		g.resumed = make(chan struct{})
+	}
+}
+
+func (g *pauseGate) resume() {
+	g.mt.Lock()
+	defer g.mt.Unlock()
+	if g.resumed != nil {
+		close(g.resumed)
+		g.resumed = nil
+	}
+}
+
+// wait blocks while the gate is paused, unless the context is cancelled
+func (g *pauseGate) wait(ctx context.Context) {
+	g.mt.Lock()
+	resumed := g.resumed
+	g.mt.Unlock()
+	if resumed != nil {
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// Pause stops the node from processing and pulling items from its input, so the previous nodes
+// are eventually blocked, until Resume is invoked. The item that is being processed when Pause is
+// invoked is still forwarded, and the next received item is held until Resume is invoked.
+// Only the Middle nodes whose processing loop is owned by the library can be paused (e.g.
+// node.Map and node.Filter). The nodes wrapping a user-provided MiddleFunc (e.g. created
+// with AsMiddle) run their own loop, so Pause has no effect on them.
+// A paused node stops pausing if the context of the graph is cancelled.
+func (m *Middle[IN, OUT]) Pause() {
+	if m.pause != nil {
+		m.pause.pause()
+	}
+}
+
+// Resume makes a paused node to continue processing its input.
+func (m *Middle[IN, OUT]) Resume() {
+	if m.pause != nil {
+		m.pause.resume()
+	}
+}
+
+// asStepMiddle creates a Middle node whose processing loop is owned by the library: for each
+// received item, it invokes the step function, which can emit any number of items to the output.
+// This allows the node to be paused between items, and to run in serial mode (see
+// Graph.RunSerial).
+func asStepMiddle[IN, OUT any](step func(item IN, emit func(OUT)), opts ...Option) *Middle[IN, OUT] {
+	gate := &pauseGate{}
+	m := AsMiddleCtx(func(ctx context.Context, in <-chan IN, out chan<- OUT) {
+		emit := func(o OUT) { out <- o }
+		for item := range in {
+			gate.wait(ctx)
+			step(item, emit)
+		}
+	}, opts...)
+	m.pause = gate
+	m.serial = step
+	return m
+}
+
+// asPausableMiddle creates a Middle node whose processing loop is owned by the library: for each
+// received item, it invokes the step function, which can send any number of items to the output
+// channel. This allows the node to be paused between items.
+// As the step function has direct access to the output channel, the node can't run in serial
+// mode. Use asStepMiddle when possible.
+func asPausableMiddle[IN, OUT any](step func(item IN, out chan<- OUT), opts ...Option) *Middle[IN, OUT] {
+	gate := &pauseGate{}
+	m := AsMiddleCtx(func(ctx context.Context, in <-chan IN, out chan<- OUT) {
+		for item := range in {
+			// waiting after receiving the item, as the node might be paused while it was
+			// waiting for it
+			gate.wait(ctx)
+			step(item, out)
+		}
+	}, opts...)
+	m.pause = gate
+	return m
+}
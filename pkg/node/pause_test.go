@@ -0,0 +1,76 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+)
+
+func TestPause(t *testing.T) {
+	input := make(chan int, 10)
+	start := AsStart(func(out chan<- int) {
+		for n := range input {
+			out <- n
+		}
+	})
+	double := Map(func(n int) int { return n * 2 })
+	probe := double.Probe()
+	start.SendsTo(double)
+	start.Start()
+
+	input <- 1
+	nodetest.ExpectReceives(t, probe.inputs.Receiver(), 2, timeout)
+	double.Pause()
+	// pausing twice has no effect
+	double.Pause()
+	input <- 2
+	input <- 3
+	nodetest.ExpectBlocked(t, probe.inputs.Receiver())
+
+	double.Resume()
+	nodetest.ExpectReceives(t, probe.inputs.Receiver(), 4, timeout)
+	nodetest.ExpectReceives(t, probe.inputs.Receiver(), 6, timeout)
+	double.Resume()
+	input <- 4
+	nodetest.ExpectReceives(t, probe.inputs.Receiver(), 8, timeout)
+	close(input)
+	nodetest.ExpectClosed(t, probe.inputs.Receiver(), timeout)
+}
+
+func TestPause_Cancel(t *testing.T) {
+	start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		out <- 1
+		<-ctx.Done()
+	})
+	filter := Filter(func(n int) bool { return true })
+	probe := filter.Probe()
+	start.SendsTo(filter)
+	filter.Pause()
+	ctx, cancel := context.WithCancel(context.Background())
+	start.StartCtx(ctx)
+	nodetest.ExpectBlocked(t, probe.inputs.Receiver())
+
+	// cancelling the context releases the paused node, which forwards the item unless it is
+	// abandoned by the Start node
+	cancel()
+	for item, ok := probe.Next(timeout); ok; item, ok = probe.Next(timeout) {
+		require.Equal(t, 1, item)
+	}
+	waitDone(t, filter.Done())
+}
+
+func TestPause_Opaque(t *testing.T) {
+	odds := AsMiddle(OddFilter)
+	probe := odds.Probe()
+	start := AsStart(Counter(1, 3))
+	start.SendsTo(odds)
+	// opaque nodes can't be paused
+	odds.Pause()
+	start.Start()
+	nodetest.ExpectReceives(t, probe.inputs.Receiver(), 1, timeout)
+	odds.Resume()
+	nodetest.ExpectReceives(t, probe.inputs.Receiver(), 3, timeout)
+}
@@ -0,0 +1,50 @@
+package node
+
+// Pipe is a linear pipeline under construction, whose last stage sends items of type T.
+// Each stage is connected to the previous one when it is added, and the types of the stages
+// are checked at compile time.
+// Since Go does not allow type parameters in methods, the stages that change the type of the
+// items are added with the node.Step function, while the stages that preserve it are added
+// with the methods of the Pipe.
+type Pipe[T any] struct {
+	last  Sender[T]
+	nodes []Node
+}
+
+// Source starts a Pipe whose first stage is a Start node wrapping the provided function.
+func Source[T any](fun StartFunc[T], opts ...Option) *Pipe[T] {
+	start := AsStart(fun, opts...)
+	return &Pipe[T]{last: start, nodes: []Node{start}}
+}
+
+// Step appends to the Pipe a Middle stage that converts each item with the provided function,
+// returning a Pipe whose items have the converted type.
+func Step[A, B any](p *Pipe[A], fun func(A) B, opts ...Option) *Pipe[B] {
+	return then(p, Map(fun, opts...))
+}
+
+// Via appends to the Pipe a Middle stage that wraps the provided function, returning a Pipe
+// whose items have the output type of the function.
+func Via[A, B any](p *Pipe[A], fun MiddleFunc[A, B], opts ...Option) *Pipe[B] {
+	return then(p, AsMiddle(fun, opts...))
+}
+
+// Filter appends to the Pipe a Middle stage that only forwards the items that satisfy the
+// provided predicate.
+func (p *Pipe[T]) Filter(pred func(T) bool, opts ...Option) *Pipe[T] {
+	return then(p, Filter(pred, opts...))
+}
+
+// Sink ends the Pipe with a Terminal stage that wraps the provided function, and returns a
+// Graph containing all the stages of the Pipe, ready to be started.
+// The Pipe must not be further used after invoking Sink.
+func (p *Pipe[T]) Sink(fun TerminalFunc[T], opts ...Option) *Graph {
+	term := AsTerminal(fun, opts...)
+	p.last.SendsTo(term)
+	return NewGraph(append(p.nodes, term)...)
+}
+
+func then[A, B any](p *Pipe[A], m *Middle[A, B]) *Pipe[B] {
+	p.last.SendsTo(m)
+	return &Pipe[B]{last: m, nodes: append(p.nodes, m)}
+}
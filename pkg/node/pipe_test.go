@@ -0,0 +1,46 @@
+package node
+
+import (
+	"os/exec"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipe(t *testing.T) {
+	var received []string
+	pipe := Source(Counter(1, 6))
+	odds := pipe.Filter(func(n int) bool { return n%2 == 1 })
+	strs := Step(odds, strconv.Itoa)
+	msgs := Via(strs, func(in <-chan string, out chan<- string) {
+		for s := range in {
+			out <- "n" + s
+		}
+	}, WithName("prefix"))
+	graph := msgs.Sink(func(in <-chan string) {
+		for s := range in {
+			received = append(received, s)
+		}
+	})
+
+	require.Len(t, graph.Nodes(), 5)
+	assert.Equal(t, "prefix", graph.Nodes()[3].Name())
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	assert.Equal(t, []string{"n1", "n3", "n5"}, received)
+}
+
+func TestPipe_TypeMismatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiling the fixture is slow")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not available")
+	}
+	// the compiler messages are not checked, as they change between Go versions
+	_, err = exec.Command(goBin, "build", "-o", t.TempDir(), "./testdata/mismatch").CombinedOutput()
+	require.Error(t, err, "expected the type mismatch to fail compilation")
+}
@@ -0,0 +1,74 @@
+package node
+
+// Plan describes how a graph would run, without starting it. See Graph.Plan.
+type Plan struct {
+	// Stages groups the nodes by their depth in the graph: the first stage contains the nodes
+	// that don't receive data from any node (e.g. the Start nodes), and each following stage
+	// contains the nodes whose senders are in the previous stages.
+	Stages [][]PlanNode
+	// Warnings describes the problems that would prevent the graph from starting, if any
+	Warnings []string
+}
+
+// PlanNode describes the runtime configuration of a node of a Plan
+type PlanNode struct {
+	Node NodeInfo
+	// BufferCap is the capacity of the node input buffer. It is 0 for unbuffered channels and
+	// for the nodes without input.
+	BufferCap int
+	// Parallelism is the maximum number of goroutines that run the node function concurrently
+	// (e.g. the workers of a node.Parallel node)
+	Parallelism int
+	// Receivers is the number of nodes that receive data from the node. The Start and Middle
+	// nodes send each item to all their receivers, so it is also the amplification of the items
+	// that the node sends. The routing nodes (e.g. node.Switch or node.Partition) send each item
+	// to a subset of their receivers.
+	Receivers int
+}
+
+// Plan returns the execution plan of the graph, including the unlisted nodes that receive data
+// from any of its nodes, so the runtime configuration of a graph can be reviewed before
+// committing resources to it. Unlike Validate, it also reports the configuration of each node.
+// It does not start any node. If the graph has a cycle, the plan has no stages.
+func (g *Graph) Plan() Plan {
+	plan := Plan{}
+	if err := g.Validate(); err != nil {
+		plan.Warnings = append(plan.Warnings, err.Error())
+	}
+	order, err := g.TopoSort()
+	if err != nil {
+		return plan
+	}
+	// in topological order, the senders of a node are placed before it
+	stages := map[graphNode]int{}
+	for _, n := range order {
+		stage := stages[n]
+		for _, out := range n.outputs() {
+			if stages[out] < stage+1 {
+				stages[out] = stage + 1
+			}
+		}
+		for len(plan.Stages) <= stage {
+			plan.Stages = append(plan.Stages, nil)
+		}
+		plan.Stages[stage] = append(plan.Stages[stage], planNode(n))
+	}
+	return plan
+}
+
+func planNode(n Node) PlanNode {
+	pn := PlanNode{
+		Node:        infoOf(n),
+		BufferCap:   n.Stats().BufferCap,
+		Parallelism: n.meta().parallelism,
+	}
+	if pn.Parallelism == 0 {
+		pn.Parallelism = 1
+	}
+	distinct := map[graphNode]struct{}{}
+	for _, out := range n.outputs() {
+		distinct[out] = struct{}{}
+	}
+	pn.Receivers = len(distinct)
+	return pn
+}
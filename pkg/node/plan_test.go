@@ -0,0 +1,67 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph_Plan(t *testing.T) {
+	start := AsStart(Counter(1, 3), WithName("start"))
+	square := Parallel(4, func(n int) int { return n * n }, WithName("square"), ChannelBufferLen(10))
+	odds := AsMiddle(OddFilter, WithName("odds"))
+	printer := AsTerminal(func(in <-chan int) {}, WithName("printer"))
+	start.SendsTo(square, odds)
+	square.SendsTo(printer)
+	odds.SendsTo(printer)
+	graph := NewGraph(start, square, odds, printer)
+
+	type node struct {
+		name        string
+		bufferCap   int
+		parallelism int
+		receivers   int
+	}
+	plan := graph.Plan()
+	var stages [][]node
+	for _, stage := range plan.Stages {
+		var nodes []node
+		for _, n := range stage {
+			nodes = append(nodes, node{n.Node.Name, n.BufferCap, n.Parallelism, n.Receivers})
+		}
+		stages = append(stages, nodes)
+	}
+	assert.Equal(t, [][]node{
+		{{"start", 0, 1, 2}},
+		{{"square", 10, 4, 1}, {"odds", 0, 1, 1}},
+		{{"printer", 0, 1, 0}},
+	}, stages)
+	assert.Empty(t, plan.Warnings)
+
+	// the plan does not start the graph
+	assert.False(t, isClosed(printer.Done()))
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+}
+
+func TestGraph_Plan_Warnings(t *testing.T) {
+	start := AsStart(Counter(1, 3), WithName("start"))
+	odds := AsMiddle(OddFilter, WithName("odds"))
+	start.SendsTo(odds)
+
+	plan := NewGraph(start, odds).Plan()
+	require.Len(t, plan.Warnings, 1)
+	assert.Contains(t, plan.Warnings[0], "odds")
+	assert.Len(t, plan.Stages, 2)
+
+	m1 := AsMiddle(OddFilter, WithName("m1"))
+	m2 := AsMiddle(OddFilter, WithName("m2"))
+	start = AsStart(Counter(1, 3))
+	start.SendsTo(m1)
+	m1.SendsTo(m2)
+	m2.SendsTo(m1)
+	plan = NewGraph(start, m1, m2).Plan()
+	assert.NotEmpty(t, plan.Warnings)
+	assert.Empty(t, plan.Stages)
+}
@@ -0,0 +1,106 @@
+package node
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type packet struct {
+	payload []byte
+}
+
+func TestWithItemPool(t *testing.T) {
+	var mt sync.Mutex
+	recycled := 0
+	pool := NewPool(func() *packet {
+		return &packet{payload: make([]byte, 0, 16)}
+	}, func(p *packet) {
+		p.payload = p.payload[:0]
+		mt.Lock()
+		recycled++
+		mt.Unlock()
+	})
+	start := AsStart(func(out chan<- *Pooled[packet]) {
+		for i := 0; i < 10; i++ {
+			p := pool.Get()
+			p.Item.payload = append(p.Item.payload, byte(i))
+			out <- p
+		}
+	}, WithItemPool(pool))
+	sums := make([]int, 2)
+	consumer := func(idx int) *Terminal[*Pooled[packet]] {
+		return AsTerminal(func(in <-chan *Pooled[packet]) {
+			for p := range in {
+				for _, b := range p.Item.payload {
+					sums[idx] += int(b)
+				}
+				p.Release()
+			}
+		})
+	}
+	c1, c2 := consumer(0), consumer(1)
+	start.SendsTo(c1, c2)
+	start.Start()
+
+	waitDone(t, c1.Done())
+	waitDone(t, c2.Done())
+	// the items were not recycled before both consumers released them
+	assert.Equal(t, []int{45, 45}, sums)
+	assert.Equal(t, 10, recycled)
+}
+
+func TestPooled_ReleaseTwice(t *testing.T) {
+	pool := NewPool(func() *packet { return &packet{} }, nil)
+	p := pool.Get()
+	p.Release()
+	assert.Panics(t, p.Release)
+}
+
+func TestWithItemPool_TopicExchange(t *testing.T) {
+	var mt sync.Mutex
+	recycled := 0
+	pool := NewPool(func() *packet { return &packet{} }, func(*packet) {
+		mt.Lock()
+		recycled++
+		mt.Unlock()
+	})
+	start := AsStart(func(out chan<- *Pooled[packet]) {
+		for _, topic := range []string{"a.b", "a.c", "d"} {
+			p := pool.Get()
+			p.Item.payload = []byte(topic)
+			out <- p
+		}
+	})
+	exchange := TopicExchange(func(p *Pooled[packet]) string {
+		return string(p.Item.payload)
+	}, WithItemPool(pool))
+	var received [2]int
+	consumer := func(idx int) *Terminal[*Pooled[packet]] {
+		return AsTerminal(func(in <-chan *Pooled[packet]) {
+			for p := range in {
+				received[idx]++
+				p.Release()
+			}
+		})
+	}
+	c1, c2 := consumer(0), consumer(1)
+	start.SendsTo(exchange)
+	exchange.SubscribePattern("a.*", c1, c2).SubscribePattern("a.b", c1)
+
+	graph := NewGraph(start, exchange, c1, c2)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	assert.Equal(t, [2]int{2, 2}, received)
+	// the unmatched item is released by the exchange
+	mt.Lock()
+	defer mt.Unlock()
+	assert.Equal(t, 3, recycled)
+}
+
+func TestWithItemPool_Broadcaster(t *testing.T) {
+	pool := NewPool(func() *packet { return &packet{} }, nil)
+	assert.Panics(t, func() { ReplayBroadcaster[*Pooled[packet]](1, WithItemPool(pool)) })
+}
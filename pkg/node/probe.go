@@ -0,0 +1,148 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/netobserv/gopipes/pkg/node/internal/connect"
+)
+
+// probeBufferLen is the length of the input channel of a Probe
+const probeBufferLen = 128
+
+// Probe is a receiver that can be attached to the output of a Start or Middle node to
+// synchronously pull the items that are sent through it, without having to build a Terminal
+// node. It is intended for testing purposes.
+// The Probe input channel is buffered, but if the buffer gets full, the attached node will block
+// until the items are pulled from the Probe.
+type Probe[T any] struct {
+	nodeMeta
+	receiverBase[T]
+	done chan struct{}
+}
+
+func newProbe[T any]() *Probe[T] {
+	receiver := newReceiverBase[T](&creationOptions{channelBufferLen: probeBufferLen})
+	p := &Probe[T]{
+		nodeMeta:     nodeMeta{name: fmt.Sprintf("Probe[%v]", receiver.inType)},
+		receiverBase: receiver,
+		done:         make(chan struct{}),
+	}
+	// the Probe is done as soon as its input is closed, even if it has not been pulled, so the
+	// graphs that wait for their nodes to finish are not blocked by an unread Probe
+	p.inputs = connect.NewChannelJoiner[T](&probeChannel[T]{
+		ch:      make(chan T, probeBufferLen),
+		onClose: p.finish,
+	})
+	p.inputDone = p.receiverBase.inputClosed
+	return p
+}
+
+// Probe attaches a Probe to the output of the Start node. It must be invoked before the
+// node is started.
+func (s *Start[OUT]) Probe() *Probe[OUT] {
+	p := newProbe[OUT]()
+	s.SendsTo(p)
+	return p
+}
+
+// Probe attaches a Probe to the output of the Middle node. It must be invoked before the
+// node is started.
+func (m *Middle[IN, OUT]) Probe() *Probe[OUT] {
+	p := newProbe[OUT]()
+	m.SendsTo(p)
+	return p
+}
+
+// Next returns the next item that has been sent to the Probe. If no item is received before the
+// given timeout, or the attached node has closed its output, it returns false.
+func (p *Probe[T]) Next(timeout time.Duration) (T, bool) {
+	select {
+	case item, ok := <-p.inputs.Receiver():
+		return item, ok
+	case <-time.After(timeout):
+		var zero T
+		return zero, false
+	}
+}
+
+// Expect returns the next n items that have been sent to the Probe. It returns an error, together
+// with the items that have been received, if the n items are not received before the
+// given timeout, or if the attached node closes its output before.
+func (p *Probe[T]) Expect(n int, timeout time.Duration) ([]T, error) {
+	items := make([]T, 0, n)
+	deadline := time.After(timeout)
+	for len(items) < n {
+		select {
+		case item, ok := <-p.inputs.Receiver():
+			if !ok {
+				return items, fmt.Errorf("expected %d items but the input was closed after %d", n, len(items))
+			}
+			items = append(items, item)
+		case <-deadline:
+			return items, fmt.Errorf("expected %d items but only %d were received before the timeout", n, len(items))
+		}
+	}
+	return items, nil
+}
+
+// Done returns a channel that is closed when the attached node has closed its output, even if
+// the items that it sent have not been pulled from the Probe yet.
+func (p *Probe[T]) Done() <-chan struct{} {
+	return p.done
+}
+
+// Kind returns KindTerminal, as the Probe does not forward the data to other nodes
+func (p *Probe[T]) Kind() NodeKind {
+	return KindTerminal
+}
+
+// Schema returns the input type of the Probe
+func (p *Probe[T]) Schema() Schema {
+	return Schema{In: p.inType}
+}
+
+func (p *Probe[T]) outputs() []graphNode {
+	return nil
+}
+
+func (p *Probe[T]) start(_ context.Context) {
+	// the data is pulled by the Next and Expect methods, so there is nothing to run
+	if !p.markStarted() {
+		return
+	}
+	p.notifyStart()
+}
+
+func (p *Probe[T]) finish() {
+	p.notifyFinish()
+	close(p.done)
+}
+
+// probeChannel is the input Channel of a Probe, which finishes the Probe when it is closed
+type probeChannel[T any] struct {
+	ch      chan T
+	onClose func()
+}
+
+func (c *probeChannel[T]) Send() chan<- T {
+	return c.ch
+}
+
+func (c *probeChannel[T]) Receive() <-chan T {
+	return c.ch
+}
+
+func (c *probeChannel[T]) Len() int {
+	return len(c.ch)
+}
+
+func (c *probeChannel[T]) Cap() int {
+	return cap(c.ch)
+}
+
+func (c *probeChannel[T]) Close() {
+	close(c.ch)
+	c.onClose()
+}
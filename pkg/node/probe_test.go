@@ -0,0 +1,79 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbe(t *testing.T) {
+	start := AsStart(Counter(1, 5))
+	odds := AsMiddle(OddFilter)
+	oddsMsg := AsMiddle(Messager("odd"))
+	startProbe := start.Probe()
+	oddsProbe := odds.Probe()
+	var collected []string
+	collector := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			collected = append(collected, s)
+		}
+	})
+	start.SendsTo(odds)
+	odds.SendsTo(oddsMsg)
+	oddsMsg.SendsTo(collector)
+	start.Start()
+
+	items, err := startProbe.Expect(5, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, items)
+
+	n, ok := oddsProbe.Next(timeout)
+	require.True(t, ok)
+	assert.Equal(t, 1, n)
+	items, err = oddsProbe.Expect(3, timeout)
+	assert.Error(t, err)
+	assert.Equal(t, []int{3, 5}, items)
+	_, ok = oddsProbe.Next(timeout)
+	assert.False(t, ok, "expected the probe input to be closed")
+
+	// the probes do not affect the rest of the graph
+	waitDone(t, collector.Done())
+	assert.Equal(t, []string{"odd: 1", "odd: 3", "odd: 5"}, collected)
+}
+
+func TestProbe_Timeout(t *testing.T) {
+	end := make(chan struct{})
+	defer close(end)
+	start := AsStart(func(out chan<- int) {
+		out <- 1
+		<-end
+	})
+	probe := start.Probe()
+	start.Start()
+
+	items, err := probe.Expect(2, 20*time.Millisecond)
+	assert.Error(t, err)
+	assert.Equal(t, []int{1}, items)
+	_, ok := probe.Next(10 * time.Millisecond)
+	assert.False(t, ok)
+}
+
+func TestProbe_DoneWithoutPulling(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	probe := start.Probe()
+	graph := NewGraph(start, probe)
+	require.NoError(t, graph.Start())
+
+	// the graph finishes even if the probe has not been pulled
+	waitDone(t, probe.Done())
+	require.NoError(t, graph.Shutdown(context.Background()))
+	assert.Equal(t, StateFinished, probe.State())
+
+	// the buffered items can still be pulled
+	items, err := probe.Expect(3, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, items)
+}
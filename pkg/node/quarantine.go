@@ -0,0 +1,87 @@
+package node
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// QuarantinedItem is an item that has failed to be processed after all the attempts of a
+// Quarantiner node, together with the information about its failures.
+type QuarantinedItem[T any] struct {
+	Item T
+	// Attempts is the number of times that the item has been processed unsuccessfully
+	Attempts int
+	// Err is the error returned by the last attempt, or the context error if the node has been
+	// cancelled before performing all the attempts
+	Err error
+	// Time is the time of the last failure
+	Time time.Time
+}
+
+// Quarantiner is a Middle node that retries the processing of the items that fail, and
+// quarantines the items that keep failing
+type Quarantiner[IN, OUT any] struct {
+	*Middle[IN, OUT]
+	// allocated separately to guarantee the 64-bit alignment of atomic operations
+	quarantined *int64
+}
+
+// Quarantine returns a Quarantiner node that converts each received item with the provided
+// function, and forwards the result. If the function returns an error, it is retried after the
+// delay provided by the node.WithBackoff option, up to maxAttempts times in total. The items
+// that still fail are not forwarded, and they are passed as a QuarantinedItem to onQuarantine
+// (e.g. to send them to a dedicated sink where the systematically problematic inputs can be
+// investigated). If onQuarantine is nil, the quarantined items are discarded.
+// The function is invoked with the context of the node. If the context is cancelled, the item
+// being retried is quarantined with the context error.
+// The node.WithClock option allows overriding the source of time.
+// It panics if maxAttempts is lower than 1.
+func Quarantine[IN, OUT any](
+	fun func(context.Context, IN) (OUT, error),
+	maxAttempts int,
+	onQuarantine func(QuarantinedItem[IN]),
+	opts ...Option,
+) *Quarantiner[IN, OUT] {
+	if maxAttempts < 1 {
+		panic("Quarantine requires at least one attempt")
+	}
+	options := getOptions(opts...)
+	clock, backoff := options.clock, options.backoff
+	quarantined := new(int64)
+	return &Quarantiner[IN, OUT]{
+		Middle: AsMiddleCtx(func(ctx context.Context, in <-chan IN, out chan<- OUT) {
+			for item := range in {
+				attempts := 0
+				for {
+					result, err := fun(ctx, item)
+					if err == nil {
+						out <- result
+						break
+					}
+					attempts++
+					if attempts < maxAttempts && sleepCtx(ctx, clock, backoff.Delay(attempts)) {
+						continue
+					}
+					if attempts < maxAttempts {
+						err = ctx.Err()
+					}
+					atomic.AddInt64(quarantined, 1)
+					if onQuarantine != nil {
+						onQuarantine(QuarantinedItem[IN]{
+							Item: item, Attempts: attempts, Err: err, Time: clock.Now(),
+						})
+					}
+					break
+				}
+			}
+		}, opts...),
+		quarantined: quarantined,
+	}
+}
+
+// Quarantined returns the number of items that have been quarantined. It can be invoked
+// concurrently with the node execution.
+func (q *Quarantiner[IN, OUT]) Quarantined() int64 {
+	return atomic.LoadInt64(q.quarantined)
+}
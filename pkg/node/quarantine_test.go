@@ -0,0 +1,87 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantine(t *testing.T) {
+	start := AsStart(Counter(1, 4))
+	calls := map[int]int{}
+	var quarantined []QuarantinedItem[int]
+	quarantine := Quarantine(func(_ context.Context, n int) (string, error) {
+		calls[n]++
+		switch {
+		case n == 2 && calls[n] == 1:
+			return "", errors.New("transient")
+		case n == 3:
+			return "", errors.New("poison " + strconv.Itoa(calls[n]))
+		}
+		return strconv.Itoa(n), nil
+	}, 3, func(q QuarantinedItem[int]) {
+		quarantined = append(quarantined, q)
+	}, WithBackoff(NewConstantBackoff(0)))
+	var received []string
+	term := collectStrings(&received)
+	start.SendsTo(quarantine)
+	quarantine.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []string{"1", "2", "4"}, received)
+	assert.Equal(t, map[int]int{1: 1, 2: 2, 3: 3, 4: 1}, calls)
+	if assert.Len(t, quarantined, 1) {
+		assert.Equal(t, 3, quarantined[0].Item)
+		assert.Equal(t, 3, quarantined[0].Attempts)
+		assert.EqualError(t, quarantined[0].Err, "poison 3")
+		assert.False(t, quarantined[0].Time.IsZero())
+	}
+	assert.EqualValues(t, 1, quarantine.Quarantined())
+}
+
+func TestQuarantine_Cancel(t *testing.T) {
+	start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		out <- 1
+		<-ctx.Done()
+	})
+	quarantined := make(chan QuarantinedItem[int], 1)
+	attempted := make(chan struct{}, 1)
+	quarantine := Quarantine(func(context.Context, int) (int, error) {
+		attempted <- struct{}{}
+		return 0, errors.New("failed")
+	}, 10, func(q QuarantinedItem[int]) {
+		quarantined <- q
+	}, WithBackoff(NewConstantBackoff(time.Hour)))
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+		}
+	})
+	start.SendsTo(quarantine)
+	quarantine.SendsTo(term)
+	ctx, cancel := context.WithCancel(context.Background())
+	start.StartCtx(ctx)
+
+	// cancel once the item is waiting for the next attempt
+	<-attempted
+	cancel()
+	select {
+	case q := <-quarantined:
+		assert.Equal(t, 1, q.Item)
+		assert.Equal(t, 1, q.Attempts)
+		assert.ErrorIs(t, q.Err, context.Canceled)
+	case <-time.After(timeout):
+		assert.Fail(t, "timeout while waiting for the quarantined item")
+	}
+	waitDone(t, term.Done())
+}
+
+func TestQuarantine_NoAttempts(t *testing.T) {
+	assert.Panics(t, func() {
+		Quarantine(func(_ context.Context, n int) (int, error) { return n, nil }, 0, nil)
+	})
+}
@@ -0,0 +1,193 @@
+package node
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateUnlimited is the limit of a RateLimiter that does not restrict the rate of items
+const RateUnlimited = math.MaxFloat64
+
+// RateLimiter is a Middle node that forwards the received items at a bounded rate. Its limit can
+// be changed while it is running.
+type RateLimiter[T any] struct {
+	*Middle[T, T]
+	bucket *tokenBucket
+}
+
+// RateLimit returns a RateLimiter node that forwards at most limit items per second, allowing
+// bursts of up to burst items. The items that exceed the rate wait until they can be forwarded,
+// so the previous nodes are backpressured. When the context passed to the node is cancelled, the
+// items stop waiting, so the pending items are forwarded without limit until the input is closed.
+// A limit of 0 or lower does not allow forwarding any item beyond the burst, and the
+// RateUnlimited limit does not restrict the rate.
+// The node.WithClock option allows overriding the source of time.
+func RateLimit[T any](limit float64, burst int, opts ...Option) *RateLimiter[T] {
+	clock := getOptions(opts...).clock
+	bucket := newTokenBucket(limit, burst, clock)
+	return &RateLimiter[T]{
+		Middle: AsMiddleCtx(func(ctx context.Context, in <-chan T, out chan<- T) {
+			for item := range in {
+				bucket.wait(ctx, 1)
+				out <- item
+			}
+		}, opts...),
+		bucket: bucket,
+	}
+}
+
+// SetLimit changes the limit and the burst of the RateLimiter. It takes effect immediately, even
+// for the item that is waiting to be forwarded. It can be invoked concurrently with the node
+// execution.
+func (r *RateLimiter[T]) SetLimit(limit float64, burst int) {
+	r.bucket.setLimit(limit, burst)
+}
+
+// Limit returns the current limit and burst of the RateLimiter.
+func (r *RateLimiter[T]) Limit() (limit float64, burst int) {
+	return r.bucket.getLimit()
+}
+
+// ByteRateLimit returns a Middle node that forwards the received items at a bounded rate of bytes
+// per second, where the size of each item is provided by the sizeOf function, so the large items
+// take proportionally more time than the small ones. It allows bursts of up to one second of
+// bytes. An item larger than the burst is forwarded when the burst is available, and the
+// following items wait until its excess is compensated.
+// The items that exceed the rate wait until they can be forwarded, so the previous nodes are
+// backpressured. When the context passed to the node is cancelled, the items stop waiting, so
+// the pending items are forwarded without limit until the input is closed.
+// The node.WithClock option allows overriding the source of time.
+func ByteRateLimit[T any](bytesPerSec int, sizeOf func(T) int, opts ...Option) *Middle[T, T] {
+	if bytesPerSec <= 0 {
+		panic("ByteRateLimit rate must be greater than zero")
+	}
+	clock := getOptions(opts...).clock
+	return AsMiddleCtx(func(ctx context.Context, in <-chan T, out chan<- T) {
+		bucket := newTokenBucket(float64(bytesPerSec), bytesPerSec, clock)
+		for item := range in {
+			bucket.wait(ctx, float64(sizeOf(item)))
+			out <- item
+		}
+	}, opts...)
+}
+
+// tokenBucket implements the token bucket algorithm: the bucket is filled with limit tokens per
+// second, up to burst tokens, and each forwarded item takes a number of tokens (e.g. one token,
+// or its size in bytes).
+type tokenBucket struct {
+	clock Clock
+
+	mt     sync.Mutex
+	limit  float64
+	burst  int
+	tokens float64
+	last   time.Time
+	// closed when the limit changes, to wake up the waiting items
+	changed chan struct{}
+}
+
+func newTokenBucket(limit float64, burst int, clock Clock) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		clock:   clock,
+		limit:   limit,
+		burst:   burst,
+		tokens:  float64(burst),
+		last:    clock.Now(),
+		changed: make(chan struct{}),
+	}
+}
+
+// wait blocks until n tokens are available, and takes them. It returns early, without taking
+// them, if the context is cancelled.
+func (b *tokenBucket) wait(ctx context.Context, n float64) {
+	for {
+		delay, changed := b.take(n)
+		if delay == 0 {
+			return
+		}
+		if delay < 0 {
+			// no tokens will be added until the limit changes
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		timer := b.clock.NewTimer(delay)
+		select {
+		case <-timer.C():
+		case <-changed:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// take takes n tokens, returning 0, or returns the time until they will be available, and a
+// channel that is closed if the limit changes before. The returned time is negative if the tokens
+// will never be available with the current limit.
+// If n is greater than the burst, the tokens are taken when the bucket is full, leaving it in
+// debt, so the average rate is respected.
+func (b *tokenBucket) take(n float64) (time.Duration, <-chan struct{}) {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	b.refill()
+	need := math.Min(n, float64(b.burst))
+	if b.tokens >= need {
+		b.tokens -= n
+		return 0, nil
+	}
+	if b.limit <= 0 {
+		return -1, b.changed
+	}
+	delay := time.Duration((need - b.tokens) / b.limit * float64(time.Second))
+	if delay <= 0 {
+		// rounding error
+		delay = time.Nanosecond
+	}
+	return delay, b.changed
+}
+
+// refill adds the tokens for the time elapsed since the last refill. It must be invoked with the
+// mutex locked.
+func (b *tokenBucket) refill() {
+	now := b.clock.Now()
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	if b.limit >= RateUnlimited {
+		b.tokens = float64(b.burst)
+		return
+	}
+	if b.limit > 0 && elapsed > 0 {
+		b.tokens = math.Min(float64(b.burst), b.tokens+elapsed*b.limit)
+	}
+}
+
+func (b *tokenBucket) setLimit(limit float64, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	// the tokens until now are added at the previous rate
+	b.refill()
+	b.limit = limit
+	b.burst = burst
+	b.tokens = math.Min(float64(burst), b.tokens)
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+func (b *tokenBucket) getLimit() (float64, int) {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	return b.limit, b.burst
+}
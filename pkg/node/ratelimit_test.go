@@ -0,0 +1,110 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+)
+
+func TestRateLimit(t *testing.T) {
+	clock := nodetest.NewManualClock()
+	start := AsStart(Counter(1, 11))
+	limit := RateLimit[int](100, 1, WithClock(clock))
+	probe := limit.Probe()
+	start.SendsTo(limit)
+	require.NoError(t, NewGraph(start, limit, probe).Start())
+
+	// the first item is sent immediately, and the rest every 10ms
+	items, err := probe.Expect(1, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, items)
+	for n := 2; n <= 11; n++ {
+		assert.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+		clock.Advance(9 * time.Millisecond)
+		nodetest.ExpectBlocked(t, probe.inputs.Receiver())
+		clock.Advance(time.Millisecond)
+		nodetest.ExpectReceives(t, probe.inputs.Receiver(), n, timeout)
+	}
+	nodetest.ExpectClosed(t, probe.inputs.Receiver(), timeout)
+}
+
+func TestRateLimit_SetLimit(t *testing.T) {
+	start := AsStart(Counter(1, 5))
+	// only the burst is allowed
+	limit := RateLimit[int](0, 2)
+	probe := limit.Probe()
+	start.SendsTo(limit)
+	start.Start()
+
+	items, err := probe.Expect(2, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, items)
+	_, ok := probe.Next(50 * time.Millisecond)
+	require.False(t, ok)
+
+	// the waiting item is released immediately
+	limit.SetLimit(RateUnlimited, 1)
+	items, err = probe.Expect(3, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 4, 5}, items)
+	l, burst := limit.Limit()
+	assert.Equal(t, RateUnlimited, l)
+	assert.Equal(t, 1, burst)
+}
+
+func TestByteRateLimit(t *testing.T) {
+	clock := nodetest.NewManualClock()
+	start := AsStart(func(out chan<- []byte) {
+		// larger than the burst, so it leaves the bucket in debt
+		out <- make([]byte, 110_000)
+		out <- make([]byte, 1)
+	})
+	limit := ByteRateLimit(100_000, func(b []byte) int { return len(b) }, WithClock(clock))
+	probe := limit.Probe()
+	start.SendsTo(limit)
+	start.Start()
+
+	item, ok := probe.Next(timeout)
+	require.True(t, ok)
+	assert.Len(t, item, 110_000)
+	// the second item waits until the 10KB of debt, plus its own byte, are refilled
+	assert.Eventually(t, func() bool { return clock.Timers() == 1 }, timeout, time.Millisecond)
+	clock.Advance(100 * time.Millisecond)
+	nodetest.ExpectBlocked(t, probe.inputs.Receiver())
+	clock.Advance(time.Millisecond)
+	item, ok = probe.Next(timeout)
+	require.True(t, ok)
+	assert.Len(t, item, 1)
+}
+
+func TestByteRateLimit_Cancel(t *testing.T) {
+	start := AsStartCtx(func(_ context.Context, out chan<- []byte) {
+		for i := 0; i < 3; i++ {
+			out <- make([]byte, 100)
+		}
+	})
+	// one item per second
+	limit := ByteRateLimit(100, func(b []byte) int { return len(b) })
+	var received int
+	term := AsTerminal(func(in <-chan []byte) {
+		for range in {
+			received++
+		}
+	})
+	start.SendsTo(limit)
+	limit.SendsTo(term)
+	ctx, cancel := context.WithCancel(context.Background())
+	start.StartCtx(ctx)
+	cancel()
+
+	// after cancelling, the items do not wait: they are either received or abandoned
+	waitDone(t, term.Done())
+	assert.Eventually(t, func() bool {
+		return int64(received)+start.Stats().Abandoned+limit.Stats().Abandoned == 3
+	}, timeout, time.Millisecond)
+}
@@ -0,0 +1,183 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/netobserv/gopipes/pkg/node/internal/connect"
+)
+
+// receiverBase implements the functionalities that are common to all the nodes that receive data
+// from other nodes.
+type receiverBase[IN any] struct {
+	inputs connect.Joiner[IN]
+	// 1 if the node has been started
+	started int32
+	inType  reflect.Type
+	// nil if the node does not measure the queue wait time
+	queueWait *LatencyHistogram
+}
+
+func newReceiverBase[IN any](options *creationOptions) receiverBase[IN] {
+	var in IN
+	return receiverBase[IN]{
+		inputs:    newJoiner[IN](options),
+		inType:    reflect.TypeOf(in),
+		queueWait: options.queueWait,
+	}
+}
+
+// inputClosed returns whether all the senders of the node have closed their outputs
+func (r *receiverBase[IN]) inputClosed() bool {
+	return r.inputs.Closed()
+}
+
+func (r *receiverBase[IN]) joiner() *connect.Joiner[IN] {
+	return &r.inputs
+}
+
+func (r *receiverBase[IN]) isStarted() bool {
+	return atomic.LoadInt32(&r.started) == 1
+}
+
+// markStarted marks the node as started, returning false if it was already started. It allows
+// the node to be started only once, even if multiple sender nodes are started concurrently, so
+// the node function runs in a single goroutine.
+func (r *receiverBase[IN]) markStarted() bool {
+	return atomic.CompareAndSwapInt32(&r.started, 0, 1)
+}
+
+// SetBufferLen changes the length of the node input buffer while the node is running. If the
+// buffer holds more items than the new length, they are kept, and the senders are blocked until
+// the buffer is below it. It panics if the node has not been created with the
+// node.ResizableBuffer option.
+func (r *receiverBase[IN]) SetBufferLen(length int) {
+	if length < 0 {
+		panic("buffer length can't be negative")
+	}
+	if !r.inputs.SetCap(length) {
+		panic("the buffer length can only be changed in the nodes created with the ResizableBuffer option")
+	}
+}
+
+// useMemoryBudget bounds the buffered input of the node by the budget, as a graph-wide limit set
+// with Graph.WithMemoryBudget. It must be invoked before the node is started.
+func (r *receiverBase[IN]) useMemoryBudget(mb *MemoryBudget) {
+	r.inputs.UseBudget(mb.budget, func(item IN) int { return mb.sizeOf(item) })
+}
+
+// InType returns the inner type of the node input channel
+func (r *receiverBase[IN]) InType() reflect.Type {
+	return r.inType
+}
+
+// Stats returns runtime information about the node
+func (r *receiverBase[IN]) Stats() Stats {
+	stats := Stats{BufferLen: r.inputs.Len(), BufferCap: r.inputs.Cap()}
+	if r.queueWait != nil {
+		queueWait := r.queueWait.Snapshot()
+		stats.QueueWait = &queueWait
+	}
+	return stats
+}
+
+// connectTo registers the sender in the input of the provided receivers, and returns them
+// appended to the current receivers of the sender. It must be invoked each time a node is
+// connected to the receivers, so their input is not closed until all the nodes sending data to
+// them have finished.
+// The receivers accumulate across invocations, and all of them are started when the sender is
+// started. Connecting receivers to a sender that has already been started panics, as they would
+// never receive any data nor have their input closed.
+// Connecting a receiver that is already connected to the sender panics, unless the sender has
+// been created with the node.DedupeReceivers option. Then, the duplicate receivers are ignored.
+func connectTo[T any](sender *nodeMeta, current, receivers []Receiver[T]) []Receiver[T] {
+	if atomic.LoadInt32(&sender.forked) == 1 {
+		panic(fmt.Sprintf("node %s is already started. Its receivers must be connected before"+
+			" starting it", sender.Name()))
+	}
+	for _, r := range receivers {
+		if containsReceiver(current, r) {
+			if sender.dedupeReceivers {
+				continue
+			}
+			panic(fmt.Sprintf("node %s is already connected to node %s. Use the DedupeReceivers"+
+				" option to ignore duplicate connections", sender.Name(), r.(Node).Name()))
+		}
+		r.joiner().AddSender()
+		if sender.edgeCounts != nil && sender.edgeCounts[r] == nil {
+			sender.edgeCounts[r] = new(int64)
+		}
+		current = append(current, r)
+	}
+	return current
+}
+
+// panicNoOutputs reports a node that is started without outputs. SendsTo accepts being invoked
+// without receivers (e.g. from a loop over an empty slice), so the panic points to the node whose
+// connections are missing.
+func panicNoOutputs(n *nodeMeta) {
+	panic(fmt.Sprintf("node %s should have outputs. Check that its SendsTo method is invoked"+
+		" with at least one receiver before starting it", n.Name()))
+}
+
+func containsReceiver[T any](receivers []Receiver[T], r Receiver[T]) bool {
+	return receiverIndex(receivers, r) >= 0
+}
+
+// receiverIndex returns the index of the receiver in the list, or -1 if it is not there
+func receiverIndex[T any](receivers []Receiver[T], r Receiver[T]) int {
+	for i, c := range receivers {
+		if c == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// forkTo starts the provided receivers with the given context, if they are not started yet, and returns a forker that
+// sends data to all of them until the context is cancelled. If the sender counts its edges, the
+// forker counts the items that are sent to each receiver, and the items emitted by the sender.
+func forkTo[T any](ctx context.Context, sender *nodeMeta, receivers []Receiver[T]) connect.Forker[T] {
+	return forkCounting(ctx, sender, receivers, sender.emitted)
+}
+
+// forkCounting works as forkTo, but the forker counts the emitted items in the provided counter,
+// which can be nil. This allows the nodes that send each item through multiple forkers (e.g.
+// node.Exchange) to count the emitted items by themselves, once per item.
+func forkCounting[T any](ctx context.Context, sender *nodeMeta, receivers []Receiver[T], emitted *int64) connect.Forker[T] {
+	atomic.StoreInt32(&sender.forked, 1)
+	joiners := make([]*connect.Joiner[T], 0, len(receivers))
+	var counters []*int64
+	var filters []func(any) bool
+	for i, out := range receivers {
+		joiners = append(joiners, out.joiner())
+		if sender.edgeCounts != nil {
+			counters = append(counters, sender.edgeCounts[out])
+		}
+		// the canaries are removed before they reach the input of the detector nodes
+		if detector, ok := out.(canaryDetector); ok && detector.detectsCanaries() {
+			if filters == nil {
+				filters = make([]func(any) bool, len(receivers))
+			}
+			filters[i] = func(item any) bool { return !detector.catchCanary(item) }
+		}
+		if !out.isStarted() {
+			out.start(ctx)
+		}
+	}
+	forkCtx := ctx
+	if sender.flushOnCancel {
+		forkCtx = context.Background()
+	}
+	return connect.ForkWith(forkCtx, connect.ForkOptions{
+		Counters:     counters,
+		Filters:      filters,
+		CloseTimeout: sender.closeTimeout,
+		NewTimer:     sender.clock.NewTimer,
+		Abandoned:    sender.abandoned,
+		Sent:         emitted,
+		OnFork:       sender.onFork,
+	}, joiners...)
+}
@@ -0,0 +1,157 @@
+package node
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// the records of RecordTo are prefixed by a 12-byte header: the Unix time in nanoseconds when the
+// item was recorded, as an 8-byte big endian integer, and the length of the encoded item, as a
+// 4-byte big endian integer
+const recordHeaderLen = 12
+
+// RecordTo returns a Middle node that forwards the received items unchanged, while it writes
+// them into w, encoded with the provided codec, together with the time when they were received.
+// The recorded stream can be replayed later with ReplayFrom (e.g. to reproduce a production issue
+// or for load testing from real data). Each record is flushed as soon as it is written. If the
+// codec is nil, the items are encoded with the Codec of the node.WithCodec option, or with
+// node.GobCodec by default.
+// If an item can't be encoded or written, the node stops recording, but it keeps forwarding the
+// items. It also returns a function that provides the error that stopped the recording, if any,
+// once the node has finished. The function blocks until the Done channel of the Middle is closed.
+// The node.WithClock option allows overriding the source of the recorded time.
+func RecordTo[T any](w io.Writer, codec Codec[T], opts ...Option) (*Middle[T, T], func() error) {
+	options := getOptions(opts...)
+	if codec == nil {
+		codec = codecOf[T](&options)
+	}
+	clock := options.clock
+	var recordErr error
+	middle := AsMiddle(func(in <-chan T, out chan<- T) {
+		bw := bufio.NewWriter(w)
+		for item := range in {
+			if recordErr == nil {
+				recordErr = writeRecord(bw, clock.Now(), item, codec)
+			}
+			out <- item
+		}
+	}, opts...)
+	return middle, func() error {
+		<-middle.Done()
+		return recordErr
+	}
+}
+
+func writeRecord[T any](w *bufio.Writer, ts time.Time, item T, codec Codec[T]) error {
+	payload, err := codec.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("encoding item: %w", err)
+	}
+	if len(payload) > maxFrameLen {
+		return fmt.Errorf("encoded item is too large: %d bytes", len(payload))
+	}
+	var header [recordHeaderLen]byte
+	binary.BigEndian.PutUint64(header[:8], uint64(ts.UnixNano()))
+	binary.BigEndian.PutUint32(header[8:], uint32(len(payload)))
+	if _, err := w.Write(header[:]); err != nil {
+		return fmt.Errorf("writing record: %w", err)
+	}
+	if _, err := w.Write(payload); err != nil {
+		return fmt.Errorf("writing record: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("writing record: %w", err)
+	}
+	return nil
+}
+
+// ReplayFrom returns a Start node that sends the items that have been recorded into r by
+// RecordTo, decoded with the provided codec. If the codec is nil, the items are decoded with the
+// Codec of the node.WithCodec option, or with node.GobCodec by default.
+// By default, the items are sent as fast as possible. The node.PreserveTiming option makes the
+// node wait between items the same time that passed between them when they were recorded.
+// The node finishes when all the records have been read, or when the context passed to the node
+// is cancelled. If a record can't be read or decoded, the node stops. It also returns a function
+// that provides the error that stopped the node, if any, once the node has finished. The
+// function blocks until the Done channel of the Start node is closed.
+// The node.WithClock option allows overriding the source of time of node.PreserveTiming.
+func ReplayFrom[T any](r io.Reader, codec Codec[T], opts ...Option) (*Start[T], func() error) {
+	options := getOptions(opts...)
+	if codec == nil {
+		codec = codecOf[T](&options)
+	}
+	var replayErr error
+	start := AsStartCtx(func(ctx context.Context, out chan<- T) {
+		br := bufio.NewReader(r)
+		var previous time.Time
+		for {
+			ts, item, err := readRecord(br, codec)
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					replayErr = err
+				}
+				return
+			}
+			if options.preserveTiming && !previous.IsZero() && ts.After(previous) {
+				if !sleepCtx(ctx, options.clock, ts.Sub(previous)) {
+					return
+				}
+			}
+			previous = ts
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, opts...)
+	return start, func() error {
+		<-start.Done()
+		return replayErr
+	}
+}
+
+// readRecord reads a record that has been written by writeRecord. It returns io.EOF if there
+// are no more records.
+func readRecord[T any](r *bufio.Reader, codec Codec[T]) (time.Time, T, error) {
+	var item T
+	var header [recordHeaderLen]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return time.Time{}, item, io.EOF
+		}
+		return time.Time{}, item, fmt.Errorf("reading record: %w", err)
+	}
+	ts := time.Unix(0, int64(binary.BigEndian.Uint64(header[:8])))
+	length := binary.BigEndian.Uint32(header[8:])
+	if length > maxFrameLen {
+		return ts, item, fmt.Errorf("record is too large: %d bytes", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return ts, item, fmt.Errorf("reading record: %w", err)
+	}
+	item, err := codec.Unmarshal(payload)
+	if err != nil {
+		return ts, item, fmt.Errorf("decoding record: %w", err)
+	}
+	return ts, item, nil
+}
+
+// sleepCtx waits for the provided duration, returning true, or until the context is cancelled,
+// returning false
+func sleepCtx(ctx context.Context, clock Clock, d time.Duration) bool {
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
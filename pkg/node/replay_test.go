@@ -0,0 +1,93 @@
+package node
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReplay(t *testing.T) {
+	recorded := time.Date(2022, 3, 4, 12, 0, 0, 0, time.UTC)
+	buf := &bytes.Buffer{}
+	start := AsStart(func(out chan<- netEvent) {
+		out <- netEvent{Name: "a", Bytes: 1}
+		out <- netEvent{Name: "b", Bytes: 2}
+	})
+	record, recordErr := RecordTo[netEvent](buf, JSONCodec[netEvent](), WithClock(fixedClock(recorded)))
+	var forwarded []netEvent
+	term := ForEach(func(e netEvent) { forwarded = append(forwarded, e) })
+	start.SendsTo(record)
+	record.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	require.NoError(t, recordErr())
+	// the items are forwarded unchanged
+	assert.Equal(t, []netEvent{{"a", 1}, {"b", 2}}, forwarded)
+
+	replay, replayErr := ReplayFrom[netEvent](buf, JSONCodec[netEvent]())
+	var replayed []netEvent
+	replayTerm := ForEach(func(e netEvent) { replayed = append(replayed, e) })
+	replay.SendsTo(replayTerm)
+	replay.Start()
+
+	waitDone(t, replayTerm.Done())
+	require.NoError(t, replayErr())
+	assert.Equal(t, []netEvent{{"a", 1}, {"b", 2}}, replayed)
+}
+
+func TestReplayFrom_PreserveTiming(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	begin := time.Date(2022, 3, 4, 12, 0, 0, 0, time.UTC)
+	for i, offset := range []time.Duration{0, 30 * time.Millisecond, 60 * time.Millisecond} {
+		require.NoError(t, writeRecord(w, begin.Add(offset), i, GobCodec[int]()))
+	}
+
+	replay, replayErr := ReplayFrom[int](buf, nil, PreserveTiming())
+	var arrivals []time.Time
+	term := AsTerminal(func(in <-chan int) {
+		for range in {
+			arrivals = append(arrivals, time.Now())
+		}
+	})
+	replay.SendsTo(term)
+	replay.Start()
+
+	waitDone(t, term.Done())
+	require.NoError(t, replayErr())
+	require.Len(t, arrivals, 3)
+	assert.GreaterOrEqual(t, arrivals[1].Sub(arrivals[0]), 25*time.Millisecond)
+	assert.GreaterOrEqual(t, arrivals[2].Sub(arrivals[1]), 25*time.Millisecond)
+}
+
+func TestReplayFrom_Errors(t *testing.T) {
+	// truncated record
+	replay, replayErr := ReplayFrom[int](bytes.NewReader([]byte{0, 0, 0}), nil)
+	replay.SendsTo(Blackhole[int]())
+	replay.Start()
+	assert.Error(t, replayErr())
+
+	// the context is cancelled while waiting for the next item
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	begin := time.Date(2022, 3, 4, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, writeRecord(w, begin, 1, GobCodec[int]()))
+	require.NoError(t, writeRecord(w, begin.Add(time.Hour), 2, GobCodec[int]()))
+	replay, replayErr = ReplayFrom[int](buf, nil, PreserveTiming())
+	received := make(chan int, 10)
+	term := ForEach(func(n int) { received <- n })
+	replay.SendsTo(term)
+	ctx, cancel := context.WithCancel(context.Background())
+	replay.StartCtx(ctx)
+	assert.Equal(t, 1, <-received)
+	cancel()
+	waitDone(t, term.Done())
+	assert.NoError(t, replayErr())
+	assert.Empty(t, received)
+}
@@ -0,0 +1,32 @@
+package node
+
+// Reservoir returns a Terminal node that keeps a uniform random sample of at most k items
+// from all the items it receives, using the reservoir sampling algorithm. This allows
+// sampling an unbounded stream without storing all its items.
+// The returned function provides the sampled items. It blocks until the Done channel of the
+// Terminal is closed.
+// The node.RandSeed or node.WithRandSource options can be used to make the sampling
+// deterministic.
+func Reservoir[T any](k int, opts ...Option) (*Terminal[T], func() []T) {
+	if k <= 0 {
+		panic("reservoir size must be greater than zero")
+	}
+	options := getOptions(opts...)
+	rnd := options.random()
+	sample := make([]T, 0, k)
+	term := AsTerminal(func(in <-chan T) {
+		seen := 0
+		for item := range in {
+			if seen < k {
+				sample = append(sample, item)
+			} else if j := rnd.Intn(seen + 1); j < k {
+				sample[j] = item
+			}
+			seen++
+		}
+	}, opts...)
+	return term, func() []T {
+		<-term.Done()
+		return append([]T{}, sample...)
+	}
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservoir(t *testing.T) {
+	runSample := func(seed int64) []int {
+		start := AsStart(Counter(1, 1000))
+		term, sample := Reservoir[int](10, RandSeed(seed))
+		start.SendsTo(term)
+		start.Start()
+		waitDone(t, term.Done())
+		return sample()
+	}
+	sample := runSample(123)
+	require.Len(t, sample, 10)
+	seen := map[int]struct{}{}
+	for _, n := range sample {
+		assert.GreaterOrEqual(t, n, 1)
+		assert.LessOrEqual(t, n, 1000)
+		assert.NotContains(t, seen, n)
+		seen[n] = struct{}{}
+	}
+	// same seed provides the same sample
+	assert.Equal(t, sample, runSample(123))
+	assert.NotEqual(t, sample, runSample(456))
+}
+
+func TestReservoir_WithRandSource(t *testing.T) {
+	runSample := func(opts ...Option) []int {
+		start := AsStart(Counter(1, 1000))
+		term, sample := Reservoir[int](10, opts...)
+		start.SendsTo(term)
+		start.Start()
+		waitDone(t, term.Done())
+		return sample()
+	}
+	sample := runSample(WithRandSource(rand.NewSource(123)))
+	assert.Equal(t, sample, runSample(WithRandSource(rand.NewSource(123))))
+	// the source takes precedence over the seed
+	assert.Equal(t, sample, runSample(RandSeed(456), WithRandSource(rand.NewSource(123))))
+}
+
+func TestReservoir_LessItemsThanSize(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	term, sample := Reservoir[int](10)
+	start.SendsTo(term)
+	start.Start()
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{1, 2, 3}, sample())
+}
+
+func TestReservoir_SampleWaitsForDone(t *testing.T) {
+	input := make(chan int)
+	start := AsStart(func(out chan<- int) {
+		for n := range input {
+			out <- n
+		}
+	})
+	term, sample := Reservoir[int](10)
+	start.SendsTo(term)
+	start.Start()
+	input <- 1
+
+	sampled := make(chan []int)
+	go func() { sampled <- sample() }()
+	nodetest.ExpectBlocked(t, sampled)
+	input <- 2
+	close(input)
+	nodetest.ExpectReceives(t, sampled, []int{1, 2}, timeout)
+}
@@ -0,0 +1,57 @@
+package node
+
+// Result carries either a value or an error, so the errors can flow through the same channels as
+// the data (railway-oriented style) instead of being reported in a side channel.
+// A Result whose Err is nil is successful, and its Value is meaningful.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Ok returns a successful Result carrying the provided value
+func Ok[T any](value T) Result[T] {
+	return Result[T]{Value: value}
+}
+
+// Err returns a failed Result carrying the provided error
+func Err[T any](err error) Result[T] {
+	return Result[T]{Err: err}
+}
+
+// IsOk returns true if the Result is successful
+func (r Result[T]) IsOk() bool {
+	return r.Err == nil
+}
+
+// MapResult returns a Middle node that applies fun to the value of each successful Result, and
+// sends the returned value, or the returned error, as a new Result. The failed Results are
+// forwarded untouched, skipping fun, so the error reaches the end of the pipeline.
+func MapResult[IN, OUT any](fun func(IN) (OUT, error), opts ...Option) *Middle[Result[IN], Result[OUT]] {
+	return Map(func(r Result[IN]) Result[OUT] {
+		if r.Err != nil {
+			return Err[OUT](r.Err)
+		}
+		out, err := fun(r.Value)
+		return Result[OUT]{Value: out, Err: err}
+	}, opts...)
+}
+
+// PartitionResults returns a Terminal node that splits the received Results into the values of
+// the successful ones and the errors of the failed ones, in their order of arrival.
+// It also returns a function that provides both once the Terminal node has finished. As for
+// ToMap, the function blocks until the Done channel of the Terminal is closed.
+func PartitionResults[T any](opts ...Option) (*Terminal[Result[T]], func() ([]T, []error)) {
+	var oks []T
+	var errs []error
+	term := ForEach(func(r Result[T]) {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		} else {
+			oks = append(oks, r.Value)
+		}
+	}, opts...)
+	return term, func() ([]T, []error) {
+		<-term.Done()
+		return oks, errs
+	}
+}
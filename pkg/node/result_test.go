@@ -0,0 +1,50 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResults(t *testing.T) {
+	start := AsStart(func(out chan<- Result[string]) {
+		out <- Ok("1")
+		out <- Ok("two")
+		out <- Err[string](errors.New("read failed"))
+		out <- Ok("4")
+	})
+	parse := MapResult(strconv.Atoi)
+	calls := 0
+	invert := MapResult(func(n int) (float64, error) {
+		calls++
+		if n == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return 1 / float64(n), nil
+	})
+	term, results := PartitionResults[float64]()
+	start.SendsTo(parse)
+	parse.SendsTo(invert)
+	invert.SendsTo(term)
+	start.Start()
+
+	oks, errs := results()
+	assert.Equal(t, []float64{1, 0.25}, oks)
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, fmt.Sprint(err))
+	}
+	assert.Len(t, msgs, 2)
+	assert.Contains(t, msgs[0], "two")
+	assert.Equal(t, "read failed", msgs[1])
+	// the failed results skipped the second stage
+	assert.Equal(t, 2, calls)
+}
+
+func TestResult_IsOk(t *testing.T) {
+	assert.True(t, Ok(0).IsOk())
+	assert.False(t, Err[int](errors.New("fail")).IsOk())
+}
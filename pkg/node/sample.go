@@ -0,0 +1,53 @@
+package node
+
+import (
+	"context"
+	"time"
+)
+
+// SampleLatest returns a Middle node that conflates the received items: it keeps only the latest
+// received item and sends it every interval, discarding the intermediate items. The ticks
+// without new items since the previous tick send nothing. When the input is closed, the latest
+// item is sent if it has not been sent yet. The same happens if the context of the node is
+// cancelled, after taking the items that were queued in the input. The rest of the input is
+// discarded.
+// It suits the consumers that only care about the most recent value (e.g. a dashboard).
+// The node.WithClock option allows overriding the source of time.
+func SampleLatest[T any](interval time.Duration, opts ...Option) *Middle[T, T] {
+	clock := getOptions(opts...).clock
+	sample := AsMiddleCtx(func(ctx context.Context, in <-chan T, out chan<- T) {
+		timer := clock.NewTimer(interval)
+		defer timer.Stop()
+		var latest T
+		pending := false
+		for {
+			select {
+			case item, ok := <-in:
+				if !ok {
+					if pending {
+						out <- latest
+					}
+					return
+				}
+				latest, pending = item, true
+			case <-timer.C():
+				if pending {
+					out <- latest
+					var zero T
+					latest, pending = zero, false
+				}
+				timer.Reset(interval)
+			case <-ctx.Done():
+				if queued := takeQueued(in); len(queued) > 0 {
+					latest, pending = queued[len(queued)-1], true
+				}
+				if pending {
+					out <- latest
+				}
+				return
+			}
+		}
+	}, opts...)
+	sample.flushOnCancel = true
+	return sample
+}
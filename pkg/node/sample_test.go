@@ -0,0 +1,47 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleLatest(t *testing.T) {
+	updates := make(chan int)
+	start := AsStart(func(out chan<- int) {
+		for n := range updates {
+			out <- n
+		}
+	})
+	sample := SampleLatest[int](20 * time.Millisecond)
+	probe := sample.Probe()
+	start.SendsTo(sample)
+	start.Start()
+
+	// the intermediate updates are discarded
+	for n := 1; n <= 3; n++ {
+		updates <- n
+	}
+	items, err := probe.Expect(1, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, items)
+
+	// the ticks without updates send nothing
+	_, ok := probe.Next(50 * time.Millisecond)
+	assert.False(t, ok)
+
+	// the last update is sent when the input is closed, even if no tick happened
+	updates <- 4
+	close(updates)
+	items, err = probe.Expect(1, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4}, items)
+	waitDone(t, sample.Done())
+}
+
+func TestSampleLatest_Cancel(t *testing.T) {
+	assert.Equal(t, [2][]int{{3}, {3}},
+		runCancelled(t, SampleLatest[int](time.Hour, ChannelBufferLen(10)), 1, 2, 3))
+}
@@ -0,0 +1,241 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Sequenced wraps an item together with a sequence number, which allows restoring the original
+// order of the items after they have been processed by stages that do not preserve it (e.g.
+// node.Parallel).
+type Sequenced[T any] struct {
+	Seq   uint64
+	Value T
+}
+
+// Sequence returns a Middle node that wraps each received item into a Sequenced item, whose
+// sequence number increases monotonically from 0, in the order the items are received.
+// If the node is created with the node.WithReorderBuffer option and a ReorderBlock buffer, it
+// waits before sending each item until the item fits in the buffer of the Reorder node. If the
+// context of the node is cancelled while waiting, the rest of items are discarded.
+func Sequence[T any](opts ...Option) *Middle[T, Sequenced[T]] {
+	buffer := getOptions(opts...).reorderBuffer
+	if buffer != nil && buffer.policy != ReorderBlock {
+		buffer = nil
+	}
+	return AsMiddleCtx(func(ctx context.Context, in <-chan T, out chan<- Sequenced[T]) {
+		seq := uint64(0)
+		for item := range in {
+			if buffer != nil && !buffer.waitFor(ctx, seq) {
+				drain(in)
+				return
+			}
+			out <- Sequenced[T]{Seq: seq, Value: item}
+			seq++
+		}
+	}, opts...)
+}
+
+// Unsequence returns a Middle node that unwraps the value of each received Sequenced item.
+func Unsequence[T any](opts ...Option) *Middle[Sequenced[T], T] {
+	return AsMiddle(func(in <-chan Sequenced[T], out chan<- T) {
+		for item := range in {
+			out <- item.Value
+		}
+	}, opts...)
+}
+
+// Reorder returns a Middle node that forwards the received Sequenced items in order of
+// sequence number, starting from 0. The items that arrive before their predecessors are kept in
+// a buffer until all their predecessors have been forwarded.
+// All the sequence numbers must eventually arrive to this node: if an intermediate stage
+// discards an item, the successor items are kept in the buffer until the input is closed.
+// Then, the buffered items are forwarded in order.
+// The node.WithReorderBuffer option bounds the size of the buffer, and allows observing its
+// depth.
+func Reorder[T any](opts ...Option) *Middle[Sequenced[T], Sequenced[T]] {
+	buffer := getOptions(opts...).reorderBuffer
+	return AsMiddle(func(in <-chan Sequenced[T], out chan<- Sequenced[T]) {
+		next := uint64(0)
+		pending := map[uint64]Sequenced[T]{}
+		for item := range in {
+			switch {
+			case item.Seq == next:
+				out <- item
+				next++
+			case buffer != nil && item.Seq < next:
+				// the item arrived after its gap was skipped
+				buffer.discard()
+				continue
+			default:
+				pending[item.Seq] = item
+				buffer.update(next, len(pending))
+				if buffer == nil || buffer.policy != ReorderError || len(pending) <= buffer.max {
+					continue
+				}
+				// stop waiting for the missing items, and forward the buffered items from the
+				// lowest sequence number
+				next = lowestSeq(pending)
+				buffer.overflow()
+			}
+			for p, ok := pending[next]; ok; p, ok = pending[next] {
+				delete(pending, next)
+				out <- p
+				next++
+			}
+			buffer.update(next, len(pending))
+		}
+		remaining := make([]Sequenced[T], 0, len(pending))
+		for _, p := range pending {
+			remaining = append(remaining, p)
+		}
+		sort.Slice(remaining, func(i, j int) bool {
+			return remaining[i].Seq < remaining[j].Seq
+		})
+		for _, p := range remaining {
+			out <- p
+		}
+		buffer.update(next, 0)
+	}, opts...)
+}
+
+func lowestSeq[T any](items map[uint64]Sequenced[T]) uint64 {
+	first := true
+	lowest := uint64(0)
+	for seq := range items {
+		if first || seq < lowest {
+			lowest = seq
+			first = false
+		}
+	}
+	return lowest
+}
+
+// ReorderPolicy defines the behavior of a Reorder node when its buffer reaches the size limit of
+// a ReorderBuffer.
+type ReorderPolicy int
+
+const (
+	// ReorderBlock keeps the buffer size below the limit by blocking the Sequence node that
+	// creates the sequence numbers, which must be created with the same ReorderBuffer: it
+	// waits before sending each item until all the items whose sequence number is lower by
+	// the limit or more have been forwarded by the Reorder node. If an intermediate stage
+	// discards an item, the Sequence node blocks forever.
+	ReorderBlock ReorderPolicy = iota
+	// ReorderError stops waiting for the missing items when the buffer exceeds the limit: the
+	// buffered items are forwarded in order from the lowest sequence number, and the missing
+	// items are discarded if they arrive later. The ReorderBuffer reports ErrReorderOverflow.
+	ReorderError
+)
+
+// ErrReorderOverflow is reported by a ReorderBuffer with the ReorderError policy when the buffer
+// of the Reorder node has exceeded its limit.
+var ErrReorderOverflow = errors.New("reorder buffer overflow")
+
+// ReorderBuffer bounds the number of items that a Reorder node keeps in its buffer while waiting
+// for the missing predecessors, and provides information about the buffer depth.
+// It is passed to the Reorder node (and to the Sequence node, for the ReorderBlock policy)
+// through the node.WithReorderBuffer option. Its methods can be invoked concurrently with the
+// node execution.
+type ReorderBuffer struct {
+	max    int
+	policy ReorderPolicy
+
+	mt sync.Mutex
+	// closed and replaced each time the state of the Reorder node changes
+	changed chan struct{}
+	// next sequence number to be forwarded by the Reorder node
+	next      uint64
+	depth     int
+	peak      int
+	discarded int
+	err       error
+}
+
+// NewReorderBuffer creates a ReorderBuffer whose limit is max items, with the provided policy to
+// apply when the limit is reached.
+func NewReorderBuffer(max int, policy ReorderPolicy) *ReorderBuffer {
+	if max <= 0 {
+		panic("reorder buffer limit must be greater than zero")
+	}
+	return &ReorderBuffer{max: max, policy: policy, changed: make(chan struct{})}
+}
+
+// Depth returns the number of items that are currently in the buffer of the Reorder node
+func (b *ReorderBuffer) Depth() int {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	return b.depth
+}
+
+// Peak returns the maximum number of items that have been in the buffer of the Reorder node
+func (b *ReorderBuffer) Peak() int {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	return b.peak
+}
+
+// Discarded returns the number of items that have been discarded because they arrived after
+// their gap was skipped by the ReorderError policy
+func (b *ReorderBuffer) Discarded() int {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	return b.discarded
+}
+
+// Err returns ErrReorderOverflow if the buffer has exceeded its limit with the ReorderError
+// policy, or nil otherwise.
+func (b *ReorderBuffer) Err() error {
+	b.mt.Lock()
+	defer b.mt.Unlock()
+	return b.err
+}
+
+// update records the state of the Reorder node. It is a no-op for nil buffers
+func (b *ReorderBuffer) update(next uint64, depth int) {
+	if b == nil {
+		return
+	}
+	b.mt.Lock()
+	b.next = next
+	b.depth = depth
+	if depth > b.peak {
+		b.peak = depth
+	}
+	close(b.changed)
+	b.changed = make(chan struct{})
+	b.mt.Unlock()
+}
+
+func (b *ReorderBuffer) overflow() {
+	b.mt.Lock()
+	b.err = ErrReorderOverflow
+	b.mt.Unlock()
+}
+
+func (b *ReorderBuffer) discard() {
+	b.mt.Lock()
+	b.discarded++
+	b.mt.Unlock()
+}
+
+// waitFor blocks until the item with the provided sequence number fits in the buffer, returning
+// true, or the context is cancelled, returning false
+func (b *ReorderBuffer) waitFor(ctx context.Context, seq uint64) bool {
+	for {
+		b.mt.Lock()
+		if seq < b.next+uint64(b.max) {
+			b.mt.Unlock()
+			return true
+		}
+		changed := b.changed
+		b.mt.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
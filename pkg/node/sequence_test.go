@@ -0,0 +1,162 @@
+package node
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+)
+
+func TestSequence_RestoresOrderAfterParallel(t *testing.T) {
+	start := AsStart(Counter(1, 50))
+	seq := Sequence[int]()
+	rnd := rand.New(rand.NewSource(0))
+	delays := make([]time.Duration, 51)
+	for i := range delays {
+		delays[i] = time.Duration(rnd.Intn(5)) * time.Millisecond
+	}
+	square := Parallel(8, func(s Sequenced[int]) Sequenced[int] {
+		time.Sleep(delays[s.Value])
+		return Sequenced[int]{Seq: s.Seq, Value: s.Value * s.Value}
+	})
+	reorder := Reorder[int]()
+	unseq := Unsequence[int]()
+	var results []int
+	term := collectInts(&results)
+	start.SendsTo(seq)
+	seq.SendsTo(square)
+	square.SendsTo(reorder)
+	reorder.SendsTo(unseq)
+	unseq.SendsTo(term)
+
+	graph := NewGraph(start, seq, square, reorder, unseq, term)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+
+	var expected []int
+	for i := 1; i <= 50; i++ {
+		expected = append(expected, i*i)
+	}
+	assert.Equal(t, expected, results)
+}
+
+func TestReorder_Gaps(t *testing.T) {
+	start := AsStart(func(out chan<- Sequenced[string]) {
+		out <- Sequenced[string]{Seq: 1, Value: "b"}
+		out <- Sequenced[string]{Seq: 0, Value: "a"}
+		// 2 is missing
+		out <- Sequenced[string]{Seq: 5, Value: "f"}
+		out <- Sequenced[string]{Seq: 3, Value: "d"}
+	})
+	reorder := Reorder[string]()
+	probe := reorder.Probe()
+	start.SendsTo(reorder)
+	start.Start()
+
+	items, err := probe.Expect(4, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, []Sequenced[string]{
+		{Seq: 0, Value: "a"}, {Seq: 1, Value: "b"}, {Seq: 3, Value: "d"}, {Seq: 5, Value: "f"},
+	}, items)
+}
+
+func TestReorderBuffer_Block(t *testing.T) {
+	buffer := NewReorderBuffer(4, ReorderBlock)
+	start := AsStart(Counter(1, 50))
+	seq := Sequence[int](WithReorderBuffer(buffer))
+	rnd := rand.New(rand.NewSource(0))
+	delays := make([]time.Duration, 51)
+	for i := range delays {
+		delays[i] = time.Duration(rnd.Intn(5)) * time.Millisecond
+	}
+	slow := Parallel(8, func(s Sequenced[int]) Sequenced[int] {
+		time.Sleep(delays[s.Value])
+		return s
+	})
+	reorder := Reorder[int](WithReorderBuffer(buffer))
+	unseq := Unsequence[int]()
+	var results []int
+	term := collectInts(&results)
+	start.SendsTo(seq)
+	seq.SendsTo(slow)
+	slow.SendsTo(reorder)
+	reorder.SendsTo(unseq)
+	unseq.SendsTo(term)
+
+	graph := NewGraph(start, seq, slow, reorder, unseq, term)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+
+	var expected []int
+	for i := 1; i <= 50; i++ {
+		expected = append(expected, i)
+	}
+	assert.Equal(t, expected, results)
+	assert.Less(t, buffer.Peak(), 4)
+	assert.Zero(t, buffer.Depth())
+	assert.NoError(t, buffer.Err())
+}
+
+func TestReorderBuffer_BlockCancel(t *testing.T) {
+	buffer := NewReorderBuffer(4, ReorderBlock)
+	start := AsStart(Counter(1, 50))
+	seq := Sequence[int](WithReorderBuffer(buffer))
+	// discarding the first item blocks the Sequence node forever
+	lossy := Filter(func(s Sequenced[int]) bool { return s.Value != 1 })
+	reorder := Reorder[int](WithReorderBuffer(buffer))
+	unseq := Unsequence[int]()
+	var results []int
+	term := collectInts(&results)
+	start.SendsTo(seq)
+	seq.SendsTo(lossy)
+	lossy.SendsTo(reorder)
+	reorder.SendsTo(unseq)
+	unseq.SendsTo(term)
+
+	graph := NewGraph(start, seq, lossy, reorder, unseq, term)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, graph.StartCtx(ctx))
+	assert.Eventually(t, func() bool { return buffer.Depth() == 3 }, timeout, time.Millisecond)
+	nodetest.ExpectBlocked(t, graph.Done())
+
+	// cancelling the context releases the blocked Sequence node
+	cancel()
+	waitDone(t, graph.Done())
+	// the buffered items may have been forwarded before the graph observed the cancellation
+	assert.Subset(t, []int{2, 3, 4}, results)
+}
+
+func TestReorderBuffer_Error(t *testing.T) {
+	buffer := NewReorderBuffer(2, ReorderError)
+	start := AsStart(func(out chan<- Sequenced[string]) {
+		out <- Sequenced[string]{Seq: 1, Value: "b"}
+		out <- Sequenced[string]{Seq: 2, Value: "c"}
+		// the buffer limit is exceeded
+		out <- Sequenced[string]{Seq: 3, Value: "d"}
+		out <- Sequenced[string]{Seq: 0, Value: "a"}
+		out <- Sequenced[string]{Seq: 4, Value: "e"}
+	})
+	reorder := Reorder[string](WithReorderBuffer(buffer))
+	var received []string
+	term := AsTerminal(func(in <-chan Sequenced[string]) {
+		for s := range in {
+			received = append(received, s.Value)
+		}
+	})
+	start.SendsTo(reorder)
+	reorder.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []string{"b", "c", "d", "e"}, received)
+	assert.ErrorIs(t, buffer.Err(), ErrReorderOverflow)
+	assert.Equal(t, 1, buffer.Discarded())
+	assert.Equal(t, 3, buffer.Peak())
+	assert.Zero(t, buffer.Depth())
+}
@@ -0,0 +1,211 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// serialStarter is implemented by the nodes that can be the source of a graph that runs in
+// serial mode
+type serialStarter interface {
+	// startSerial invokes the Start function and returns a function that forwards the next
+	// produced item to the receivers. It returns false when the Start function has finished.
+	startSerial(ctx context.Context) (next func() bool)
+}
+
+// serialReceiver is implemented by the nodes that can receive data in serial mode
+type serialReceiver interface {
+	serialCapable() bool
+	// beginSerial marks the node as started and invokes its start hook. It returns false if the
+	// node was already started.
+	beginSerial() bool
+	// receiveSerial processes an item and forwards the results to the receivers of the node
+	receiveSerial(item any)
+	// endSerial is invoked once all the senders of the node have finished
+	endSerial()
+}
+
+// RunSerial validates the graph and, if it is valid, runs it until all its nodes finish in a
+// deterministic, single-threaded mode meant for tests and debugging: each item is fully processed
+// by all the nodes of the graph before the next item is produced, so the order in which the nodes
+// process the items is reproducible between executions.
+// The Start nodes receive a context carrying a new GraphContext, and are run in turns, following
+// the order in which they were added to the graph: each turn forwards a single item from a Start
+// node.
+//
+// The processing loop of the Middle and Terminal nodes must be owned by the library, so only
+// the following nodes can receive data in serial mode: node.Map, node.Filter, node.Scan,
+// node.ForEach, and the node.Composite nodes whose subgraph only contains them. RunSerial
+// returns an error if the graph contains any other node (e.g. the nodes created with AsMiddle or
+// AsTerminal, whose functions own their loop and require their own goroutine).
+// The functions of the Middle and Terminal nodes are invoked from the goroutine that invoked
+// RunSerial. The Start functions push their items to a channel, so each one still runs in its
+// own goroutine, but it is blocked while the rest of the graph processes its last item. Only one
+// node function runs at any time.
+// The node.WithPanicHandler option is honored as in the concurrent mode: the panics of the nodes
+// that are created with it are recovered and passed to the handler, and the failed node discards
+// the rest of its input. The panics of a Start node without handler crash the program, while the
+// panics of a Middle or Terminal node without handler are raised in the goroutine that invoked
+// RunSerial.
+// The nodes can't be paused in serial mode, and their buffer options are ignored.
+//
+// The graph can't be started after it has run in serial mode.
+func (g *Graph) RunSerial(ctx context.Context) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+	// the graph is valid, so it has no cycles
+	order, _ := g.TopoSort()
+	var unsupported []string
+	for _, n := range order {
+		if _, ok := n.(serialStarter); ok {
+			continue
+		}
+		if r, ok := n.(serialReceiver); !ok || !r.serialCapable() {
+			unsupported = append(unsupported, n.Name())
+		}
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("nodes that can't run in serial mode: %s", strings.Join(unsupported, ", "))
+	}
+	senders := map[graphNode]int{}
+	for _, n := range order {
+		if r, ok := n.(serialReceiver); ok && !r.beginSerial() {
+			return fmt.Errorf("node %s is already started", n.Name())
+		}
+		for _, out := range n.outputs() {
+			senders[out]++
+		}
+	}
+	var finish func(n graphNode)
+	finish = func(n graphNode) {
+		for _, out := range n.outputs() {
+			senders[out]--
+			if senders[out] == 0 {
+				out.(serialReceiver).endSerial()
+				finish(out)
+			}
+		}
+	}
+
+	var panics *panicRecorder
+	if len(g.onComplete) > 0 {
+		panics = &panicRecorder{}
+		observePanics(order, nil, nil, panics)
+	}
+
+	type source struct {
+		node graphNode
+		next func() bool
+	}
+	ctx = WithGraphContext(ctx)
+	var sources []source
+	for _, n := range g.nodes {
+		if s, ok := n.(serialStarter); ok {
+			sources = append(sources, source{node: n, next: s.startSerial(ctx)})
+		}
+	}
+	for len(sources) > 0 {
+		active := sources[:0]
+		for _, s := range sources {
+			if s.next() {
+				active = append(active, s)
+			} else {
+				finish(s.node)
+			}
+		}
+		sources = active
+	}
+	if panics != nil {
+		invokeHooks(g.onComplete, panics.result(ctx))
+	}
+	return nil
+}
+
+func (s *Start[OUT]) startSerial(ctx context.Context) func() bool {
+	s.notifyStart()
+	items := make(chan OUT)
+	go func() {
+		if s.waitGate(ctx) {
+			s.invoke(s, func() { s.fun(ctx, items) })
+			if s.onEnd != nil {
+				items <- s.onEnd()
+			}
+		}
+		close(items)
+	}()
+	return func() bool {
+		item, ok := <-items
+		if !ok {
+			s.notifyFinish()
+			close(s.done)
+			return false
+		}
+		for _, out := range s.outs {
+			out.(serialReceiver).receiveSerial(item)
+		}
+		return true
+	}
+}
+
+func (m *Middle[IN, OUT]) serialCapable() bool {
+	return m.serial != nil
+}
+
+func (m *Middle[IN, OUT]) beginSerial() bool {
+	if !m.markStarted() {
+		return false
+	}
+	m.notifyStart()
+	return true
+}
+
+func (m *Middle[IN, OUT]) receiveSerial(item any) {
+	// as in the concurrent mode, a node that panicked discards the rest of its input
+	if m.failed() {
+		return
+	}
+	m.invoke(m, func() { m.serial(item.(IN), m.emitSerial) })
+}
+
+func (m *Middle[IN, OUT]) emitSerial(item OUT) {
+	for _, out := range m.outs {
+		out.(serialReceiver).receiveSerial(item)
+	}
+}
+
+func (m *Middle[IN, OUT]) endSerial() {
+	if m.onEnd != nil {
+		m.emitSerial(m.onEnd())
+	}
+	m.notifyFinish()
+	close(m.done)
+}
+
+func (t *Terminal[IN]) serialCapable() bool {
+	return t.serial != nil
+}
+
+func (t *Terminal[IN]) beginSerial() bool {
+	if !t.markStarted() {
+		return false
+	}
+	t.notifyStart()
+	return true
+}
+
+func (t *Terminal[IN]) receiveSerial(item any) {
+	if t.failed() {
+		return
+	}
+	t.invoke(t, func() { t.serial(item.(IN)) })
+}
+
+func (t *Terminal[IN]) endSerial() {
+	if t.onEnd != nil {
+		t.onEnd()
+	}
+	t.notifyFinish()
+	close(t.done)
+}
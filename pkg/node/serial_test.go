@@ -0,0 +1,107 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph_RunSerial(t *testing.T) {
+	letters := AsStart(func(out chan<- string) {
+		for _, l := range []string{"a", "b", "c"} {
+			out <- l
+		}
+	}, WithName("letters"))
+	numbers := AsStart(func(out chan<- string) {
+		for _, n := range []string{"1", "2"} {
+			out <- n
+		}
+	}, WithName("numbers"))
+	var trace []string
+	upper := Map(func(s string) string {
+		trace = append(trace, "map "+s)
+		return s + s
+	})
+	notB := Filter(func(s string) bool {
+		trace = append(trace, "filter "+s)
+		return s != "bb"
+	})
+	upper.OnEnd(func() string { return "end" })
+	collect := ForEach(func(s string) {
+		trace = append(trace, "collect "+s)
+	})
+	ended := false
+	collect.OnEnd(func() { ended = true })
+	letters.SendsTo(upper)
+	numbers.SendsTo(upper)
+	upper.SendsTo(notB)
+	notB.SendsTo(collect)
+
+	graph := NewGraph(letters, numbers, upper, notB, collect)
+	require.NoError(t, graph.RunSerial(context.Background()))
+	// each item traverses the whole graph before the next item is produced, and the sources
+	// are run in turns
+	assert.Equal(t, []string{
+		"map a", "filter aa", "collect aa",
+		"map 1", "filter 11", "collect 11",
+		"map b", "filter bb",
+		"map 2", "filter 22", "collect 22",
+		"map c", "filter cc", "collect cc",
+		"filter end", "collect end",
+	}, trace)
+	assert.True(t, ended)
+	for _, n := range graph.Nodes() {
+		assert.True(t, isClosed(n.Done()), n.Name())
+	}
+
+	// the graph can't run again
+	assert.Error(t, graph.RunSerial(context.Background()))
+}
+
+func TestGraph_RunSerial_PanicHandler(t *testing.T) {
+	panics := make(chan NodePanic, 2)
+	handler := WithPanicHandler(func(p NodePanic) { panics <- p })
+	start := AsStart(func(out chan<- int) {
+		for i := 1; i <= 4; i++ {
+			out <- i
+		}
+		panic("start failed")
+	}, WithName("start"), handler)
+	var received []int
+	failAt3 := Map(func(n int) int {
+		if n == 3 {
+			panic("map failed")
+		}
+		return n
+	}, WithName("map"), handler)
+	term := ForEach(func(n int) { received = append(received, n) })
+	start.SendsTo(failAt3)
+	failAt3.SendsTo(term)
+
+	graph := NewGraph(start, failAt3, term)
+	require.NoError(t, graph.RunSerial(context.Background()))
+	// the failed node discards the rest of its input
+	assert.Equal(t, []int{1, 2}, received)
+	assert.Equal(t, StateFailed, failAt3.State())
+	assert.Equal(t, StateFailed, start.State())
+	assert.Equal(t, StateFinished, term.State())
+	require.Len(t, panics, 2)
+	assert.Equal(t, "map", (<-panics).Node.Name)
+	assert.Equal(t, "start", (<-panics).Node.Name)
+}
+
+func TestGraph_RunSerial_Unsupported(t *testing.T) {
+	start := AsStart(Counter(1, 3))
+	odds := AsMiddle(OddFilter, WithName("odds"))
+	var received []int
+	term := ForEach(func(n int) { received = append(received, n) })
+	start.SendsTo(odds)
+	odds.SendsTo(term)
+
+	err := NewGraph(start, odds, term).RunSerial(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "odds")
+	assert.Empty(t, received)
+}
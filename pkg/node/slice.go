@@ -0,0 +1,89 @@
+package node
+
+// Take returns a Middle node that forwards the first n received items, and then closes its output
+// so the rest of the graph can finish. The items that are received afterwards are discarded, so
+// the previous nodes are not blocked.
+// The node.WithCancel option allows cancelling the context of the graph once the n items have
+// been forwarded, so the Start nodes that listen to it stop producing items.
+func Take[T any](n int, opts ...Option) *Middle[T, T] {
+	cancel := getOptions(opts...).cancel
+	take := AsMiddle(func(in <-chan T, out chan<- T) {
+		for taken := 0; taken < n; taken++ {
+			item, ok := <-in
+			if !ok {
+				return
+			}
+			out <- item
+		}
+		if cancel != nil {
+			cancel()
+		}
+		// returning closes the output, and the node discards the rest of the input
+	}, opts...)
+	// the taken items are still forwarded if the node cancels the graph
+	take.flushOnCancel = true
+	return take
+}
+
+// Skip returns a Middle node that discards the first n received items and forwards the rest.
+// If the input has n items or less, it does not forward any item.
+func Skip[T any](n int, opts ...Option) *Middle[T, T] {
+	return AsMiddle(func(in <-chan T, out chan<- T) {
+		for skipped := 0; skipped < n; skipped++ {
+			if _, ok := <-in; !ok {
+				return
+			}
+		}
+		for item := range in {
+			out <- item
+		}
+	}, opts...)
+}
+
+// Rechunk returns a Middle node that regroups the items of the received slices into slices of
+// exactly size items, regardless of the size of the received slices. When the input is closed,
+// the remaining items are sent as a last, smaller slice.
+// Each sent slice is newly allocated, so the receivers can retain it. The received slices are
+// not retained, so the previous nodes can reuse them once they have been sent.
+func Rechunk[T any](size int, opts ...Option) *Middle[[]T, []T] {
+	if size <= 0 {
+		panic("chunk size must be greater than zero")
+	}
+	return AsMiddle(func(in <-chan []T, out chan<- []T) {
+		chunk := make([]T, 0, size)
+		for items := range in {
+			for len(items) > 0 {
+				n := size - len(chunk)
+				if n > len(items) {
+					n = len(items)
+				}
+				chunk = append(chunk, items[:n]...)
+				items = items[n:]
+				if len(chunk) == size {
+					out <- chunk
+					chunk = make([]T, 0, size)
+				}
+			}
+		}
+		if len(chunk) > 0 {
+			out <- chunk
+		}
+	}, opts...)
+}
+
+// Collectively returns a Middle node that buffers all the received items until its input is
+// closed, then invokes fun once with all of them and sends the returned items. It allows
+// running operations that require the whole stream (e.g. a global sort) inside a graph.
+// The node retains all the items of the stream in memory, so it must be only used with bounded
+// streams whose items fit in memory. Nothing is sent until the input is closed.
+func Collectively[IN, OUT any](fun func([]IN) []OUT, opts ...Option) *Middle[IN, OUT] {
+	return AsMiddle(func(in <-chan IN, out chan<- OUT) {
+		var items []IN
+		for item := range in {
+			items = append(items, item)
+		}
+		for _, item := range fun(items) {
+			out <- item
+		}
+	}, opts...)
+}
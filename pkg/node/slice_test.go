@@ -0,0 +1,183 @@
+package node
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTake(t *testing.T) {
+	// the start node does not listen to the context, so it is not blocked by the Take node
+	start := AsStart(Counter(1, 100))
+	take := Take[int](3)
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(take)
+	take.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{1, 2, 3}, received)
+	waitDone(t, start.Done())
+}
+
+func TestTake_DoneAfterDrain(t *testing.T) {
+	input := make(chan int)
+	start := AsStart(func(out chan<- int) {
+		for n := range input {
+			out <- n
+		}
+	})
+	take := Take[int](1)
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(take)
+	take.SendsTo(term)
+	start.Start()
+
+	input <- 1
+	waitDone(t, term.Done())
+	// the node keeps discarding its input, so it is not done while the input is open
+	input <- 2
+	assert.False(t, isClosed(take.Done()))
+	close(input)
+	waitDone(t, take.Done())
+	assert.Equal(t, []int{1}, received)
+}
+
+func TestTake_Cancel(t *testing.T) {
+	start := AsStartCtx(func(ctx context.Context, out chan<- int) {
+		for n := 1; ; n++ {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- n:
+			}
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	take := Take[int](5, WithCancel(cancel))
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(take)
+	take.SendsTo(term)
+	graph := NewGraph(start, take, term)
+	require.NoError(t, graph.StartCtx(ctx))
+
+	waitDone(t, graph.Done())
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, received)
+	waitDone(t, start.Done())
+	assert.Error(t, ctx.Err())
+}
+
+func TestTake_ShortInput(t *testing.T) {
+	start := AsStart(Counter(1, 2))
+	take := Take[int](5)
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(take)
+	take.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{1, 2}, received)
+}
+
+func TestSkip(t *testing.T) {
+	for _, tc := range []struct {
+		skip     int
+		expected []int
+	}{
+		{skip: 0, expected: []int{1, 2, 3, 4, 5}},
+		{skip: 2, expected: []int{3, 4, 5}},
+		{skip: 5, expected: nil},
+		{skip: 10, expected: nil},
+	} {
+		start := AsStart(Counter(1, 5))
+		skip := Skip[int](tc.skip)
+		var received []int
+		term := collectInts(&received)
+		start.SendsTo(skip)
+		skip.SendsTo(term)
+		start.Start()
+
+		waitDone(t, term.Done())
+		assert.Equal(t, tc.expected, received, "skip %d", tc.skip)
+	}
+}
+
+func TestSkipTake(t *testing.T) {
+	start := AsStart(Counter(1, 10))
+	skip := Skip[int](3)
+	take := Take[int](4)
+	var received []int
+	term := collectInts(&received)
+	start.SendsTo(skip)
+	skip.SendsTo(take)
+	take.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []int{4, 5, 6, 7}, received)
+}
+
+func TestRechunk(t *testing.T) {
+	start := AsStart(func(out chan<- []int) {
+		for _, s := range [][]int{{1, 2}, {3, 4, 5, 6, 7, 8, 9}, {}, {10}, {11, 12, 13}} {
+			out <- s
+		}
+	})
+	rechunk := Rechunk[int](4)
+	var received [][]int
+	term := AsTerminal(func(in <-chan []int) {
+		for s := range in {
+			received = append(received, s)
+		}
+	})
+	start.SendsTo(rechunk)
+	rechunk.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, [][]int{{1, 2, 3, 4}, {5, 6, 7, 8}, {9, 10, 11, 12}, {13}}, received)
+}
+
+func TestRechunk_InvalidSize(t *testing.T) {
+	assert.Panics(t, func() { Rechunk[int](0) })
+}
+
+func TestCollectively(t *testing.T) {
+	start := AsStart(func(out chan<- int) {
+		for _, n := range []int{5, 3, 8, 1, 4} {
+			out <- n
+		}
+	})
+	calls := 0
+	sortTop3 := Collectively(func(items []int) []string {
+		calls++
+		sort.Ints(items)
+		var top []string
+		for _, n := range items[len(items)-3:] {
+			top = append(top, strconv.Itoa(n))
+		}
+		return top
+	})
+	var received []string
+	term := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			received = append(received, s)
+		}
+	})
+	start.SendsTo(sortTop3)
+	sortTop3.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []string{"4", "5", "8"}, received)
+	assert.Equal(t, 1, calls)
+}
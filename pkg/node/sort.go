@@ -0,0 +1,58 @@
+package node
+
+import "container/heap"
+
+// SortBounded returns a Middle node that sorts the stream within a bounded lookahead window: it
+// keeps a buffer of the last k received items and, once the buffer is full, forwards the
+// smallest buffered item each time a new item is received. When the input is closed, the
+// remaining items are forwarded in order.
+// The output is totally sorted if no item arrives more than k positions later than its sorted
+// position. Otherwise, the output is only approximately sorted. The memory is bounded by k.
+func SortBounded[T any](k int, less func(a, b T) bool, opts ...Option) *Middle[T, T] {
+	if k < 0 {
+		panic("SortBounded buffer size can't be negative")
+	}
+	return AsMiddle(func(in <-chan T, out chan<- T) {
+		buffer := &sortHeap[T]{less: less, items: make([]T, 0, k+1)}
+		for item := range in {
+			heap.Push(buffer, item)
+			if buffer.Len() > k {
+				out <- heap.Pop(buffer).(T)
+			}
+		}
+		for buffer.Len() > 0 {
+			out <- heap.Pop(buffer).(T)
+		}
+	}, opts...)
+}
+
+// sortHeap implements heap.Interface
+type sortHeap[T any] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+func (h *sortHeap[T]) Len() int {
+	return len(h.items)
+}
+
+func (h *sortHeap[T]) Less(i, j int) bool {
+	return h.less(h.items[i], h.items[j])
+}
+
+func (h *sortHeap[T]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *sortHeap[T]) Push(x any) {
+	h.items = append(h.items, x.(T))
+}
+
+func (h *sortHeap[T]) Pop() any {
+	last := len(h.items) - 1
+	item := h.items[last]
+	var zero T
+	h.items[last] = zero
+	h.items = h.items[:last]
+	return item
+}
@@ -0,0 +1,46 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortBounded(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		k        int
+		input    []int
+		expected []int
+	}{
+		{name: "near-sorted", k: 2,
+			input:    []int{2, 1, 3, 5, 4, 7, 6, 8},
+			expected: []int{1, 2, 3, 4, 5, 6, 7, 8}},
+		{name: "beyond lookahead", k: 2,
+			input:    []int{4, 5, 6, 1, 2, 3},
+			expected: []int{4, 1, 2, 3, 5, 6}},
+		{name: "input shorter than buffer", k: 10,
+			input:    []int{3, 1, 2},
+			expected: []int{1, 2, 3}},
+		{name: "no buffer", k: 0,
+			input:    []int{3, 1, 2},
+			expected: []int{3, 1, 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			start := AsStart(func(out chan<- int) {
+				for _, n := range tc.input {
+					out <- n
+				}
+			})
+			sorter := SortBounded(tc.k, func(a, b int) bool { return a < b })
+			var received []int
+			term := collectInts(&received)
+			start.SendsTo(sorter)
+			sorter.SendsTo(term)
+			start.Start()
+
+			waitDone(t, term.Done())
+			assert.Equal(t, tc.expected, received)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package node
+
+import (
+	"context"
+	"time"
+)
+
+// SourceStatus reports whether a Start node came up before the timeout of
+// Graph.StartWithSourceTimeout
+type SourceStatus struct {
+	Node NodeInfo
+	// Ready is true if the node sent its first item, or finished, before the timeout. Otherwise,
+	// the node has been stopped.
+	Ready bool
+}
+
+// StartWithSourceTimeout works as StartCtx, but waits up to the provided timeout for each Start
+// node of the graph to send its first item, so a source that hangs (e.g. connecting to an
+// external system) does not prevent the graph from running with the rest of the sources.
+// The Start nodes that have not sent any item before the timeout, and have not finished, are
+// stopped as with their Stop method. It returns the status of each Start node, in the order they
+// were added to the graph.
+// The stopped nodes must listen to the cancellation of their context. Otherwise, they will
+// prevent the graph from finishing.
+func (g *Graph) StartWithSourceTimeout(ctx context.Context, timeout time.Duration) ([]SourceStatus, error) {
+	type source struct {
+		Node
+		ready <-chan struct{}
+		stop  func()
+	}
+	var sources []source
+	for _, n := range g.nodes {
+		if r, ok := n.(readySignaler); ok {
+			sources = append(sources, source{Node: n, ready: r.signalReady(), stop: r.Stop})
+		}
+	}
+	if err := g.StartCtx(ctx); err != nil {
+		return nil, err
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	statuses := make([]SourceStatus, 0, len(sources))
+	for _, src := range sources {
+		select {
+		case <-src.ready:
+		case <-src.Done():
+		case <-waitCtx.Done():
+		}
+		// after the deadline, only the sources that are already up are considered ready
+		ready := isClosed(src.ready) || isClosed(src.Done())
+		if !ready {
+			src.stop()
+		}
+		statuses = append(statuses, SourceStatus{Node: infoOf(src.Node), Ready: ready})
+	}
+	return statuses, nil
+}
+
+// readySignaler is implemented by the Start nodes, which can notify when they send their first item
+type readySignaler interface {
+	// signalReady returns a channel that is closed when the node sends its first item. It must be
+	// invoked before the node is started.
+	signalReady() <-chan struct{}
+	Stop()
+}
+
+func (s *Start[OUT]) signalReady() <-chan struct{} {
+	s.ready = make(chan struct{})
+	return s.ready
+}
+
+// signalFirstItem returns a channel that forwards the items to the out channel, closing the ready
+// channel after forwarding the first item, and a function that must be invoked after the last
+// item is sent, which waits for all the items to be forwarded.
+func signalFirstItem[T any](out chan<- T, ready chan struct{}) (chan<- T, func()) {
+	in := make(chan T)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		if item, ok := <-in; ok {
+			out <- item
+			close(ready)
+		}
+		for item := range in {
+			out <- item
+		}
+	}()
+	return in, func() {
+		close(in)
+		<-forwarded
+	}
+}
+
+// isClosed returns whether the channel is closed, without blocking
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
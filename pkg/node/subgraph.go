@@ -0,0 +1,133 @@
+package node
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Subgraph is a reusable building block of a graph, whose nodes are connected to the rest of the
+// graph through named and typed ports. Unlike a Composite, it can have multiple input and output
+// ports, of different types.
+// The ports are declared and accessed with the node.InputPort and node.OutputPort functions:
+// the code that builds the subgraph connects the input ports to its first nodes, and its last
+// nodes to the output ports, while the external code connects its senders to the input ports and
+// the output ports to its receivers.
+// Each output port finishes when all the subgraph nodes sending to it have finished, and the
+// Subgraph is done when all its output ports have finished.
+type Subgraph struct {
+	name  string
+	ports []*subgraphPort
+}
+
+type subgraphPort struct {
+	name     string
+	input    bool
+	itemType reflect.Type
+	// the port node, as returned by InputPort or OutputPort
+	node Node
+	// returns whether the port node has been connected to senders and receivers
+	connected func() (in, out bool)
+}
+
+// NewSubgraph creates an empty Subgraph. The name prefixes the names of its ports.
+func NewSubgraph(name string) *Subgraph {
+	return &Subgraph{name: name}
+}
+
+// InputPort returns the input port of the Subgraph with the given name, declaring it on the first
+// invocation. The port is a pass-through Middle node: the external senders connect to it, and it
+// is connected to the first nodes of the subgraph through its SendsTo method.
+// It panics if the port has been declared as an output port, or with a different type.
+func InputPort[T any](sg *Subgraph, name string) *Middle[T, T] {
+	return sg.port(name, true, reflect.TypeOf((*T)(nil)).Elem(), func() *subgraphPort {
+		return newSubgraphPort[T](sg, name)
+	}).node.(*Middle[T, T])
+}
+
+// OutputPort returns the output port of the Subgraph with the given name, declaring it on the
+// first invocation. The port is a pass-through Middle node: the last nodes of the subgraph
+// connect to it, and it is connected to the external receivers through its SendsTo method.
+// It panics if the port has been declared as an input port, or with a different type.
+func OutputPort[T any](sg *Subgraph, name string) *Middle[T, T] {
+	return sg.port(name, false, reflect.TypeOf((*T)(nil)).Elem(), func() *subgraphPort {
+		return newSubgraphPort[T](sg, name)
+	}).node.(*Middle[T, T])
+}
+
+func newSubgraphPort[T any](sg *Subgraph, name string) *subgraphPort {
+	m := Map(func(item T) T { return item }, WithName(sg.name+"/"+name))
+	m.subgraph = sg
+	return &subgraphPort{
+		node: m,
+		connected: func() (bool, bool) {
+			// the senders release the port input when they finish, so the number of current
+			// senders would report a finished port as unconnected
+			return m.joiner().Wired(), len(m.outs) > 0
+		},
+	}
+}
+
+func (sg *Subgraph) port(name string, input bool, itemType reflect.Type, create func() *subgraphPort) *subgraphPort {
+	for _, p := range sg.ports {
+		if p.name != name {
+			continue
+		}
+		if p.input != input || p.itemType != itemType {
+			panic(fmt.Sprintf("port %s of subgraph %s is declared as %s", name, sg.name, p.describe()))
+		}
+		return p
+	}
+	p := create()
+	p.name, p.input, p.itemType = name, input, itemType
+	sg.ports = append(sg.ports, p)
+	return p
+}
+
+func (p *subgraphPort) describe() string {
+	if p.input {
+		return fmt.Sprintf("input of %v", p.itemType)
+	}
+	return fmt.Sprintf("output of %v", p.itemType)
+}
+
+// Ports returns the nodes of the ports of the Subgraph, in declaration order, so they can be
+// added to a Graph. The rest of nodes of the subgraph are discovered from them.
+func (sg *Subgraph) Ports() []Node {
+	nodes := make([]Node, 0, len(sg.ports))
+	for _, p := range sg.ports {
+		nodes = append(nodes, p.node)
+	}
+	return nodes
+}
+
+// Validate verifies that all the declared ports of the Subgraph are connected on both sides:
+// the external senders and the subgraph nodes for the input ports, and the subgraph nodes and
+// the external receivers for the output ports. It returns an error listing the ports that are
+// not connected otherwise.
+// An unconnected input port would never finish, and an unconnected output port would panic
+// when the subgraph is started.
+// Graph.Validate also validates the subgraphs whose ports are part of the graph.
+func (sg *Subgraph) Validate() error {
+	var unconnected []string
+	for _, p := range sg.ports {
+		if in, out := p.connected(); !in || !out {
+			unconnected = append(unconnected, fmt.Sprintf("%s (%s)", p.name, p.describe()))
+		}
+	}
+	if len(unconnected) > 0 {
+		return fmt.Errorf("subgraph %s has unconnected ports: %s", sg.name, strings.Join(unconnected, ", "))
+	}
+	return nil
+}
+
+// Done returns a channel that is closed when all the output ports of the Subgraph have finished.
+func (sg *Subgraph) Done() <-chan struct{} {
+	var outs []Doner
+	for _, p := range sg.ports {
+		if !p.input {
+			outs = append(outs, p.node)
+		}
+	}
+	return AllDone(outs...)
+}
@@ -0,0 +1,80 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// splitter builds a subgraph that splits the received numbers into odd messages and even numbers
+func splitter() *Subgraph {
+	sg := NewSubgraph("splitter")
+	in := InputPort[int](sg, "numbers")
+	odds := AsMiddle(OddFilter)
+	msg := AsMiddle(Messager("odd"))
+	evens := AsMiddle(EvenFilter)
+	in.SendsTo(odds, evens)
+	odds.SendsTo(msg)
+	msg.SendsTo(OutputPort[string](sg, "odds"))
+	evens.SendsTo(OutputPort[int](sg, "evens"))
+	return sg
+}
+
+func TestSubgraph(t *testing.T) {
+	sg := splitter()
+	start := AsStart(Counter(1, 5))
+	var odds []string
+	oddsTerm := collectStrings(&odds)
+	var evens []int
+	evensTerm := collectInts(&evens)
+
+	start.SendsTo(InputPort[int](sg, "numbers"))
+	OutputPort[string](sg, "odds").SendsTo(oddsTerm)
+	// the subgraph is not valid until all its ports are connected
+	err := sg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "evens (output of int)")
+	assert.NotContains(t, err.Error(), "numbers")
+	assert.NotContains(t, err.Error(), "odds")
+	OutputPort[int](sg, "evens").SendsTo(evensTerm)
+	require.NoError(t, sg.Validate())
+
+	graph := NewGraph(start, oddsTerm, evensTerm)
+	graph.Add(sg.Ports()...)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+	waitDone(t, sg.Done())
+	assert.Equal(t, []string{"odd: 1", "odd: 3", "odd: 5"}, odds)
+	assert.Equal(t, []int{2, 4}, evens)
+	// the ports are still connected after their senders have finished
+	require.NoError(t, sg.Validate())
+}
+
+func TestSubgraph_GraphValidate(t *testing.T) {
+	sg := splitter()
+	start := AsStart(Counter(1, 5))
+	var odds []string
+	oddsTerm := collectStrings(&odds)
+	start.SendsTo(InputPort[int](sg, "numbers"))
+	OutputPort[string](sg, "odds").SendsTo(oddsTerm)
+
+	// the subgraph is validated even if its ports are not added to the graph
+	graph := NewGraph(start, oddsTerm)
+	err := graph.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "subgraph splitter has unconnected ports: evens (output of int)")
+
+	var evens []int
+	evensTerm := collectInts(&evens)
+	OutputPort[int](sg, "evens").SendsTo(evensTerm)
+	graph.Add(evensTerm)
+	require.NoError(t, graph.Validate())
+}
+
+func TestSubgraph_PortMismatch(t *testing.T) {
+	sg := splitter()
+	assert.Panics(t, func() { InputPort[string](sg, "numbers") })
+	assert.Panics(t, func() { InputPort[int](sg, "evens") })
+	assert.Equal(t, "splitter/numbers", InputPort[int](sg, "numbers").Name())
+}
@@ -0,0 +1,36 @@
+package node
+
+// Tagged wraps an item together with the index of the source node that sent it, as provided
+// to node.MergeTagged.
+type Tagged[T any] struct {
+	SourceIndex int
+	Value       T
+}
+
+// MergeTagged joins the outputs of the provided source nodes into a Middle node that forwards
+// all their items, wrapped into a Tagged item whose SourceIndex is the position of the
+// sender in the sources argument. This allows downstream nodes to distinguish the origin of each
+// item after the merge (e.g. to route responses back or for per-source statistics).
+// The returned node must be connected to its outputs through its SendsTo method, and finishes
+// when all the sources have closed their output.
+func MergeTagged[T any](sources ...Sender[T]) *Middle[Tagged[T], Tagged[T]] {
+	if len(sources) == 0 {
+		panic("MergeTagged requires at least one source")
+	}
+	merged := AsMiddle(func(in <-chan Tagged[T], out chan<- Tagged[T]) {
+		for item := range in {
+			out <- item
+		}
+	})
+	for i, src := range sources {
+		idx := i
+		tagger := AsMiddle(func(in <-chan T, out chan<- Tagged[T]) {
+			for item := range in {
+				out <- Tagged[T]{SourceIndex: idx, Value: item}
+			}
+		})
+		tagger.SendsTo(merged)
+		src.SendsTo(tagger)
+	}
+	return merged
+}
@@ -0,0 +1,27 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeTagged(t *testing.T) {
+	start1 := AsStart(Counter(1, 3))
+	start2 := AsStart(Counter(10, 12))
+	odds := AsMiddle(OddFilter)
+	start2.SendsTo(odds)
+	merged := MergeTagged[int](start1, odds)
+	received := map[int][]int{}
+	term := AsTerminal(func(in <-chan Tagged[int]) {
+		for item := range in {
+			received[item.SourceIndex] = append(received[item.SourceIndex], item.Value)
+		}
+	})
+	merged.SendsTo(term)
+	start1.Start()
+	start2.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, map[int][]int{0: {1, 2, 3}, 1: {11}}, received)
+}
@@ -0,0 +1,15 @@
+// Package main is a fixture that must not compile, as the type of the Pipe items does not
+// match the type of the Sink function input.
+package main
+
+import (
+	"strconv"
+
+	"github.com/netobserv/gopipes/pkg/node"
+)
+
+func main() {
+	pipe := node.Source(func(out chan<- int) { out <- 1 })
+	strs := node.Step(pipe, strconv.Itoa)
+	strs.Sink(func(in <-chan int) {})
+}
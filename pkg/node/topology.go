@@ -0,0 +1,86 @@
+package node
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Topology is a serializable description of the static structure of a graph, meant to be
+// consumed by external tools (e.g. visualizers or validators).
+type Topology struct {
+	Nodes []TopologyNode `json:"nodes"`
+	Edges []TopologyEdge `json:"edges"`
+}
+
+// TopologyNode describes a node of a graph
+type TopologyNode struct {
+	// ID identifies the node within the Topology, as the node names are not required to be unique
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// In is the name of the type of the items that the node receives, if any
+	In string `json:"in,omitempty"`
+	// Out is the name of the type of the items that the node sends, if any
+	Out    string            `json:"out,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// TopologyEdge describes a connection between two nodes of a graph
+type TopologyEdge struct {
+	// From is the ID of the sender node
+	From int `json:"from"`
+	// To is the ID of the receiver node
+	To int `json:"to"`
+	// Type is the name of the type of the items that are sent through the edge
+	Type string `json:"type"`
+}
+
+// Topology returns the description of the nodes of the graph and their connections, including
+// the unlisted nodes that receive data from any of its nodes. The nodes are listed in the order
+// in which they were added to the graph, followed by the unlisted nodes.
+func (g *Graph) Topology() Topology {
+	nodes := discoverNodes(g.nodes)
+	ids := make(map[graphNode]int, len(nodes))
+	topology := Topology{Nodes: make([]TopologyNode, 0, len(nodes))}
+	for i, n := range nodes {
+		ids[n] = i
+		schema := n.Schema()
+		topology.Nodes = append(topology.Nodes, TopologyNode{
+			ID:     i,
+			Name:   n.Name(),
+			Kind:   n.Kind().String(),
+			In:     typeName(schema.In),
+			Out:    typeName(schema.Out),
+			Labels: n.Labels(),
+		})
+	}
+	topology.Edges = []TopologyEdge{}
+	for _, n := range nodes {
+		visited := map[graphNode]struct{}{}
+		for _, out := range n.outputs() {
+			if _, ok := visited[out]; ok {
+				continue
+			}
+			visited[out] = struct{}{}
+			topology.Edges = append(topology.Edges, TopologyEdge{
+				From: ids[n],
+				To:   ids[out],
+				// the output type of some senders (e.g. node.Switch) depends on the receiver
+				Type: typeName(out.(Node).Schema().In),
+			})
+		}
+	}
+	return topology
+}
+
+// MarshalJSON returns the JSON representation of the graph Topology
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(g.Topology())
+}
+
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
@@ -0,0 +1,38 @@
+package node
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph_Topology(t *testing.T) {
+	start := AsStart(Counter(1, 3), WithName("counter"))
+	odds := AsMiddle(OddFilter, WithLabels(map[string]string{"team": "a"}))
+	msg := AsMiddle(Messager("odd"), WithName("msg"))
+	print := AsTerminal(func(in <-chan string) {}, WithName("print"))
+	start.SendsTo(odds)
+	odds.SendsTo(msg)
+	msg.SendsTo(print)
+
+	// msg and print are not listed, but they are part of the topology
+	graph := NewGraph(start, odds)
+	out, err := json.Marshal(graph)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"nodes": [
+			{"id": 0, "name": "counter", "kind": "Start", "out": "int"},
+			{"id": 1, "name": "Middle[int,int]", "kind": "Middle", "in": "int", "out": "int",
+				"labels": {"team": "a"}},
+			{"id": 2, "name": "msg", "kind": "Middle", "in": "int", "out": "string"},
+			{"id": 3, "name": "print", "kind": "Terminal", "in": "string"}
+		],
+		"edges": [
+			{"from": 0, "to": 1, "type": "int"},
+			{"from": 1, "to": 2, "type": "int"},
+			{"from": 2, "to": 3, "type": "string"}
+		]
+	}`, string(out))
+}
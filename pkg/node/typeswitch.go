@@ -0,0 +1,141 @@
+package node
+
+import "context"
+
+// Switch is a node that routes each received item to the receivers that are registered for the
+// dynamic type of the item. It allows demultiplexing a stream of interfaces (or any other sum
+// type) into concretely-typed branches.
+// The receivers are registered with the node.Case function and the Default method.
+type Switch[IN any] struct {
+	nodeMeta
+	receiverBase[IN]
+	cases    []switchCase[IN]
+	defaults []Receiver[IN]
+	done     chan struct{}
+}
+
+type switchCase[IN any] struct {
+	outs []graphNode
+	// start connects the receivers of the case, and returns a function that sends the item to
+	// them if it matches the case type, and a function that closes the connection
+	start func(ctx context.Context) (send func(IN) bool, release func())
+}
+
+// TypeSwitch creates a Switch node. Items are routed to the receivers of the first registered
+// case whose type matches the dynamic type of the item. If no case matches, items are sent to
+// the default receivers, or discarded if there are no default receivers.
+func TypeSwitch[IN any](opts ...Option) *Switch[IN] {
+	options := getOptions(opts...)
+	receiver := newReceiverBase[IN](&options)
+	s := &Switch[IN]{
+		nodeMeta:     newNodeMeta(&options, KindMiddle, Schema{In: receiver.inType}),
+		receiverBase: receiver,
+		done:         make(chan struct{}),
+	}
+	s.inputDone = s.receiverBase.inputClosed
+	return s
+}
+
+// Case registers the receivers of the items whose dynamic type is C. If C is an interface, the
+// items implementing it are sent to the receivers. Case returns the passed Switch, so multiple
+// invocations can be chained. It must be invoked before the Switch is started.
+func Case[C, IN any](s *Switch[IN], receivers ...Receiver[C]) *Switch[IN] {
+	receivers = connectTo(&s.nodeMeta, nil, receivers)
+	s.cases = append(s.cases, switchCase[IN]{
+		outs: receiversAsNodes(receivers),
+		start: func(ctx context.Context) (func(IN) bool, func()) {
+			forker := forkTo(ctx, &s.nodeMeta, receivers)
+			return func(item IN) bool {
+				c, ok := any(item).(C)
+				if ok {
+					forker.Sender() <- c
+				}
+				return ok
+			}, forker.Close
+		},
+	})
+	return s
+}
+
+// Default registers the receivers of the items that do not match any case. It must be invoked
+// before the Switch is started.
+func (s *Switch[IN]) Default(receivers ...Receiver[IN]) *Switch[IN] {
+	s.defaults = connectTo(&s.nodeMeta, s.defaults, receivers)
+	return s
+}
+
+// Done returns a channel that is closed when the Switch node has ended its processing. This is,
+// when its input has been closed and all its outputs have been closed.
+func (s *Switch[IN]) Done() <-chan struct{} {
+	return s.done
+}
+
+// Kind returns KindMiddle
+func (s *Switch[IN]) Kind() NodeKind {
+	return KindMiddle
+}
+
+// Schema returns the input type of the Switch. The output type is not defined, as it depends on
+// each case.
+func (s *Switch[IN]) Schema() Schema {
+	return Schema{In: s.inType}
+}
+
+// Stats returns runtime information about the Switch node
+func (s *Switch[IN]) Stats() Stats {
+	stats := s.receiverBase.Stats()
+	s.senderStats(&stats)
+	return stats
+}
+
+func (s *Switch[IN]) outputs() []graphNode {
+	outs := receiversAsNodes(s.defaults)
+	for _, c := range s.cases {
+		outs = append(outs, c.outs...)
+	}
+	return outs
+}
+
+func (s *Switch[IN]) start(ctx context.Context) {
+	if len(s.cases) == 0 && len(s.defaults) == 0 {
+		panicNoOutputs(&s.nodeMeta)
+	}
+	if !s.markStarted() {
+		return
+	}
+	senders := make([]func(IN) bool, 0, len(s.cases))
+	releasers := make([]func(), 0, len(s.cases)+1)
+	for _, c := range s.cases {
+		send, release := c.start(ctx)
+		senders = append(senders, send)
+		releasers = append(releasers, release)
+	}
+	var defaults chan<- IN
+	if len(s.defaults) > 0 {
+		forker := forkTo(ctx, &s.nodeMeta, s.defaults)
+		defaults = forker.Sender()
+		releasers = append(releasers, forker.Close)
+	}
+	go func() {
+		s.notifyStart()
+		for item := range s.inputs.Receiver() {
+			if !routeCase(item, senders) && defaults != nil {
+				defaults <- item
+			}
+		}
+		for _, release := range releasers {
+			release()
+		}
+		s.notifyFinish()
+		close(s.done)
+	}()
+}
+
+func routeCase[IN any](item IN, senders []func(IN) bool) bool {
+	for _, send := range senders {
+		if send(item) {
+			return true
+		}
+	}
+	return false
+}
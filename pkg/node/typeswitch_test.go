@@ -0,0 +1,84 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type flowEvent struct{ bytes int }
+type dnsEvent struct{ name string }
+type tcpDrop struct{ reason string }
+
+func (t tcpDrop) String() string { return "drop: " + t.reason }
+
+func TestTypeSwitch(t *testing.T) {
+	start := AsStart(func(out chan<- any) {
+		out <- flowEvent{bytes: 10}
+		out <- dnsEvent{name: "foo.com"}
+		out <- tcpDrop{reason: "oom"}
+		out <- 123
+		out <- flowEvent{bytes: 20}
+		out <- &dnsEvent{name: "bar.com"}
+	})
+	var flows []flowEvent
+	flowsTerm := AsTerminal(func(in <-chan flowEvent) {
+		for f := range in {
+			flows = append(flows, f)
+		}
+	})
+	var dns []dnsEvent
+	dnsTerm := AsTerminal(func(in <-chan dnsEvent) {
+		for d := range in {
+			dns = append(dns, d)
+		}
+	})
+	var stringers []string
+	stringersTerm := AsTerminal(func(in <-chan fmt.Stringer) {
+		for s := range in {
+			stringers = append(stringers, s.String())
+		}
+	})
+	var others []any
+	othersTerm := AsTerminal(func(in <-chan any) {
+		for o := range in {
+			others = append(others, o)
+		}
+	})
+	events := TypeSwitch[any]()
+	Case[flowEvent](events, flowsTerm)
+	Case[dnsEvent](events, dnsTerm)
+	Case[fmt.Stringer](events, stringersTerm).Default(othersTerm)
+	start.SendsTo(events)
+
+	graph := NewGraph(start, events, flowsTerm, dnsTerm, stringersTerm, othersTerm)
+	require.NoError(t, graph.Start())
+	waitDone(t, graph.Done())
+
+	assert.Equal(t, []flowEvent{{bytes: 10}, {bytes: 20}}, flows)
+	assert.Equal(t, []dnsEvent{{name: "foo.com"}}, dns)
+	assert.Equal(t, []string{"drop: oom"}, stringers)
+	assert.Equal(t, []any{123, &dnsEvent{name: "bar.com"}}, others)
+}
+
+func TestTypeSwitch_NoDefault(t *testing.T) {
+	start := AsStart(func(out chan<- any) {
+		out <- "hello"
+		out <- 1
+		out <- "world"
+	})
+	var strs []string
+	strsTerm := AsTerminal(func(in <-chan string) {
+		for s := range in {
+			strs = append(strs, s)
+		}
+	})
+	sw := Case[string](TypeSwitch[any](), strsTerm)
+	start.SendsTo(sw)
+	start.Start()
+
+	waitDone(t, strsTerm.Done())
+	assert.Equal(t, []string{"hello", "world"}, strs)
+}
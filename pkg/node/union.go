@@ -0,0 +1,42 @@
+package node
+
+// Union is a Middle node that forwards the items of two inputs of different types, after
+// converting them to a common type U, so a single stream of U items is produced.
+type Union[A, B, U any] struct {
+	*Middle[U, U]
+	first  *Middle[A, U]
+	second *Middle[B, U]
+}
+
+// Union2 returns a Union node that converts the items received by its First input with wrapA,
+// and the items received by its Second input with wrapB, and forwards them as they arrive. It
+// is a lighter alternative to merging typed streams when they only need to be normalized to a
+// common type (e.g. an interface that both types implement).
+// The upstream nodes must be connected to the inputs returned by the First and Second methods,
+// and the receivers of the Union through its SendsTo method. The node closes its output when
+// both inputs have been closed and all their items have been forwarded.
+// The options are applied to the node that forwards the converted items.
+func Union2[A, B, U any](wrapA func(A) U, wrapB func(B) U, opts ...Option) *Union[A, B, U] {
+	union := &Union[A, B, U]{
+		Middle: AsMiddle(func(in <-chan U, out chan<- U) {
+			for item := range in {
+				out <- item
+			}
+		}, opts...),
+		first:  Map(wrapA),
+		second: Map(wrapB),
+	}
+	union.first.SendsTo(union.Middle)
+	union.second.SendsTo(union.Middle)
+	return union
+}
+
+// First returns the input of the Union for the items of type A
+func (u *Union[A, B, U]) First() Receiver[A] {
+	return u.first
+}
+
+// Second returns the input of the Union for the items of type B
+func (u *Union[A, B, U]) Second() Receiver[B] {
+	return u.second
+}
@@ -0,0 +1,37 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnion2(t *testing.T) {
+	ints := AsStart(Counter(1, 3))
+	strs := AsStart(func(out chan<- string) {
+		for _, s := range []string{"a", "b"} {
+			out <- s
+		}
+	})
+	union := Union2(
+		func(n int) interface{} { return n },
+		func(s string) interface{} { return s },
+	)
+	var received []interface{}
+	term := AsTerminal(func(in <-chan interface{}) {
+		for item := range in {
+			received = append(received, item)
+		}
+	})
+	ints.SendsTo(union.First())
+	strs.SendsTo(union.Second())
+	union.SendsTo(term)
+	ints.Start()
+
+	// the output is not closed until both inputs are closed
+	waitDone(t, ints.Done())
+	assert.False(t, isClosed(term.Done()))
+	strs.Start()
+	waitDone(t, term.Done())
+	assert.ElementsMatch(t, []interface{}{1, 2, 3, "a", "b"}, received)
+}
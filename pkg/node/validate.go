@@ -0,0 +1,37 @@
+package node
+
+import "sync/atomic"
+
+// Validator is a Middle node that forwards the items that pass a validation, and rejects the rest
+type Validator[T any] struct {
+	*Middle[T, T]
+	// allocated separately to guarantee the 64-bit alignment of atomic operations
+	rejected *int64
+}
+
+// Validate returns a Validator node that forwards the received items for which check returns
+// nil, and passes the rest to onInvalid, together with the returned error (e.g. to send them to a
+// dead-letter sink). If onInvalid is nil, the invalid items are discarded.
+// It allows centralizing the validation of the items at the ingestion boundaries of a graph.
+func Validate[T any](check func(T) error, onInvalid func(T, error), opts ...Option) *Validator[T] {
+	rejected := new(int64)
+	return &Validator[T]{
+		Middle: asStepMiddle(func(item T, emit func(T)) {
+			if err := check(item); err != nil {
+				atomic.AddInt64(rejected, 1)
+				if onInvalid != nil {
+					onInvalid(item, err)
+				}
+				return
+			}
+			emit(item)
+		}, opts...),
+		rejected: rejected,
+	}
+}
+
+// Rejected returns the number of items that have not passed the validation. It can be invoked
+// concurrently with the node execution.
+func (v *Validator[T]) Rejected() int64 {
+	return atomic.LoadInt64(v.rejected)
+}
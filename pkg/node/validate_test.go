@@ -0,0 +1,47 @@
+package node
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	start := AsStart(func(out chan<- netEvent) {
+		for _, e := range []netEvent{
+			{Name: "eth0", Bytes: 100},
+			{Name: "", Bytes: 20},
+			{Name: "eth1", Bytes: -5},
+			{Name: "eth2", Bytes: 0},
+		} {
+			out <- e
+		}
+	})
+	var invalid []string
+	validate := Validate(func(e netEvent) error {
+		if e.Name == "" {
+			return errors.New("missing name")
+		}
+		if e.Bytes < 0 {
+			return errors.New("negative bytes")
+		}
+		return nil
+	}, func(e netEvent, err error) {
+		invalid = append(invalid, e.Name+": "+err.Error())
+	})
+	var valid []netEvent
+	term := AsTerminal(func(in <-chan netEvent) {
+		for e := range in {
+			valid = append(valid, e)
+		}
+	})
+	start.SendsTo(validate)
+	validate.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, []netEvent{{Name: "eth0", Bytes: 100}, {Name: "eth2", Bytes: 0}}, valid)
+	assert.Equal(t, []string{": missing name", "eth1: negative bytes"}, invalid)
+	assert.EqualValues(t, 2, validate.Rejected())
+}
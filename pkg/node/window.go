@@ -0,0 +1,179 @@
+package node
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// CountWindow returns a Middle node that aggregates sliding windows of items by count: once the
+// first size items have been received, it sends the aggregation of the last size items every
+// slide received items. If slide is lower than size, the windows overlap. If slide is greater
+// than size, some items are not part of any window.
+// The agg function receives the items of the window in order of arrival. It can retain the
+// passed slice, as a new slice is provided for each window.
+// By default, the items that have not been part of any window when the input is closed are
+// discarded. The node.FlushPartialWindow option sends a last window with the last received items
+// (at most size), if any of them has not been part of a previous window.
+// If the context of the node is cancelled, the node processes the items that were queued in its
+// input, and then behaves as if its input was closed, so the partial window is not lost on
+// shutdown. The rest of the input is discarded.
+func CountWindow[IN, OUT any](size, slide int, agg func([]IN) OUT, opts ...Option) *Middle[IN, OUT] {
+	if size <= 0 || slide <= 0 {
+		panic("window size and slide must be greater than zero")
+	}
+	options := getOptions(opts...)
+	middle := AsMiddleCtx(func(ctx context.Context, in <-chan IN, out chan<- OUT) {
+		ring := make([]IN, 0, size)
+		// position of the oldest item in the ring, once it is full
+		oldest := 0
+		received, lastWindow := 0, 0
+		window := func() []IN {
+			w := make([]IN, 0, len(ring))
+			w = append(w, ring[oldest:]...)
+			return append(w, ring[:oldest]...)
+		}
+		next := receiveCtx(ctx, in)
+		for {
+			item, ok := next()
+			if !ok {
+				break
+			}
+			if len(ring) < size {
+				ring = append(ring, item)
+			} else {
+				ring[oldest] = item
+				oldest = (oldest + 1) % size
+			}
+			received++
+			if received >= size && (received-size)%slide == 0 {
+				out <- agg(window())
+				lastWindow = received
+			}
+		}
+		if options.flushPartialWindow && received > lastWindow {
+			out <- agg(window())
+		}
+	}, opts...)
+	middle.flushOnCancel = true
+	return middle
+}
+
+// EventWindow is a Middle node that aggregates the items in windows of event time. It is
+// created with the node.EventTimeWindow function.
+type EventWindow[IN, OUT any] struct {
+	*Middle[IN, OUT]
+	// allocated separately to guarantee the 64-bit alignment of atomic operations
+	dropped *int64
+}
+
+// eventWindow contains the items of a window, in order of arrival
+type eventWindow[IN any] struct {
+	start time.Time
+	items []IN
+	fired bool
+}
+
+// EventTimeWindow returns an EventWindow node that aggregates the items in tumbling windows of the
+// given size, according to the timestamp that the tsOf function returns for each item (event
+// time) instead of the time when they are received. The windows are aligned to the size (e.g. a
+// window of one minute starts at the beginning of each minute).
+// The node tracks a watermark, which is the newest timestamp that has been received. A window is
+// aggregated and sent once, when the watermark passes its end. The items that arrive out of order
+// are added to their window while it has not been sent. The late items, whose window has already
+// been sent, are accepted while the watermark has not passed the window end plus the allowed
+// lateness: each late item sends again the aggregation of the window with all its items, so the
+// receivers can update the previous result. The items that arrive later are dropped, and counted
+// by the Dropped method.
+// The agg function receives the items of the window in order of arrival. It can retain the
+// passed slice. When the input is closed, the windows that have not been sent yet are sent, in
+// order of event time. The same happens when the context of the node is cancelled, after
+// processing the items that were queued in the input. The rest of the input is discarded.
+func EventTimeWindow[IN, OUT any](
+	tsOf func(IN) time.Time, size, lateness time.Duration, agg func([]IN) OUT, opts ...Option,
+) *EventWindow[IN, OUT] {
+	if size <= 0 {
+		panic("window size must be greater than zero")
+	}
+	if lateness < 0 {
+		panic("window lateness can't be negative")
+	}
+	dropped := new(int64)
+	window := &EventWindow[IN, OUT]{
+		Middle: AsMiddleCtx(func(ctx context.Context, in <-chan IN, out chan<- OUT) {
+			windows := map[int64]*eventWindow[IN]{}
+			var watermark time.Time
+			send := func(w *eventWindow[IN]) {
+				w.fired = true
+				items := make([]IN, len(w.items))
+				copy(items, w.items)
+				out <- agg(items)
+			}
+			next := receiveCtx(ctx, in)
+			for {
+				item, ok := next()
+				if !ok {
+					break
+				}
+				ts := tsOf(item)
+				if ts.After(watermark) {
+					watermark = ts
+				}
+				start := ts.Truncate(size)
+				if !start.Add(size + lateness).After(watermark) {
+					atomic.AddInt64(dropped, 1)
+					continue
+				}
+				w, ok := windows[start.UnixNano()]
+				if !ok {
+					w = &eventWindow[IN]{start: start}
+					windows[start.UnixNano()] = w
+				}
+				w.items = append(w.items, item)
+				if w.fired {
+					send(w)
+				}
+				// send the windows that the watermark has passed, and forget the windows
+				// that can't accept late items anymore
+				for _, w := range sortedWindows(windows) {
+					end := w.start.Add(size)
+					if end.After(watermark) {
+						break
+					}
+					if !w.fired {
+						send(w)
+					}
+					if !end.Add(lateness).After(watermark) {
+						delete(windows, w.start.UnixNano())
+					}
+				}
+			}
+			for _, w := range sortedWindows(windows) {
+				if !w.fired {
+					send(w)
+				}
+			}
+		}, opts...),
+		dropped: dropped,
+	}
+	window.flushOnCancel = true
+	return window
+}
+
+func sortedWindows[IN any](windows map[int64]*eventWindow[IN]) []*eventWindow[IN] {
+	sorted := make([]*eventWindow[IN], 0, len(windows))
+	for _, w := range windows {
+		sorted = append(sorted, w)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].start.Before(sorted[j].start)
+	})
+	return sorted
+}
+
+// Dropped returns the number of items that have been received after the allowed lateness of their
+// window. It can be invoked concurrently with the node execution.
+func (w *EventWindow[IN, OUT]) Dropped() int64 {
+	return atomic.LoadInt64(w.dropped)
+}
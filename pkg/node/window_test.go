@@ -0,0 +1,188 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sum(items []int) int {
+	s := 0
+	for _, n := range items {
+		s += n
+	}
+	return s
+}
+
+func runCountWindow(t *testing.T, from, to int, window *Middle[int, []int]) [][]int {
+	t.Helper()
+	var windows [][]int
+	start := AsStart(Counter(from, to))
+	term := AsTerminal(func(in <-chan []int) {
+		for w := range in {
+			windows = append(windows, w)
+		}
+	})
+	start.SendsTo(window)
+	window.SendsTo(term)
+	start.Start()
+	waitDone(t, term.Done())
+	return windows
+}
+
+// runCancelled sends the items to the node, and cancels the graph context afterwards without
+// closing the input of the node. The node is connected to two receivers, and it returns the
+// items that each of them has received.
+func runCancelled[IN, OUT any](t *testing.T, node *Middle[IN, OUT], items ...IN) [2][]OUT {
+	t.Helper()
+	release := make(chan struct{})
+	defer close(release)
+	start := AsStartCtx(func(_ context.Context, out chan<- IN) {
+		for _, item := range items {
+			out <- item
+		}
+		<-release
+	}, CountEdges())
+	var forwarded [2][]OUT
+	var terms [2]*Terminal[OUT]
+	for i := range forwarded {
+		received := &forwarded[i]
+		terms[i] = AsTerminal(func(in <-chan OUT) {
+			for item := range in {
+				*received = append(*received, item)
+			}
+		})
+	}
+	start.SendsTo(node)
+	node.SendsTo(terms[0], terms[1])
+	ctx, cancel := context.WithCancel(context.Background())
+	start.StartCtx(ctx)
+	// the items may still be queued in the input of the node when the context is cancelled
+	require.Eventually(t, func() bool {
+		return start.Stats().Delivered == int64(len(items))
+	}, timeout, time.Millisecond)
+	cancel()
+	for _, term := range terms {
+		waitDone(t, term.Done())
+	}
+	return forwarded
+}
+
+func identity(items []int) []int {
+	return items
+}
+
+func TestCountWindow(t *testing.T) {
+	assert.Equal(t, [][]int{{1, 2, 3}, {3, 4, 5}, {5, 6, 7}},
+		runCountWindow(t, 1, 8, CountWindow(3, 2, identity)))
+	assert.Equal(t, [][]int{{1, 2}, {2, 3}, {3, 4}},
+		runCountWindow(t, 1, 4, CountWindow(2, 1, identity)))
+	// non-overlapping windows
+	assert.Equal(t, [][]int{{1, 2}, {5, 6}},
+		runCountWindow(t, 1, 7, CountWindow(2, 4, identity)))
+	// not enough items for a window
+	assert.Empty(t, runCountWindow(t, 1, 2, CountWindow(3, 1, identity)))
+}
+
+func TestCountWindow_FlushPartialWindow(t *testing.T) {
+	assert.Equal(t, [][]int{{1, 2, 3}, {3, 4, 5}, {5, 6, 7}, {6, 7, 8}},
+		runCountWindow(t, 1, 8, CountWindow(3, 2, identity, FlushPartialWindow())))
+	assert.Equal(t, [][]int{{1, 2, 3}, {3, 4, 5}},
+		runCountWindow(t, 1, 5, CountWindow(3, 2, identity, FlushPartialWindow())))
+	assert.Equal(t, [][]int{{1, 2}},
+		runCountWindow(t, 1, 2, CountWindow(3, 1, identity, FlushPartialWindow())))
+}
+
+func TestCountWindow_Cancel(t *testing.T) {
+	flushed := [][]int{{1, 2, 3}, {3, 4, 5}}
+	assert.Equal(t, [2][][]int{flushed, flushed},
+		runCancelled(t, CountWindow(3, 3, identity, FlushPartialWindow(), ChannelBufferLen(10)), 1, 2, 3, 4, 5))
+	unflushed := [][]int{{1, 2, 3}}
+	assert.Equal(t, [2][][]int{unflushed, unflushed},
+		runCancelled(t, CountWindow(3, 3, identity, ChannelBufferLen(10)), 1, 2, 3, 4, 5))
+}
+
+func TestCountWindow_MovingAverage(t *testing.T) {
+	start := AsStart(Counter(1, 6))
+	avg := CountWindow(3, 1, func(items []int) float64 {
+		return float64(sum(items)) / float64(len(items))
+	})
+	var averages []float64
+	term := AsTerminal(func(in <-chan float64) {
+		for a := range in {
+			averages = append(averages, a)
+		}
+	})
+	start.SendsTo(avg)
+	avg.SendsTo(term)
+	start.Start()
+	waitDone(t, term.Done())
+	assert.Equal(t, []float64{2, 3, 4, 5}, averages)
+}
+
+func TestEventTimeWindow(t *testing.T) {
+	// each item is its timestamp, in seconds
+	start := AsStart(func(out chan<- int) {
+		for _, ts := range []int{1, 3, 12, 5, 14, 21, 7, 18, 25} {
+			out <- ts
+		}
+	})
+	window := EventTimeWindow(func(ts int) time.Time {
+		return time.Unix(int64(ts), 0)
+	}, 10*time.Second, 5*time.Second, identity)
+	var windows [][]int
+	term := AsTerminal(func(in <-chan []int) {
+		for w := range in {
+			windows = append(windows, w)
+		}
+	})
+	start.SendsTo(window)
+	window.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	assert.Equal(t, [][]int{
+		// the watermark passes the end of the first window
+		{1, 3},
+		// late item, within the allowed lateness
+		{1, 3, 5},
+		{12, 14},
+		{12, 14, 18},
+		// window that is flushed when the input is closed
+		{21, 25},
+	}, windows)
+	// the item 7 arrived when the watermark had passed the end of its window plus the lateness
+	assert.EqualValues(t, 1, window.Dropped())
+}
+
+func TestEventTimeWindow_NoLateness(t *testing.T) {
+	start := AsStart(func(out chan<- int) {
+		for _, ts := range []int{2, 1, 10, 9, 11, 30} {
+			out <- ts
+		}
+	})
+	window := EventTimeWindow(func(ts int) time.Time {
+		return time.Unix(int64(ts), 0)
+	}, 10*time.Second, 0, sum)
+	var sums []int
+	term := collectInts(&sums)
+	start.SendsTo(window)
+	window.SendsTo(term)
+	start.Start()
+
+	waitDone(t, term.Done())
+	// the empty window between 20 and 30 is not sent
+	assert.Equal(t, []int{3, 21, 30}, sums)
+	assert.EqualValues(t, 1, window.Dropped())
+}
+
+func TestEventTimeWindow_Cancel(t *testing.T) {
+	begin := time.Date(2022, 3, 4, 12, 0, 0, 0, time.UTC)
+	window := EventTimeWindow(func(ts time.Time) time.Time { return ts },
+		time.Minute, 0, func(items []time.Time) int { return len(items) }, ChannelBufferLen(10))
+	assert.Equal(t, [2][]int{{2, 1}, {2, 1}}, runCancelled(t, window.Middle,
+		begin, begin.Add(time.Second), begin.Add(time.Minute)))
+}
@@ -0,0 +1,184 @@
+package node
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/netobserv/gopipes/pkg/node/internal/connect"
+)
+
+// Pair groups two items of possibly different types
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// zipped is an item received by the Zip node from any of its sides, or the notification that the
+// input of a side has been closed
+type zipped[A, B any] struct {
+	pair   Pair[A, B]
+	second bool
+	end    bool
+}
+
+// Zipper is a node that pairs positionally the items of two senders, as created by node.Zip. It
+// can be connected to other nodes as the output of any Start or Middle node.
+type Zipper[A, B any] struct {
+	zip *Middle[zipped[A, B], Pair[A, B]]
+}
+
+// Zip returns a Zipper node that pairs positionally the items sent by the a and b nodes: the
+// i-th item sent by a is paired with the i-th item sent by b, and forwarded as a Pair.
+// The node closes its output when any of the senders has closed its output and all its items
+// have been paired, so if the senders send a different number of items, the exceeding items of
+// the longer stream are discarded.
+// The items of the faster sender wait to be paired with the items of the slower one. Up to
+// the buffer length of the node.ChannelBufferLen option (1 by default) items wait per sender.
+// Beyond them, the faster sender is backpressured.
+func Zip[A, B any](a Sender[A], b Sender[B], opts ...Option) *Zipper[A, B] {
+	maxPending := getOptions(opts...).channelBufferLen
+	if maxPending < 1 {
+		maxPending = 1
+	}
+	// each waiting item of a side holds a credit until it is paired
+	firstCredits := make(chan struct{}, maxPending)
+	secondCredits := make(chan struct{}, maxPending)
+	// closed when no more pairs can be formed, so the sides discard their items
+	stopped := make(chan struct{})
+	acquire := func(credits chan<- struct{}) bool {
+		select {
+		case credits <- struct{}{}:
+			return true
+		case <-stopped:
+			return false
+		}
+	}
+	zip := AsMiddle(func(in <-chan zipped[A, B], out chan<- Pair[A, B]) {
+		var firsts []A
+		var seconds []B
+		firstEnded, secondEnded := false, false
+		for item := range in {
+			switch {
+			case item.end && item.second:
+				secondEnded = true
+			case item.end:
+				firstEnded = true
+			case item.second:
+				seconds = append(seconds, item.pair.Second)
+			default:
+				firsts = append(firsts, item.pair.First)
+			}
+			if len(firsts) > 0 && len(seconds) > 0 {
+				out <- Pair[A, B]{First: firsts[0], Second: seconds[0]}
+				firsts, seconds = firsts[1:], seconds[1:]
+				<-firstCredits
+				<-secondCredits
+			}
+			if (firstEnded && len(firsts) == 0) || (secondEnded && len(seconds) == 0) {
+				// no more pairs can be formed. Returning closes the output, and the node discards
+				// the exceeding items
+				close(stopped)
+				return
+			}
+		}
+	}, opts...)
+	firsts := AsMiddle(func(in <-chan A, out chan<- zipped[A, B]) {
+		for item := range in {
+			if acquire(firstCredits) {
+				out <- zipped[A, B]{pair: Pair[A, B]{First: item}}
+			}
+		}
+	})
+	firsts.OnEnd(func() zipped[A, B] {
+		return zipped[A, B]{end: true}
+	})
+	seconds := AsMiddle(func(in <-chan B, out chan<- zipped[A, B]) {
+		for item := range in {
+			if acquire(secondCredits) {
+				out <- zipped[A, B]{pair: Pair[A, B]{Second: item}, second: true}
+			}
+		}
+	})
+	seconds.OnEnd(func() zipped[A, B] {
+		return zipped[A, B]{second: true, end: true}
+	})
+	zipper := &Zipper[A, B]{zip: zip}
+	// the sides send to the Zipper instead of to its inner node, so the Zipper is the node that
+	// is part of the graph topology
+	firsts.SendsTo(zipper)
+	seconds.SendsTo(zipper)
+	a.SendsTo(firsts)
+	b.SendsTo(seconds)
+	return zipper
+}
+
+// SendsTo connects the output of the Zipper with a group of receivers
+func (z *Zipper[A, B]) SendsTo(outputs ...Receiver[Pair[A, B]]) {
+	z.zip.SendsTo(outputs...)
+}
+
+// OutType returns the inner type of the Zipper output channel
+func (z *Zipper[A, B]) OutType() reflect.Type {
+	return z.zip.OutType()
+}
+
+// Name of the node, as provided by the node.WithName option
+func (z *Zipper[A, B]) Name() string {
+	return z.zip.Name()
+}
+
+// Kind returns KindMiddle
+func (z *Zipper[A, B]) Kind() NodeKind {
+	return KindMiddle
+}
+
+// InType returns the inner type of the Zipper input channel, which receives the items of both
+// senders
+func (z *Zipper[A, B]) InType() reflect.Type {
+	return z.zip.InType()
+}
+
+// Schema returns the types of the items that the Zipper receives from both senders, and sends
+func (z *Zipper[A, B]) Schema() Schema {
+	return z.zip.Schema()
+}
+
+// Stats returns runtime information about the Zipper
+func (z *Zipper[A, B]) Stats() Stats {
+	return z.zip.Stats()
+}
+
+// Labels returns the labels of the Zipper, as provided by the node.WithLabels option
+func (z *Zipper[A, B]) Labels() map[string]string {
+	return z.zip.Labels()
+}
+
+// Done returns a channel that is closed when the Zipper has closed its output
+func (z *Zipper[A, B]) Done() <-chan struct{} {
+	return z.zip.Done()
+}
+
+// State returns the stage of the lifecycle of the Zipper
+func (z *Zipper[A, B]) State() NodeState {
+	return z.zip.State()
+}
+
+func (z *Zipper[A, B]) meta() *nodeMeta {
+	return z.zip.meta()
+}
+
+func (z *Zipper[A, B]) outputs() []graphNode {
+	return z.zip.outputs()
+}
+
+func (z *Zipper[A, B]) isStarted() bool {
+	return z.zip.isStarted()
+}
+
+func (z *Zipper[A, B]) start(ctx context.Context) {
+	z.zip.start(ctx)
+}
+
+func (z *Zipper[A, B]) joiner() *connect.Joiner[zipped[A, B]] {
+	return z.zip.joiner()
+}
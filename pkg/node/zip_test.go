@@ -0,0 +1,80 @@
+package node
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/netobserv/gopipes/pkg/node/nodetest"
+)
+
+func TestZip(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		nums      int
+		strs      int
+		wantPairs int
+	}{
+		{name: "same length", nums: 4, strs: 4, wantPairs: 4},
+		{name: "shorter first", nums: 2, strs: 5, wantPairs: 2},
+		{name: "shorter second", nums: 5, strs: 3, wantPairs: 3},
+		{name: "empty", nums: 0, strs: 3, wantPairs: 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			nums := Range(1, tc.nums, 1)
+			strs := Generate(tc.strs, func(i int) string { return fmt.Sprint("s", i+1) })
+			zip := Zip[int, string](nums, strs)
+			var received []Pair[int, string]
+			term := AsTerminal(func(in <-chan Pair[int, string]) {
+				for p := range in {
+					received = append(received, p)
+				}
+			})
+			zip.SendsTo(term)
+			nums.Start()
+			strs.Start()
+
+			waitDone(t, term.Done())
+			// the senders are not blocked by the discarded items
+			waitDone(t, nums.Done())
+			waitDone(t, strs.Done())
+			var expected []Pair[int, string]
+			for i := 1; i <= tc.wantPairs; i++ {
+				expected = append(expected, Pair[int, string]{First: i, Second: fmt.Sprint("s", i)})
+			}
+			assert.Equal(t, expected, received)
+		})
+	}
+}
+
+func TestZip_Backpressure(t *testing.T) {
+	nums := Range(1, 10, 1)
+	release := make(chan struct{})
+	strs := AsStart(func(out chan<- string) {
+		<-release
+		for i := 1; i <= 10; i++ {
+			out <- fmt.Sprint("s", i)
+		}
+	})
+	zip := Zip[int, string](nums, strs, ChannelBufferLen(2))
+	var received []Pair[int, string]
+	term := AsTerminal(func(in <-chan Pair[int, string]) {
+		for p := range in {
+			received = append(received, p)
+		}
+	})
+	zip.SendsTo(term)
+	graph := NewGraph(nums, strs, zip, term)
+	require.NoError(t, graph.Start())
+
+	// the faster sender is blocked while its items can't be paired
+	nodetest.ExpectBlocked(t, nums.Done())
+	assert.LessOrEqual(t, zip.Stats().BufferLen, 2)
+
+	close(release)
+	waitDone(t, graph.Done())
+	require.Len(t, received, 10)
+	assert.Equal(t, Pair[int, string]{First: 10, Second: "s10"}, received[9])
+}